@@ -0,0 +1,35 @@
+package inverseschema
+
+import (
+	"strings"
+)
+
+// commentTags extracts annotations of the form "@name" or "@name:value" from a comment,
+// any other text in the comment is ignored
+func commentTags(comment string) map[string][]string {
+	tags := map[string][]string{}
+	for _, field := range strings.Fields(comment) {
+		if len(field) < 2 || field[0] != '@' {
+			continue
+		}
+		name := field[1:]
+		value := ""
+		if idx := strings.Index(name, ":"); idx >= 0 {
+			value = name[idx+1:]
+			name = name[:idx]
+		}
+		if len(name) == 0 {
+			continue
+		}
+		tags[name] = append(tags[name], value)
+	}
+	return tags
+}
+
+func commentTag(comment string, name string) (string, bool) {
+	values, ok := commentTags(comment)[name]
+	if !ok {
+		return "", false
+	}
+	return values[0], true
+}
@@ -0,0 +1,187 @@
+package inverseschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+var avroDatatypes = map[string]Datatype{
+	"string":  DatatypeText,
+	"int":     DatatypeInt,
+	"long":    DatatypeBigint,
+	"boolean": DatatypeBoolean,
+	"bytes":   DatatypeBytea,
+	"fixed":   DatatypeBytea,
+	"map":     DatatypeJsonb,
+}
+
+var avroLogicalDatatypes = map[string]Datatype{
+	"date":                   DatatypeDate,
+	"timestamp-millis":       DatatypeTimestampz,
+	"timestamp-micros":       DatatypeTimestampz,
+	"local-timestamp-millis": DatatypeTimestamp,
+	"local-timestamp-micros": DatatypeTimestamp,
+	"uuid":                   DatatypeUuid,
+	"decimal":                DatatypeNumeric,
+}
+
+type avroSchema struct {
+	Type        json.RawMessage `json:"type"`
+	Name        string          `json:"name"`
+	Namespace   string          `json:"namespace"`
+	Doc         string          `json:"doc"`
+	Fields      []avroField     `json:"fields"`
+	Symbols     []string        `json:"symbols"`
+	Items       json.RawMessage `json:"items"`
+	LogicalType string          `json:"logicalType"`
+}
+
+type avroField struct {
+	Name    string          `json:"name"`
+	Type    json.RawMessage `json:"type"`
+	Doc     string          `json:"doc"`
+	Default json.RawMessage `json:"default"`
+}
+
+// avroParser converts an Avro schema, named records, enums and fixed types may be referenced by name once defined
+type avroParser struct {
+	named map[string]avroSchema
+	enums []Enum
+}
+
+func (p *avroParser) define(schema avroSchema) {
+	if len(schema.Name) == 0 {
+		return
+	}
+	p.named[schema.Name] = schema
+	if idx := strings.LastIndex(schema.Name, "."); idx >= 0 {
+		p.named[schema.Name[idx+1:]] = schema
+	} else if len(schema.Namespace) > 0 {
+		p.named[schema.Namespace+"."+schema.Name] = schema
+	}
+	if string(schema.Type) == `"enum"` {
+		enum := Enum{Name: schema.Name, Comments: schema.Doc}
+		for i, symbol := range schema.Symbols {
+			enum.Values = append(enum.Values, EnumValue{Label: symbol, Order: i + 1})
+		}
+		p.enums = append(p.enums, enum)
+	}
+}
+
+// typeOf applies an Avro type to a column, unions with null make the column nullable
+func (p *avroParser) typeOf(col *Column, raw json.RawMessage) error {
+	var name string
+	if err := json.Unmarshal(raw, &name); err == nil {
+		if named, ok := p.named[name]; ok {
+			return p.schemaOf(col, named)
+		}
+		col.DatatypeRaw = name
+		col.Datatype = avroDatatypes[name]
+		return nil
+	}
+
+	var union []json.RawMessage
+	if err := json.Unmarshal(raw, &union); err == nil {
+		branches := []json.RawMessage{}
+		for _, branch := range union {
+			if string(branch) == `"null"` {
+				col.IsNullable = true
+				continue
+			}
+			branches = append(branches, branch)
+		}
+		if len(branches) == 1 {
+			return p.typeOf(col, branches[0])
+		}
+		col.DatatypeRaw = "union"
+		col.Datatype = DatatypeJsonb
+		return nil
+	}
+
+	schema := avroSchema{}
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		return err
+	}
+	p.define(schema)
+	return p.schemaOf(col, schema)
+}
+
+func (p *avroParser) schemaOf(col *Column, schema avroSchema) error {
+	var typ string
+	if err := json.Unmarshal(schema.Type, &typ); err != nil {
+		// {"type": {...}} wraps another type
+		return p.typeOf(col, schema.Type)
+	}
+	switch typ {
+	case "record":
+		col.DatatypeRaw = schema.Name
+		col.Datatype = DatatypeJsonb
+		fields, err := p.fields(schema.Fields)
+		if err != nil {
+			return err
+		}
+		col.Fields = fields
+	case "enum":
+		col.DatatypeRaw = "USER-DEFINED"
+		col.Datatype = DatatypeUserdefined
+		col.IsUserDefined = true
+		col.UserDefinedType = &UserDefinedType{Name: schema.Name, Schema: schema.Namespace}
+	case "array":
+		if col.IsArray {
+			col.DatatypeRaw = "array"
+			return nil
+		}
+		col.IsArray = true
+		return p.typeOf(col, schema.Items)
+	default:
+		col.DatatypeRaw = typ
+		col.Datatype = avroDatatypes[typ]
+		if datatype, ok := avroLogicalDatatypes[schema.LogicalType]; ok {
+			col.DatatypeRaw = schema.LogicalType
+			col.Datatype = datatype
+		}
+	}
+	return nil
+}
+
+func (p *avroParser) fields(fields []avroField) ([]Column, error) {
+	cols := []Column{}
+	for i, field := range fields {
+		col := Column{OrdinalPosition: i + 1, Name: field.Name, Comments: field.Doc}
+		if err := p.typeOf(&col, field.Type); err != nil {
+			return nil, fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		if len(field.Default) > 0 {
+			col.HasDefault = true
+			col.Default = string(field.Default)
+		}
+		cols = append(cols, col)
+	}
+	return cols, nil
+}
+
+// avroTable maps an Avro record schema to a table, its enums are returned alongside
+func avroTable(name string, definition string) (Table, []Enum, error) {
+	table := Table{Name: name, Columns: []Column{}}
+	schema := avroSchema{}
+	if err := json.Unmarshal([]byte(definition), &schema); err != nil {
+		return table, nil, fmt.Errorf("avro schema %s: %w", name, err)
+	}
+	if string(schema.Type) != `"record"` {
+		return table, nil, fmt.Errorf("avro schema %s: top level type is not a record", name)
+	}
+	p := &avroParser{named: map[string]avroSchema{}}
+	p.define(schema)
+	cols, err := p.fields(schema.Fields)
+	if err != nil {
+		return table, nil, fmt.Errorf("avro schema %s: %w", name, err)
+	}
+	table.Comments = schema.Doc
+	table.Columns = cols
+	table.ColumnsByName = make(map[string]Column, len(cols))
+	for _, col := range cols {
+		table.ColumnsByName[col.Name] = col
+	}
+	return table, p.enums, nil
+}
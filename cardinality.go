@@ -0,0 +1,62 @@
+package inverseschema
+
+type Cardinality string
+
+const (
+	CardinalityOneToOne   Cardinality = "one-to-one"
+	CardinalityManyToOne  Cardinality = "many-to-one"
+	CardinalityManyToMany Cardinality = "many-to-many"
+)
+
+// constraintColumns counts the columns of a table covered by a constraint
+func constraintColumns(table Table, name string) int {
+	n := 0
+	for _, col := range table.Columns {
+		for _, c := range col.Constraints {
+			if c.Name == name {
+				n++
+				break
+			}
+		}
+	}
+	return n
+}
+
+// uniqueColumn tells whether a column is unique on its own: a single column primary key or unique constraint, or a
+// unique index without predicate on the column alone
+func uniqueColumn(table Table, col Column) bool {
+	for _, c := range col.Constraints {
+		if (c.Type == ConstraintTypePrimaryKey || c.Type == ConstraintTypeUnique) && constraintColumns(table, c.Name) == 1 {
+			return true
+		}
+	}
+	for _, index := range table.Indexes {
+		if index.IsUnique && len(index.Predicate) == 0 && len(index.Columns) == 1 && (index.Columns[0] == col.Name || index.Columns[0] == quoteIdent(col.Name)) {
+			return true
+		}
+	}
+	// sources without constraints nor indexes only report the flags
+	if col.Constraints == nil && table.Indexes == nil {
+		return col.IsUnique || (col.IsPrimary && len(primaryKeyColumns(table)) == 1)
+	}
+	return false
+}
+
+func primaryKeyColumns(table Table) []string {
+	names := []string{}
+	for _, col := range table.Columns {
+		if col.IsPrimary {
+			names = append(names, col.Name)
+		}
+	}
+	return names
+}
+
+// referenceCardinality classifies the foreign key of a column from the referencing side: one-to-one when the column
+// is unique on its own, many-to-one otherwise, the relationship being optional when the column is nullable
+func referenceCardinality(table Table, col Column) (Cardinality, bool) {
+	if uniqueColumn(table, col) {
+		return CardinalityOneToOne, col.IsNullable
+	}
+	return CardinalityManyToOne, col.IsNullable
+}
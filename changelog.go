@@ -0,0 +1,103 @@
+package inverseschema
+
+import (
+	"io"
+	"strings"
+)
+
+// changelogColumn turns a column signature into the "type, nullable" form of changelog entries
+func changelogColumn(signature string) string {
+	if strings.Contains(signature, " NOT NULL") {
+		return strings.Replace(signature, " NOT NULL", "", 1) + ", not null"
+	}
+	return signature + ", nullable"
+}
+
+// changelogEntry describes a change in a sentence
+func changelogEntry(change Change) string {
+	column := "`" + change.Tablename + "." + change.Columnname + "`"
+	value := "`" + change.Label + "`"
+	enum := "`" + change.Enumname + "`"
+	switch change.Kind {
+	case ChangeTableAdded:
+		return "Added table `" + change.Tablename + "`"
+	case ChangeTableRemoved:
+		return "Removed table `" + change.Tablename + "`"
+	case ChangeTableRenamed:
+		return "Renamed table `" + change.From + "` to `" + change.Tablename + "`"
+	case ChangeColumnAdded:
+		return "Added column " + column + " (" + changelogColumn(change.To) + ")"
+	case ChangeColumnRemoved:
+		return "Removed column " + column + " (" + changelogColumn(change.From) + ")"
+	case ChangeColumnRenamed:
+		return "Renamed column `" + change.Tablename + "." + change.From + "` to " + column
+	case ChangeColumnWidened, ChangeColumnNarrowed, ChangeColumnTypeChanged:
+		return "Changed the type of " + column + ": " + change.Reason
+	case ChangeColumnNullable:
+		return "Made " + column + " nullable"
+	case ChangeColumnNotNull:
+		return "Made " + column + " NOT NULL"
+	case ChangeColumnDefault:
+		return "Changed the default of " + column
+	case ChangeColumnReference:
+		return "Changed the reference of " + column + " (" + changelogColumn(change.To) + ")"
+	case ChangeEnumAdded:
+		return "Added enum " + enum
+	case ChangeEnumRemoved:
+		return "Removed enum " + enum
+	case ChangeEnumValueAdded:
+		return "Added value " + value + " to enum " + enum
+	case ChangeEnumValueInserted:
+		return "Inserted value " + value + " into enum " + enum + " before existing values"
+	case ChangeEnumValueRemoved:
+		return "Removed value " + value + " from enum " + enum
+	case ChangeEnumValueRenamed:
+		return "Renamed value " + value + " of enum " + enum + " to `" + change.To + "`"
+	case ChangeEnumValueReordered:
+		return "Moved value " + value + " of enum " + enum
+	}
+	return string(change.Kind)
+}
+
+// WriteChangelog renders the changes as a markdown CHANGELOG section titled title, grouped by table then by enum,
+// breaking and potentially breaking changes are marked as such
+func (c *ChangeSet) WriteChangelog(w io.Writer, title string) error {
+	groups := []string{}
+	entries := map[string][]string{}
+	for _, change := range c.Changes {
+		group := "Table `" + change.Tablename + "`"
+		if len(change.Enumname) > 0 {
+			group = "Enum `" + change.Enumname + "`"
+		}
+		if _, ok := entries[group]; !ok {
+			groups = append(groups, group)
+		}
+		entry := changelogEntry(change)
+		switch change.Severity {
+		case SeverityBreaking:
+			entry = "**Breaking:** " + entry
+		case SeverityPotentiallyBreaking:
+			entry = "**Potentially breaking:** " + entry
+		}
+		entries[group] = append(entries[group], entry)
+	}
+
+	var b strings.Builder
+	if len(title) > 0 {
+		b.WriteString("## " + title + "\n\n")
+	}
+	if len(groups) == 0 {
+		b.WriteString("No schema changes.\n")
+	}
+	for i, group := range groups {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString("### " + group + "\n\n")
+		for _, entry := range entries[group] {
+			b.WriteString("- " + entry + "\n")
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,302 @@
+package inverseschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+)
+
+type ChangeKind string
+
+const (
+	ChangeTableAdded         ChangeKind = "table_added"
+	ChangeTableRemoved       ChangeKind = "table_removed"
+	ChangeTableRenamed       ChangeKind = "table_renamed"
+	ChangeColumnAdded        ChangeKind = "column_added"
+	ChangeColumnRemoved      ChangeKind = "column_removed"
+	ChangeColumnRenamed      ChangeKind = "column_renamed"
+	ChangeColumnWidened      ChangeKind = "column_widened"
+	ChangeColumnNarrowed     ChangeKind = "column_narrowed"
+	ChangeColumnTypeChanged  ChangeKind = "column_type_changed"
+	ChangeColumnNullable     ChangeKind = "column_nullable"
+	ChangeColumnNotNull      ChangeKind = "column_not_null"
+	ChangeColumnDefault      ChangeKind = "column_default"
+	ChangeColumnReference    ChangeKind = "column_reference"
+	ChangeEnumAdded          ChangeKind = "enum_added"
+	ChangeEnumRemoved        ChangeKind = "enum_removed"
+	ChangeEnumValueAdded     ChangeKind = "enum_value_added"
+	ChangeEnumValueInserted  ChangeKind = "enum_value_inserted"
+	ChangeEnumValueRemoved   ChangeKind = "enum_value_removed"
+	ChangeEnumValueRenamed   ChangeKind = "enum_value_renamed"
+	ChangeEnumValueReordered ChangeKind = "enum_value_reordered"
+)
+
+// ChangeSeverity rates a change for the consumers of an API generated from the schema
+type ChangeSeverity string
+
+const (
+	SeveritySafe                ChangeSeverity = "safe"
+	SeverityPotentiallyBreaking ChangeSeverity = "potentially_breaking"
+	SeverityBreaking            ChangeSeverity = "breaking"
+)
+
+// defaultSeverities rates the kinds of changes, enum value removals are rated on their own as removing a deprecated
+// value is only potentially breaking
+var defaultSeverities = map[ChangeKind]ChangeSeverity{
+	ChangeTableAdded:         SeveritySafe,
+	ChangeTableRemoved:       SeverityBreaking,
+	ChangeTableRenamed:       SeverityBreaking,
+	ChangeColumnRemoved:      SeverityBreaking,
+	ChangeColumnRenamed:      SeverityBreaking,
+	ChangeColumnWidened:      SeveritySafe,
+	ChangeColumnNarrowed:     SeverityBreaking,
+	ChangeColumnTypeChanged:  SeverityBreaking,
+	ChangeColumnNullable:     SeverityPotentiallyBreaking,
+	ChangeColumnNotNull:      SeverityBreaking,
+	ChangeColumnDefault:      SeveritySafe,
+	ChangeColumnReference:    SeverityPotentiallyBreaking,
+	ChangeEnumAdded:          SeveritySafe,
+	ChangeEnumRemoved:        SeverityBreaking,
+	ChangeEnumValueAdded:     SeveritySafe,
+	ChangeEnumValueInserted:  SeverityPotentiallyBreaking,
+	ChangeEnumValueRenamed:   SeverityBreaking,
+	ChangeEnumValueReordered: SeverityPotentiallyBreaking,
+}
+
+// Change is a difference between a previous and a current schema, From and To hold the column signatures or enum
+// labels involved, From holds the previous name of renamed tables and columns
+type Change struct {
+	Kind       ChangeKind     `json:"kind,omitempty"`
+	Severity   ChangeSeverity `json:"severity,omitempty"`
+	Tablename  string         `json:"tablename,omitempty"`
+	Columnname string         `json:"columnname,omitempty"`
+	Enumname   string         `json:"enumname,omitempty"`
+	Label      string         `json:"label,omitempty"`
+	From       string         `json:"from,omitempty"`
+	To         string         `json:"to,omitempty"`
+	Reason     string         `json:"reason,omitempty"`
+}
+
+type ChangeSet struct {
+	Changes []Change `json:"changes,omitempty"`
+}
+
+// ChangePolicy customizes the classification: Severities overrides the severity of kinds of changes, Ignore lists
+// path.Match patterns of tables and enums whose changes are left out and Renames declares the tables and columns
+// renamed since the previous schema, keyed by their previous names, which are otherwise seen as removed and added
+type ChangePolicy struct {
+	Severities map[ChangeKind]ChangeSeverity `json:"severities,omitempty"`
+	Ignore     []string                      `json:"ignore,omitempty"`
+	Renames    Renames                       `json:"renames,omitempty"`
+}
+
+// LoadChangePolicy reads a JSON change policy, e.g. {"severities": {"column_nullable": "safe"}, "ignore": ["tmp_*"]}
+func LoadChangePolicy(r io.Reader) (*ChangePolicy, error) {
+	policy := &ChangePolicy{}
+	if err := json.NewDecoder(r).Decode(policy); err != nil {
+		return nil, err
+	}
+	for kind, severity := range policy.Severities {
+		switch severity {
+		case SeveritySafe, SeverityPotentiallyBreaking, SeverityBreaking:
+		default:
+			return nil, fmt.Errorf("invalid severity %q for %s", severity, kind)
+		}
+	}
+	return policy, nil
+}
+
+func (p *ChangePolicy) ignores(name string) bool {
+	for _, pattern := range p.Ignore {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// columnAddedSeverity rates an added column, inserts of existing clients fail when it requires a value
+func columnAddedSeverity(col Column) ChangeSeverity {
+	if col.IsNullable || col.HasDefault || col.IsIdentity || col.IsGenerated {
+		return SeveritySafe
+	}
+	return SeverityBreaking
+}
+
+// columnChanges compares two versions of a column
+func columnChanges(tablename string, from Column, to Column) []Change {
+	changes := []Change{}
+	change := func(kind ChangeKind, reason string) {
+		changes = append(changes, Change{
+			Kind:       kind,
+			Tablename:  tablename,
+			Columnname: to.Name,
+			From:       columnSignature(from),
+			To:         columnSignature(to),
+			Reason:     reason,
+		})
+	}
+	if reason, ok := columnsCompatible(from, to); !ok {
+		if _, wider := lineageCompatible(from, to); wider {
+			change(ChangeColumnWidened, fmt.Sprintf("%s widened to %s", postgresColumnType(from), postgresColumnType(to)))
+		} else if _, narrower := narrowingCondition(from, to); narrower {
+			change(ChangeColumnNarrowed, fmt.Sprintf("%s narrowed to %s, existing values and clients may not fit", postgresColumnType(from), postgresColumnType(to)))
+		} else {
+			change(ChangeColumnTypeChanged, reason)
+		}
+	} else if len(from.FormattedType) > 0 && len(to.FormattedType) > 0 && from.FormattedType != to.FormattedType {
+		change(ChangeColumnTypeChanged, fmt.Sprintf("%s changed to %s", from.FormattedType, to.FormattedType))
+	}
+	if from.IsNullable && !to.IsNullable {
+		change(ChangeColumnNotNull, "clients writing null fail")
+	} else if !from.IsNullable && to.IsNullable {
+		change(ChangeColumnNullable, "clients reading the column receive nulls")
+	}
+	if from.Default != to.Default && !from.IsIdentity && !to.IsIdentity {
+		change(ChangeColumnDefault, "the value of rows inserted without the column changes")
+	}
+	if from.ForeignTablename != to.ForeignTablename || from.ForeignColumnname != to.ForeignColumnname {
+		change(ChangeColumnReference, "the rows the column points to change")
+	}
+	return changes
+}
+
+// enumChanges translates the enum evolution into changes
+func enumChanges(previous *Schema, current *Schema) []Change {
+	changes := []Change{}
+	for _, evolution := range CheckEnumEvolution(previous, current) {
+		change := Change{Enumname: evolution.Enumname, Label: evolution.Label, Reason: evolution.Guidance}
+		switch evolution.Kind {
+		case EnumChangeRemoved:
+			change.Kind = ChangeEnumRemoved
+		case EnumChangeValueRemoved:
+			change.Kind = ChangeEnumValueRemoved
+			change.Severity = SeverityBreaking
+			if !evolution.Unsafe {
+				change.Severity = SeverityPotentiallyBreaking
+			}
+		case EnumChangeValueRenamed:
+			change.Kind = ChangeEnumValueRenamed
+			if enum, ok := current.EnumByName(evolution.Enumname); ok {
+				for _, value := range enum.Values {
+					if value.RenamedFrom == evolution.Label {
+						change.To = value.Label
+					}
+				}
+			}
+		case EnumChangeValueReordered:
+			change.Kind = ChangeEnumValueReordered
+		case EnumChangeValueInserted:
+			change.Kind = ChangeEnumValueInserted
+		case EnumChangeValueAppended:
+			change.Kind = ChangeEnumValueAdded
+		}
+		changes = append(changes, change)
+	}
+	for _, enum := range current.Enums {
+		if _, ok := previous.EnumByName(enum.Name); !ok {
+			changes = append(changes, Change{Kind: ChangeEnumAdded, Enumname: enum.Name})
+		}
+	}
+	return changes
+}
+
+// CompareSchemas lists the changes between a previous schema (usually a snapshot) and the current one, each rated
+// breaking, potentially breaking or safe for API consumers: dropping or renaming a table or a column, narrowing a type
+// or making a column NOT NULL break clients, widening a type or adding an optional column does not. The policy, which
+// may be nil, overrides severities, ignores tables and enums and declares renames
+func CompareSchemas(previous *Schema, current *Schema, policy *ChangePolicy) *ChangeSet {
+	if policy == nil {
+		policy = &ChangePolicy{}
+	}
+	changes := []Change{}
+	renamedTables := map[string]string{}
+	for before, after := range policy.Renames.Tables {
+		if _, ok := previous.TableByName(before); ok {
+			if _, ok := current.TableByName(after); ok {
+				renamedTables[after] = before
+			}
+		}
+	}
+	for _, before := range previous.Tables {
+		after, renamed := policy.Renames.Tables[before.Name]
+		if _, ok := current.TableByName(before.Name); !ok && (!renamed || renamedTables[after] != before.Name) {
+			changes = append(changes, Change{Kind: ChangeTableRemoved, Tablename: before.Name})
+		}
+	}
+	for _, table := range current.Tables {
+		previousName := table.Name
+		if name, ok := renamedTables[table.Name]; ok {
+			previousName = name
+			changes = append(changes, Change{Kind: ChangeTableRenamed, Tablename: table.Name, From: previousName})
+		}
+		before, ok := previous.TableByName(previousName)
+		if !ok {
+			changes = append(changes, Change{Kind: ChangeTableAdded, Tablename: table.Name})
+			continue
+		}
+		renamedColumns := map[string]string{}
+		for _, from := range before.Columns {
+			if after, ok := policy.Renames.Columns[previousName+"."+from.Name]; ok {
+				if _, ok := table.ColumnsByName[after]; ok {
+					renamedColumns[after] = from.Name
+				}
+			}
+		}
+		for _, from := range before.Columns {
+			after := policy.Renames.Columns[previousName+"."+from.Name]
+			if _, ok := table.ColumnsByName[from.Name]; !ok && !from.IsVirtual && renamedColumns[after] != from.Name {
+				changes = append(changes, Change{Kind: ChangeColumnRemoved, Tablename: table.Name, Columnname: from.Name, From: columnSignature(from)})
+			}
+		}
+		for _, col := range table.Columns {
+			if col.IsVirtual {
+				continue
+			}
+			fromName := col.Name
+			if name, ok := renamedColumns[col.Name]; ok {
+				fromName = name
+				changes = append(changes, Change{Kind: ChangeColumnRenamed, Tablename: table.Name, Columnname: col.Name, From: fromName})
+			}
+			from, ok := before.ColumnsByName[fromName]
+			if !ok {
+				changes = append(changes, Change{
+					Kind:       ChangeColumnAdded,
+					Severity:   columnAddedSeverity(col),
+					Tablename:  table.Name,
+					Columnname: col.Name,
+					To:         columnSignature(col),
+				})
+				continue
+			}
+			changes = append(changes, columnChanges(table.Name, from, col)...)
+		}
+	}
+	changes = append(changes, enumChanges(previous, current)...)
+
+	set := &ChangeSet{Changes: []Change{}}
+	for _, change := range changes {
+		if policy.ignores(change.Tablename + change.Enumname) {
+			continue
+		}
+		if severity, ok := policy.Severities[change.Kind]; ok {
+			change.Severity = severity
+		} else if len(change.Severity) == 0 {
+			change.Severity = defaultSeverities[change.Kind]
+		}
+		set.Changes = append(set.Changes, change)
+	}
+	return set
+}
+
+// Breaking lists the changes rated breaking, CI can fail on them while letting potentially breaking ones through
+func (c *ChangeSet) Breaking() []Change {
+	breaking := []Change{}
+	for _, change := range c.Changes {
+		if change.Severity == SeverityBreaking {
+			breaking = append(breaking, change)
+		}
+	}
+	return breaking
+}
@@ -0,0 +1,280 @@
+package inverseschema
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Restriction is the structured form of the simple CHECK constraints on a column: allowed values (col IN (...)),
+// numeric bounds (comparisons, BETWEEN), length bounds (length(col) <= n) and a pattern (col ~ '...')
+type Restriction struct {
+	Values           []string `json:"values,omitempty"`
+	Minimum          *float64 `json:"minimum,omitempty"`
+	ExclusiveMinimum bool     `json:"exclusive_minimum,omitempty"`
+	Maximum          *float64 `json:"maximum,omitempty"`
+	ExclusiveMaximum bool     `json:"exclusive_maximum,omitempty"`
+	MinLength        *int     `json:"min_length,omitempty"`
+	MaxLength        *int     `json:"max_length,omitempty"`
+	Pattern          string   `json:"pattern,omitempty"`
+}
+
+var checkCastContinuations = wordSet(`varying precision with without time zone`)
+
+var checkLengthFunctions = wordSet(`length char_length character_length`)
+
+// checkTokens tokenizes a CHECK constraint definition, dropping casts and parentheses and joining comparison operators
+func checkTokens(definition string) []sqlToken {
+	raw := tokenizeSQL(definition, 1)
+	tokens := []sqlToken{}
+	for i := 0; i < len(raw); i++ {
+		t := raw[i]
+		switch {
+		case t.text == "::":
+			for i++; i+1 < len(raw) && raw[i+1].kind == sqlTokenIdent && checkCastContinuations[raw[i+1].text]; i++ {
+			}
+			for i+1 < len(raw) && raw[i+1].text == "(" {
+				for i++; i < len(raw) && raw[i].text != ")"; i++ {
+				}
+			}
+			for i+2 < len(raw) && raw[i+1].text == "[" && raw[i+2].text == "]" {
+				i += 2
+			}
+		case t.kind == sqlTokenSymbol && (t.text == "(" || t.text == ")"):
+		case t.kind == sqlTokenSymbol && strings.Contains("<>=!~", t.text) && len(tokens) > 0 && tokens[len(tokens)-1].kind == sqlTokenSymbol && strings.Contains("<>!~", tokens[len(tokens)-1].text):
+			tokens[len(tokens)-1].text += t.text
+		default:
+			tokens = append(tokens, t)
+		}
+	}
+	if len(tokens) > 0 && tokens[0].kind == sqlTokenIdent && tokens[0].text == "check" {
+		tokens = tokens[1:]
+	}
+	if n := len(tokens); n >= 2 && tokens[n-2].text == "not" && tokens[n-1].text == "valid" {
+		tokens = tokens[:n-2]
+	}
+	return tokens
+}
+
+// checkNumber reads a possibly negative number at tokens[i], returning the number of tokens read
+func checkNumber(tokens []sqlToken, i int) (float64, int, bool) {
+	sign, n := 1.0, 0
+	if i < len(tokens) && tokens[i].text == "-" {
+		sign, n = -1, 1
+	}
+	if i+n >= len(tokens) {
+		return 0, 0, false
+	}
+	t := tokens[i+n]
+	if t.kind == sqlTokenString {
+		// numeric literals are sometimes rendered quoted, '0'::numeric
+		if _, err := strconv.ParseFloat(t.text, 64); err != nil {
+			return 0, 0, false
+		}
+	} else if t.kind != sqlTokenNumber {
+		return 0, 0, false
+	}
+	v, err := strconv.ParseFloat(t.text, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return sign * v, n + 1, true
+}
+
+func (r *Restriction) setMinimum(v float64, exclusive bool) {
+	if r.Minimum == nil || v > *r.Minimum || (v == *r.Minimum && exclusive) {
+		r.Minimum, r.ExclusiveMinimum = &v, exclusive
+	}
+}
+
+func (r *Restriction) setMaximum(v float64, exclusive bool) {
+	if r.Maximum == nil || v < *r.Maximum || (v == *r.Maximum && exclusive) {
+		r.Maximum, r.ExclusiveMaximum = &v, exclusive
+	}
+}
+
+func (r *Restriction) setValues(values []string) {
+	if r.Values == nil {
+		r.Values = values
+		return
+	}
+	allowed := map[string]bool{}
+	for _, v := range values {
+		allowed[v] = true
+	}
+	kept := []string{}
+	for _, v := range r.Values {
+		if allowed[v] {
+			kept = append(kept, v)
+		}
+	}
+	r.Values = kept
+}
+
+// bound applies col <op> v, flipped when the constant is on the left
+func (r *Restriction) bound(op string, v float64, flipped bool, length bool) bool {
+	if flipped {
+		op = map[string]string{"<": ">", "<=": ">=", ">": "<", ">=": "<=", "=": "="}[op]
+	}
+	if length {
+		n := int(v)
+		switch op {
+		case "<":
+			n--
+			r.MaxLength = &n
+		case "<=":
+			r.MaxLength = &n
+		case ">":
+			n++
+			r.MinLength = &n
+		case ">=":
+			r.MinLength = &n
+		case "=":
+			m := n
+			r.MinLength, r.MaxLength = &n, &m
+		default:
+			return false
+		}
+		return true
+	}
+	switch op {
+	case "<", "<=":
+		r.setMaximum(v, op == "<")
+	case ">", ">=":
+		r.setMinimum(v, op == ">")
+	case "=":
+		r.setMinimum(v, false)
+		r.setMaximum(v, false)
+	default:
+		return false
+	}
+	return true
+}
+
+// checkColumn matches the column, optionally wrapped in a length function, at tokens[i]
+func checkColumn(tokens []sqlToken, i int, columnname string) (int, bool, bool) {
+	if i >= len(tokens) {
+		return 0, false, false
+	}
+	t := tokens[i]
+	if (t.kind == sqlTokenIdent || t.kind == sqlTokenQuotedIdent) && t.text == columnname {
+		return 1, false, true
+	}
+	if t.kind == sqlTokenIdent && checkLengthFunctions[t.text] && i+1 < len(tokens) && tokens[i+1].text == columnname {
+		return 2, true, true
+	}
+	return 0, false, false
+}
+
+// applyConjunct folds a single conjunct about the column into the restriction, conjuncts about other columns or
+// expressions it does not understand are ignored
+func (r *Restriction) applyConjunct(tokens []sqlToken, columnname string) {
+	n, length, ok := checkColumn(tokens, 0, columnname)
+	if !ok {
+		// constant on the left, 0 < col
+		v, m, ok := checkNumber(tokens, 0)
+		if !ok || m+1 >= len(tokens) {
+			return
+		}
+		if c, length, ok := checkColumn(tokens, m+1, columnname); ok && m+1+c == len(tokens) {
+			r.bound(tokens[m].text, v, true, length)
+		}
+		return
+	}
+	rest := tokens[n:]
+	if len(rest) < 2 {
+		return
+	}
+	op := rest[0].text
+	switch {
+	case op == "between" && !length:
+		low, m, ok := checkNumber(rest, 1)
+		if !ok || 1+m >= len(rest) || rest[1+m].text != "and" {
+			return
+		}
+		high, k, ok := checkNumber(rest, 2+m)
+		if ok && 2+m+k == len(rest) {
+			r.setMinimum(low, false)
+			r.setMaximum(high, false)
+		}
+	case (op == "=" && len(rest) > 2 && rest[1].text == "any" && rest[2].text == "array") || op == "in":
+		values := []string{}
+		for _, t := range rest[1:] {
+			switch {
+			case t.kind == sqlTokenString || t.kind == sqlTokenNumber:
+				values = append(values, t.text)
+			case t.text == "any" || t.text == "array" || t.text == "[" || t.text == "]" || t.text == ",":
+			default:
+				return
+			}
+		}
+		if len(values) > 0 && !length {
+			r.setValues(values)
+		}
+	case op == "~" && len(rest) == 2 && rest[1].kind == sqlTokenString && !length:
+		r.Pattern = rest[1].text
+	default:
+		if v, m, ok := checkNumber(rest, 1); ok && 1+m == len(rest) {
+			r.bound(op, v, false, length)
+		}
+	}
+}
+
+// parseCheckRestriction extracts the restrictions a CHECK constraint definition puts on a column, only conjunctions
+// of simple predicates are understood, definitions with OR or NOT (IS NOT NULL aside) yield nothing
+func parseCheckRestriction(definition string, columnname string) *Restriction {
+	tokens := checkTokens(definition)
+	for i, t := range tokens {
+		if t.kind == sqlTokenIdent && (t.text == "or" || (t.text == "not" && (i == 0 || tokens[i-1].text != "is"))) {
+			return nil
+		}
+	}
+	r := &Restriction{}
+	start := 0
+	for i := 0; i <= len(tokens); i++ {
+		if i < len(tokens) && !(tokens[i].kind == sqlTokenIdent && tokens[i].text == "and") {
+			continue
+		}
+		// the and of a between belongs to it
+		if i < len(tokens) && i >= 2 && start < i && tokens[i-2].text == "between" {
+			continue
+		}
+		if i < len(tokens) && i >= 3 && start < i && tokens[i-3].text == "between" && tokens[i-2].text == "-" {
+			continue
+		}
+		r.applyConjunct(tokens[start:i], columnname)
+		start = i + 1
+	}
+	if r.Values == nil && r.Minimum == nil && r.Maximum == nil && r.MinLength == nil && r.MaxLength == nil && len(r.Pattern) == 0 {
+		return nil
+	}
+	return r
+}
+
+// mergeRestriction combines the restrictions of several CHECK constraints on the same column
+func mergeRestriction(current *Restriction, next *Restriction) *Restriction {
+	if current == nil {
+		return next
+	}
+	if next == nil {
+		return current
+	}
+	if next.Values != nil {
+		current.setValues(next.Values)
+	}
+	if next.Minimum != nil {
+		current.setMinimum(*next.Minimum, next.ExclusiveMinimum)
+	}
+	if next.Maximum != nil {
+		current.setMaximum(*next.Maximum, next.ExclusiveMaximum)
+	}
+	if next.MinLength != nil && (current.MinLength == nil || *next.MinLength > *current.MinLength) {
+		current.MinLength = next.MinLength
+	}
+	if next.MaxLength != nil && (current.MaxLength == nil || *next.MaxLength < *current.MaxLength) {
+		current.MaxLength = next.MaxLength
+	}
+	if len(next.Pattern) > 0 {
+		current.Pattern = next.Pattern
+	}
+	return current
+}
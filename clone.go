@@ -0,0 +1,110 @@
+package inverseschema
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+)
+
+var (
+	// indexTableQualifierRe matches the schema qualifying the table of a pg_get_indexdef definition
+	indexTableQualifierRe = regexp.MustCompile(`(?i)^(CREATE\s+(?:UNIQUE\s+)?INDEX\s+(?:"[^"]+"|\S+)\s+ON\s+(?:ONLY\s+)?)(?:"[^"]+"|[^\s."]+)\.`)
+	nextvalRe             = regexp.MustCompile(`nextval\('([^']+)'(?:::regclass)?\)`)
+)
+
+type CloneOption func(o *cloneOptions)
+
+type cloneOptions struct {
+	indexes     bool
+	indexFilter func(index Index) bool
+}
+
+// WithoutIndexes leaves the secondary indexes out of the clone, indexes backing constraints are still created
+func WithoutIndexes() CloneOption {
+	return func(o *cloneOptions) {
+		o.indexes = false
+	}
+}
+
+// WithIndexFilter creates only the secondary indexes the filter keeps, e.g. to skip GIN indexes or indexes whose
+// SizeBytes is large
+func WithIndexFilter(filter func(index Index) bool) CloneOption {
+	return func(o *cloneOptions) {
+		o.indexFilter = filter
+	}
+}
+
+// CloneSQL renders the script creating an empty copy of the schema, meant to run with the target schema first on
+// the search_path: index definitions lose their schema qualifier and the sequences of nextval defaults are created,
+// schema qualified ones in the target schema with the defaults using them rewritten
+func (s *Schema) CloneSQL(opts ...CloneOption) string {
+	o := &cloneOptions{indexes: true}
+	for _, opt := range opts {
+		opt(o)
+	}
+	clone := *s
+	clone.Tables, _, _ = sequencesSQL(s.Tables, true)
+	return clone.baselineSQL(func(index Index) string {
+		if !o.indexes || (o.indexFilter != nil && !o.indexFilter(index)) {
+			return ""
+		}
+		return indexTableQualifierRe.ReplaceAllString(index.Definition, "$1")
+	})
+}
+
+// sequencesSQL renders the statements creating the sequences of nextval defaults, along with their names. With unqualify the schema qualified sequences are created on the search_path instead and the tables are
+// returned with the defaults using them rewritten
+func sequencesSQL(tables []Table, unqualify bool) ([]Table, []string, []string) {
+	statements, names := []string{}, []string{}
+	seen := map[string]bool{}
+	rewritten := make([]Table, len(tables))
+	for i, table := range tables {
+		table.Columns = append([]Column{}, table.Columns...)
+		for j, col := range table.Columns {
+			if !col.HasDefault || col.IsVirtual {
+				continue
+			}
+			table.Columns[j].Default = nextvalRe.ReplaceAllStringFunc(col.Default, func(nextval string) string {
+				schemaname, sequence := splitRegclass(nextvalRe.FindStringSubmatch(nextval)[1])
+				name := quoteIdent(sequence)
+				if len(schemaname) > 0 && !unqualify {
+					name = quoteIdent(schemaname) + "." + name
+				}
+				if !seen[name] {
+					seen[name] = true
+					names = append(names, sequence)
+					statements = append(statements, "CREATE SEQUENCE IF NOT EXISTS "+name+";")
+				}
+				if len(schemaname) == 0 || !unqualify {
+					return nextval
+				}
+				return "nextval(" + quoteLiteral(name) + "::regclass)"
+			})
+		}
+		rewritten[i] = table
+	}
+	return rewritten, statements, names
+}
+
+// CloneSchema creates an empty copy of the parsed schema (structure only, no data) into schemaname on db, creating
+// the schema when missing, within a single transaction. It is meant for provisioning a database per test: db may point
+// to a freshly created database, cloning into "public"
+func (s *Schema) CloneSchema(ctx context.Context, db *sql.DB, schemaname string, opts ...CloneOption) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	statements := []string{
+		"CREATE SCHEMA IF NOT EXISTS " + quoteIdent(schemaname),
+		"SET LOCAL search_path TO " + quoteIdent(schemaname) + ", public",
+		s.CloneSQL(opts...),
+	}
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("cloning into %s: %w", schemaname, err)
+		}
+	}
+	return tx.Commit()
+}
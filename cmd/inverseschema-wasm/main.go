@@ -0,0 +1,117 @@
+//go:build js && wasm
+// +build js,wasm
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"syscall/js"
+
+	"github.com/oiime/inverseschema"
+)
+
+// every function receives snapshots as JSON strings and returns a string, failures are returned as a JS Error
+func main() {
+	js.Global().Set("inverseschema", js.ValueOf(map[string]interface{}{
+		"fingerprint": jsFunc(1, fingerprint),
+		"search":      jsFunc(2, search),
+		"group":       jsFunc(2, group),
+		"manifests":   jsFunc(1, manifests),
+		"consistency": jsFunc(3, consistency),
+	}))
+	select {}
+}
+
+// jsFunc wraps fn so that a call with fewer than arity arguments returns an Error instead of panicking
+func jsFunc(arity int, fn func(this js.Value, args []js.Value) interface{}) js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if len(args) < arity {
+			return jsError(fmt.Errorf("expected %d arguments, got %d", arity, len(args)))
+		}
+		return fn(this, args)
+	})
+}
+
+func jsError(err error) js.Value {
+	return js.Global().Get("Error").New(err.Error())
+}
+
+func jsJSON(v interface{}) interface{} {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return jsError(err)
+	}
+	return string(data)
+}
+
+func loadSnapshot(v js.Value) (*inverseschema.Schema, error) {
+	return inverseschema.LoadSnapshot(strings.NewReader(v.String()))
+}
+
+// fingerprint(snapshot)
+func fingerprint(this js.Value, args []js.Value) interface{} {
+	schema, err := loadSnapshot(args[0])
+	if err != nil {
+		return jsError(err)
+	}
+	fingerprint, err := schema.Fingerprint()
+	if err != nil {
+		return jsError(err)
+	}
+	return fingerprint
+}
+
+// search(snapshot, query)
+func search(this js.Value, args []js.Value) interface{} {
+	schema, err := loadSnapshot(args[0])
+	if err != nil {
+		return jsError(err)
+	}
+	return jsJSON(schema.Search(args[1].String()))
+}
+
+// group(snapshot, domains)
+func group(this js.Value, args []js.Value) interface{} {
+	schema, err := loadSnapshot(args[0])
+	if err != nil {
+		return jsError(err)
+	}
+	domains := []inverseschema.Domain{}
+	if err := json.Unmarshal([]byte(args[1].String()), &domains); err != nil {
+		return jsError(err)
+	}
+	return jsJSON(schema.Group(domains...))
+}
+
+// manifests(snapshot)
+func manifests(this js.Value, args []js.Value) interface{} {
+	schema, err := loadSnapshot(args[0])
+	if err != nil {
+		return jsError(err)
+	}
+	var buf bytes.Buffer
+	if err := schema.WriteManifests(&buf); err != nil {
+		return jsError(err)
+	}
+	return buf.String()
+}
+
+// consistency(leftSnapshot, rightSnapshot, pairs)
+func consistency(this js.Value, args []js.Value) interface{} {
+	left, err := loadSnapshot(args[0])
+	if err != nil {
+		return jsError(err)
+	}
+	right, err := loadSnapshot(args[1])
+	if err != nil {
+		return jsError(err)
+	}
+	pairs := []inverseschema.SharedColumn{}
+	if err := json.Unmarshal([]byte(args[2].String()), &pairs); err != nil {
+		return jsError(err)
+	}
+	return jsJSON(inverseschema.CheckSharedColumns(left, right, pairs...))
+}
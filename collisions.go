@@ -0,0 +1,124 @@
+package inverseschema
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NameCollision lists the database names a generator normalized into the same generated name, Scope is empty for
+// type names, the table for fields and the enum for enum values. Overrides is an overlay resolving it when the
+// colliding sources are tables or columns
+type NameCollision struct {
+	Scope     string   `json:"scope,omitempty"`
+	Name      string   `json:"name,omitempty"`
+	Sources   []string `json:"sources,omitempty"`
+	Overrides *Overlay `json:"overrides,omitempty"`
+}
+
+// NameCollisionError is returned by generators instead of emitting code declaring the same name twice
+type NameCollisionError struct {
+	Generator  string          `json:"generator,omitempty"`
+	Collisions []NameCollision `json:"collisions,omitempty"`
+}
+
+func (e *NameCollisionError) Error() string {
+	problems := make([]string, len(e.Collisions))
+	for i, c := range e.Collisions {
+		name := c.Name
+		if len(c.Scope) > 0 {
+			name = c.Scope + ": " + name
+		}
+		problems[i] = fmt.Sprintf("%s generated from %s", name, strings.Join(c.Sources, ", "))
+	}
+	return e.Generator + " name collisions:\n\t" + strings.Join(problems, "\n\t")
+}
+
+type nameSource struct {
+	name string
+	// overlayKey is the overlay entry renaming the source, empty when it cannot be renamed
+	overlayKey string
+	tables     bool
+}
+
+type generatedName struct {
+	scope string
+	name  string
+}
+
+type nameCollisions struct {
+	generator string
+	order     []generatedName
+	sources   map[generatedName][]nameSource
+}
+
+func newNameCollisions(generator string) *nameCollisions {
+	return &nameCollisions{generator: generator, sources: map[generatedName][]nameSource{}}
+}
+
+func (c *nameCollisions) add(scope string, name string, source nameSource) {
+	key := generatedName{scope: scope, name: name}
+	if _, ok := c.sources[key]; !ok {
+		c.order = append(c.order, key)
+	}
+	c.sources[key] = append(c.sources[key], source)
+}
+
+func (c *nameCollisions) addTable(name string, table Table) {
+	c.add("", name, nameSource{name: "table " + table.Name, overlayKey: table.Name, tables: true})
+}
+
+func (c *nameCollisions) addEnum(name string, enum Enum) {
+	c.add("", name, nameSource{name: "enum " + enum.Name})
+}
+
+func (c *nameCollisions) addColumn(name string, table Table, col Column) {
+	c.add(table.Name, name, nameSource{name: col.Name, overlayKey: table.Name + "." + col.Name})
+}
+
+func (c *nameCollisions) addEnumValue(name string, enum Enum, value EnumValue) {
+	c.add("enum "+enum.Name, name, nameSource{name: value.Label})
+}
+
+// err suggests numbered names for every renamable source but one
+func (c *nameCollisions) err() error {
+	collisions := []NameCollision{}
+	for _, key := range c.order {
+		sources := c.sources[key]
+		if len(sources) < 2 {
+			continue
+		}
+		collision := NameCollision{Scope: key.scope, Name: key.name}
+		// sources which cannot be renamed through the overlay keep the name
+		keeper := 0
+		for i, source := range sources {
+			if len(source.overlayKey) == 0 {
+				keeper = i
+				break
+			}
+		}
+		suffix := 2
+		for i, source := range sources {
+			collision.Sources = append(collision.Sources, source.name)
+			if i == keeper || len(source.overlayKey) == 0 {
+				continue
+			}
+			if collision.Overrides == nil {
+				collision.Overrides = &Overlay{}
+			}
+			entries := &collision.Overrides.Columns
+			if source.tables {
+				entries = &collision.Overrides.Tables
+			}
+			if *entries == nil {
+				*entries = map[string]string{}
+			}
+			(*entries)[source.overlayKey] = fmt.Sprintf("%s%d", key.name, suffix)
+			suffix++
+		}
+		collisions = append(collisions, collision)
+	}
+	if len(collisions) > 0 {
+		return &NameCollisionError{Generator: c.generator, Collisions: collisions}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package inverseschema
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+func commentSQL(object string, comment string) string {
+	return fmt.Sprintf("COMMENT ON %s IS %s;", object, quoteLiteral(comment))
+}
+
+// CommentStatements renders the COMMENT ON statements pushing the comments of the schema (typically a snapshot or a
+// schema enriched with ApplyGlossary) to the database, live is the schema as introspected from the database and only
+// differing comments are written, every comment is when it is nil. Tables, columns and enums missing from live, and
+// virtual columns, are left out, comments are never cleared
+func (s *Schema) CommentStatements(live *Schema) []string {
+	statements := []string{}
+	for _, table := range s.Tables {
+		var current *Table
+		if live != nil {
+			var ok bool
+			if current, ok = live.TableByName(table.Name); !ok {
+				continue
+			}
+		}
+		if len(strings.TrimSpace(table.Comments)) > 0 && (current == nil || current.Comments != table.Comments) {
+			statements = append(statements, commentSQL("TABLE "+quoteIdent(table.Name), table.Comments))
+		}
+		for _, col := range table.Columns {
+			if col.IsVirtual || len(strings.TrimSpace(col.Comments)) == 0 {
+				continue
+			}
+			if current != nil {
+				if currentCol, ok := current.ColumnsByName[col.Name]; !ok || currentCol.Comments == col.Comments {
+					continue
+				}
+			}
+			statements = append(statements, commentSQL("COLUMN "+quoteIdent(table.Name)+"."+quoteIdent(col.Name), col.Comments))
+		}
+	}
+	for _, enum := range s.Enums {
+		if len(strings.TrimSpace(enum.Comments)) == 0 {
+			continue
+		}
+		if live != nil {
+			if current, ok := live.EnumByName(enum.Name); !ok || current.Comments == enum.Comments {
+				continue
+			}
+		}
+		statements = append(statements, commentSQL("TYPE "+quoteIdent(enum.Name), enum.Comments))
+	}
+	return statements
+}
+
+// WriteComments executes the statements of CommentStatements against schemaname within a single transaction, it
+// returns the number of comments written
+func (s *Schema) WriteComments(ctx context.Context, db *sql.DB, schemaname string, live *Schema) (int, error) {
+	statements := s.CommentStatements(live)
+	if len(statements) == 0 {
+		return 0, nil
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, "SET LOCAL search_path TO "+quoteIdent(schemaname)); err != nil {
+		return 0, err
+	}
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return 0, fmt.Errorf("%s: %w", statement, err)
+		}
+	}
+	return len(statements), tx.Commit()
+}
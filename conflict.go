@@ -0,0 +1,102 @@
+package inverseschema
+
+import (
+	"sort"
+	"strings"
+)
+
+// ConflictTarget is a valid ON CONFLICT target of a table. Columns holds column names and, for expression indexes,
+// parenthesized expressions. Constraint names the primary key or unique constraint of the target and Index the unique
+// index otherwise, Predicate is set for partial unique indexes and has to be repeated by the upsert. NaturalKey marks
+// targets matching a natural key
+type ConflictTarget struct {
+	Columns    []string `json:"columns,omitempty"`
+	Constraint string   `json:"constraint,omitempty"`
+	Index      string   `json:"index,omitempty"`
+	Predicate  string   `json:"predicate,omitempty"`
+	NaturalKey bool     `json:"natural_key,omitempty"`
+}
+
+// Clause renders the target as it follows ON CONFLICT
+func (t ConflictTarget) Clause() string {
+	cols := make([]string, len(t.Columns))
+	for i, col := range t.Columns {
+		if strings.HasPrefix(col, "(") {
+			cols[i] = col
+		} else {
+			cols[i] = quoteIdent(col)
+		}
+	}
+	clause := "(" + strings.Join(cols, ", ") + ")"
+	if len(t.Predicate) > 0 {
+		clause += " WHERE " + t.Predicate
+	}
+	return clause
+}
+
+// columnSet keys a set of columns regardless of their order, postgres infers the same arbiter for any order
+func columnSet(columns []string) string {
+	sorted := append([]string{}, columns...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
+
+// ConflictTargets lists the ON CONFLICT targets of the table: its primary key and unique constraints, then the unique
+// indexes not backing a constraint, natural keys first. Deferrable constraints and their indexes cannot arbitrate a
+// conflict and are left out
+func (t Table) ConflictTargets() []ConflictTarget {
+	targets := []ConflictTarget{}
+	seen := map[string]bool{}
+	add := func(target ConflictTarget) {
+		key := columnSet(target.Columns) + " " + target.Predicate
+		if len(target.Columns) == 0 || seen[key] {
+			return
+		}
+		seen[key] = true
+		targets = append(targets, target)
+	}
+
+	deferrable := map[string]bool{}
+	for _, c := range tableConstraints(t) {
+		if c.typ != ConstraintTypePrimaryKey && c.typ != ConstraintTypeUnique {
+			continue
+		}
+		if c.deferrable {
+			deferrable[c.name] = true
+			continue
+		}
+		add(ConflictTarget{Columns: c.columns, Constraint: c.name})
+	}
+	for _, index := range t.Indexes {
+		if !index.IsUnique || deferrable[index.Name] {
+			continue
+		}
+		cols := make([]string, len(index.Columns))
+		for i, key := range index.Columns {
+			if _, ok := t.ColumnsByName[strings.Trim(key, `"`)]; ok {
+				cols[i] = strings.Trim(key, `"`)
+			} else {
+				cols[i] = "(" + key + ")"
+			}
+		}
+		add(ConflictTarget{Columns: cols, Index: index.Name, Predicate: index.Predicate})
+	}
+	if len(targets) == 0 {
+		// sources without constraints nor indexes only report the flags
+		for _, set := range uniqueColumnSets(t) {
+			add(ConflictTarget{Columns: set})
+		}
+	}
+
+	natural := map[string]bool{}
+	for _, key := range t.NaturalKeys {
+		natural[columnSet(key)] = true
+	}
+	for i := range targets {
+		targets[i].NaturalKey = len(targets[i].Predicate) == 0 && natural[columnSet(targets[i].Columns)]
+	}
+	sort.SliceStable(targets, func(i, j int) bool {
+		return targets[i].NaturalKey && !targets[j].NaturalKey
+	})
+	return targets
+}
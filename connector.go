@@ -0,0 +1,39 @@
+package inverseschema
+
+import (
+	"context"
+	"database/sql/driver"
+)
+
+// DSNFunc builds the connection string of a new connection, it is called for every connection the pool opens
+type DSNFunc func(ctx context.Context) (string, error)
+
+// NewDSNConnector returns a connector for sql.OpenDB building every connection from a fresh DSN, so short lived
+// credentials such as RDS or Cloud SQL IAM authentication tokens are renewed on expiry instead of being fixed at startup
+func NewDSNConnector(drv driver.Driver, dsn DSNFunc) driver.Connector {
+	return &dsnConnector{driver: drv, dsn: dsn}
+}
+
+type dsnConnector struct {
+	driver driver.Driver
+	dsn    DSNFunc
+}
+
+func (c *dsnConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	dsn, err := c.dsn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if ctxDriver, ok := c.driver.(driver.DriverContext); ok {
+		connector, err := ctxDriver.OpenConnector(dsn)
+		if err != nil {
+			return nil, err
+		}
+		return connector.Connect(ctx)
+	}
+	return c.driver.Open(dsn)
+}
+
+func (c *dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
@@ -0,0 +1,72 @@
+package inverseschema
+
+import (
+	"fmt"
+)
+
+type ColumnRef struct {
+	Tablename  string `json:"tablename,omitempty"`
+	Columnname string `json:"columnname,omitempty"`
+}
+
+func (r ColumnRef) String() string {
+	return r.Tablename + "." + r.Columnname
+}
+
+// SharedColumn pairs a column of one schema with the column of another schema holding the same logical identifier
+type SharedColumn struct {
+	Left  ColumnRef `json:"left,omitempty"`
+	Right ColumnRef `json:"right,omitempty"`
+}
+
+type ConsistencyIssue struct {
+	Pair   SharedColumn `json:"pair,omitempty"`
+	Reason string       `json:"reason,omitempty"`
+}
+
+func (s *Schema) columnByRef(ref ColumnRef) (Column, bool) {
+	table, ok := s.TableByName(ref.Tablename)
+	if !ok {
+		return Column{}, false
+	}
+	col, ok := table.ColumnsByName[ref.Columnname]
+	return col, ok
+}
+
+// CheckSharedColumns verifies that every pair of shared columns has compatible types and lengths across both schemas
+func CheckSharedColumns(left *Schema, right *Schema, pairs ...SharedColumn) []ConsistencyIssue {
+	issues := []ConsistencyIssue{}
+	for _, pair := range pairs {
+		l, ok := left.columnByRef(pair.Left)
+		if !ok {
+			issues = append(issues, ConsistencyIssue{Pair: pair, Reason: fmt.Sprintf("column %s does not exist", pair.Left)})
+			continue
+		}
+		r, ok := right.columnByRef(pair.Right)
+		if !ok {
+			issues = append(issues, ConsistencyIssue{Pair: pair, Reason: fmt.Sprintf("column %s does not exist", pair.Right)})
+			continue
+		}
+		if reason, ok := columnsCompatible(l, r); !ok {
+			issues = append(issues, ConsistencyIssue{Pair: pair, Reason: reason})
+		}
+	}
+	return issues
+}
+
+func columnsCompatible(l Column, r Column) (string, bool) {
+	lt, rt := postgresColumnType(l), postgresColumnType(r)
+	if l.Datatype != r.Datatype || l.IsArray != r.IsArray {
+		return fmt.Sprintf("type mismatch: %s vs %s", lt, rt), false
+	}
+	if l.IsUserDefined && l.UserDefinedType != nil && r.UserDefinedType != nil && l.UserDefinedType.Name != r.UserDefinedType.Name {
+		return fmt.Sprintf("type mismatch: %s vs %s", lt, rt), false
+	}
+	if l.Datatype == DatatypeUnknown && l.DatatypeRaw != r.DatatypeRaw {
+		return fmt.Sprintf("type mismatch: %s vs %s", l.DatatypeRaw, r.DatatypeRaw), false
+	}
+	if l.CharacterMaxLength != r.CharacterMaxLength {
+		return fmt.Sprintf("length mismatch: %s vs %s", lt, rt), false
+	}
+	return "", true
+}
@@ -0,0 +1,224 @@
+package inverseschema
+
+import (
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+var cueIdentRe = regexp.MustCompile(`^[A-Za-z$][A-Za-z0-9_$]*$`)
+
+var cueDatatypes = map[Datatype]string{
+	DatatypeBigint:          "int64",
+	DatatypeInt:             "int32",
+	DatatypeSmallint:        "int16",
+	DatatypeDecimal:         "number",
+	DatatypeNumeric:         "number",
+	DatatypeVariableNumeric: "number",
+	DatatypeJsonb:           "_",
+	DatatypeJson:            "_",
+	DatatypeText:            "string",
+	DatatypeVarchar:         "string",
+	DatatypeBoolean:         "bool",
+	DatatypeDate:            "string",
+	DatatypeTimestamp:       "time.Time",
+	DatatypeTimestampz:      "time.Time",
+	DatatypeUuid:            "string",
+	DatatypeBytea:           "bytes",
+}
+
+var cueTemplate = template.Must(template.New("cue").Parse(`{{define "file"}}package {{.Package}}
+{{if .Imports}}
+import ({{range .Imports}}
+	"{{.}}"{{end}}
+)
+{{end}}{{range .Enums}}
+{{template "enum" .}}{{end}}{{range .Tables}}
+{{template "table" .}}{{end}}{{end}}
+
+{{define "enum"}}#{{.Name}}: {{.Values}}
+{{end}}
+
+{{define "table"}}{{if .Comment}}// {{.Comment}}
+{{end}}#{{.Name}}: {
+{{range .Fields}}{{template "field" .}}{{end}}}
+{{end}}
+
+{{define "field"}}{{if .Comment}}	// {{.Comment}}
+{{end}}	{{.Name}}{{if .Optional}}?{{end}}: {{.Type}}
+{{end}}`))
+
+type cueFile struct {
+	Package string
+	Imports []string
+	Enums   []cueEnum
+	Tables  []cueDefinition
+}
+
+type cueEnum struct {
+	Name   string
+	Values string
+}
+
+type cueDefinition struct {
+	Name    string
+	Comment string
+	Fields  []cueField
+}
+
+type cueField struct {
+	Name     string
+	Type     string
+	Optional bool
+	Comment  string
+}
+
+// cueRestriction renders the check constraint restriction of a column as CUE constraints
+func cueRestriction(col Column, imports map[string]bool) string {
+	r := col.Restriction
+	if r == nil {
+		return ""
+	}
+	constraints := []string{}
+	if len(r.Values) > 0 {
+		values := make([]string, len(r.Values))
+		for i, v := range r.Values {
+			values[i] = strconv.Quote(v)
+			if _, err := strconv.ParseFloat(v, 64); err == nil && cueDatatypes[col.Datatype] != "string" {
+				values[i] = v
+			}
+		}
+		constraints = append(constraints, "("+strings.Join(values, " | ")+")")
+	}
+	if r.Minimum != nil {
+		op := ">="
+		if r.ExclusiveMinimum {
+			op = ">"
+		}
+		constraints = append(constraints, op+strconv.FormatFloat(*r.Minimum, 'f', -1, 64))
+	}
+	if r.Maximum != nil {
+		op := "<="
+		if r.ExclusiveMaximum {
+			op = "<"
+		}
+		constraints = append(constraints, op+strconv.FormatFloat(*r.Maximum, 'f', -1, 64))
+	}
+	if r.MinLength != nil {
+		imports["strings"] = true
+		constraints = append(constraints, "strings.MinRunes("+strconv.Itoa(*r.MinLength)+")")
+	}
+	if r.MaxLength != nil {
+		imports["strings"] = true
+		constraints = append(constraints, "strings.MaxRunes("+strconv.Itoa(*r.MaxLength)+")")
+	}
+	if len(r.Pattern) > 0 {
+		constraints = append(constraints, "=~"+strconv.Quote(r.Pattern))
+	}
+	return strings.Join(constraints, " & ")
+}
+
+// cueType renders the type of a column, columns with nested Fields become inline structs
+func cueType(col Column, enumTypes map[string]string, imports map[string]bool) string {
+	typ := "_"
+	if len(col.Fields) > 0 {
+		fields := make([]string, len(col.Fields))
+		for i, field := range col.Fields {
+			optional := ""
+			if !field.Required() {
+				optional = "?"
+			}
+			fields[i] = cueLabel(field.Name) + optional + ": " + cueType(field, enumTypes, imports)
+		}
+		typ = "{" + strings.Join(fields, ", ") + "}"
+	} else if col.IsUserDefined && col.UserDefinedType != nil {
+		if enumType, ok := enumTypes[col.UserDefinedType.Name]; ok {
+			typ = enumType
+		}
+	} else if t, ok := cueDatatypes[col.Datatype]; ok {
+		typ = t
+	}
+	if strings.HasPrefix(typ, "time.") {
+		imports["time"] = true
+	}
+	if col.Datatype == DatatypeVarchar && col.CharacterMaxLength > 0 {
+		imports["strings"] = true
+		typ += " & strings.MaxRunes(" + strconv.Itoa(col.CharacterMaxLength) + ")"
+	}
+	if constraints := cueRestriction(col, imports); len(constraints) > 0 {
+		typ += " & " + constraints
+	}
+	if col.IsArray {
+		if strings.Contains(typ, " ") && len(col.Fields) == 0 {
+			typ = "(" + typ + ")"
+		}
+		typ = "[..." + typ + "]"
+	}
+	if col.IsNullable {
+		typ += " | null"
+	}
+	return typ
+}
+
+func cueLabel(name string) string {
+	if !cueIdentRe.MatchString(name) {
+		return strconv.Quote(name)
+	}
+	return name
+}
+
+func singleLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// WriteCUE emits a CUE definition per table and enum, columns which are not Required are optional fields
+func (s *Schema) WriteCUE(w io.Writer, opts ...GeneratorOption) error {
+	o := newGeneratorOptions("schema", opts)
+	file := cueFile{Package: o.packageName}
+	imports := map[string]bool{}
+	collisions := newNameCollisions("cue")
+
+	enumTypes := map[string]string{}
+	for _, enum := range s.Enums {
+		labels := make([]string, len(enum.Values))
+		for i, value := range enum.Values {
+			labels[i] = strconv.Quote(value.Label)
+		}
+		name := pascalCase(enum.Name)
+		collisions.addEnum(name, enum)
+		enumTypes[enum.Name] = "#" + name
+		file.Enums = append(file.Enums, cueEnum{Name: name, Values: strings.Join(labels, " | ")})
+	}
+
+	for _, table := range o.tables(s) {
+		def := cueDefinition{Name: tableTypeName(table), Comment: singleLine(table.Comments)}
+		collisions.addTable(def.Name, table)
+		for _, col := range table.Columns {
+			col = opaqueColumn(col)
+			typ := cueType(col, enumTypes, imports)
+			def.Fields = append(def.Fields, cueField{
+				Name:     cueLabel(columnAttributeName(col)),
+				Type:     typ,
+				Optional: !col.Required(),
+				Comment:  singleLine(col.Comments),
+			})
+		}
+		file.Tables = append(file.Tables, def)
+	}
+	if err := collisions.err(); err != nil {
+		return err
+	}
+
+	for name := range imports {
+		file.Imports = append(file.Imports, name)
+	}
+	sort.Strings(file.Imports)
+	tmpl, err := o.template("cue", cueTemplate)
+	if err != nil {
+		return err
+	}
+	return tmpl.ExecuteTemplate(w, "file", file)
+}
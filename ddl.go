@@ -0,0 +1,250 @@
+package inverseschema
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func quoteIdents(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = quoteIdent(name)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// splitRegclass splits the text form of a regclass, as found in nextval defaults, into its optional schema and its
+// unquoted name
+func splitRegclass(text string) (string, string) {
+	parts := []string{""}
+	quoted := false
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case c == '"' && quoted && i+1 < len(text) && text[i+1] == '"':
+			parts[len(parts)-1] += `"`
+			i++
+		case c == '"':
+			quoted = !quoted
+		case c == '.' && !quoted:
+			parts = append(parts, "")
+		default:
+			parts[len(parts)-1] += string(c)
+		}
+	}
+	if len(parts) == 1 {
+		return "", parts[0]
+	}
+	return parts[len(parts)-2], parts[len(parts)-1]
+}
+
+type tableConstraint struct {
+	name             string
+	typ              ConstraintType
+	columns          []string
+	foreignTablename string
+	foreignColumns   []string
+	definition       string
+	inherited        bool
+	deferrable       bool
+	positions        []int
+}
+
+// tableConstraints regroups the per column constraints of a table by name, columns follow their position in the key
+// of the constraint, or the column order of the table when unknown, and the table level checks come last
+func tableConstraints(table Table) []tableConstraint {
+	constraints := []tableConstraint{}
+	idxByName := map[string]int{}
+	for _, col := range table.Columns {
+		for _, c := range col.Constraints {
+			idx, ok := idxByName[c.Name]
+			if !ok {
+				idx = len(constraints)
+				idxByName[c.Name] = idx
+				constraints = append(constraints, tableConstraint{name: c.Name, typ: c.Type, foreignTablename: c.ForeignTablename, definition: c.Definition, inherited: c.IsInherited, deferrable: c.IsDeferrable})
+			}
+			constraints[idx].columns = append(constraints[idx].columns, col.Name)
+			constraints[idx].positions = append(constraints[idx].positions, c.Position)
+			if c.Type == ConstraintTypeForeignKey {
+				constraints[idx].foreignColumns = append(constraints[idx].foreignColumns, c.ForeignColumnname)
+			}
+		}
+	}
+	for i := range constraints {
+		constraints[i].orderByPosition()
+	}
+	for _, c := range table.Checks {
+		constraints = append(constraints, tableConstraint{name: c.Name, typ: c.Type, definition: c.Definition, inherited: c.IsInherited})
+	}
+	return constraints
+}
+
+// orderByPosition sorts the columns, and the foreign columns paired with them, by their position in the key
+func (c *tableConstraint) orderByPosition() {
+	order := make([]int, len(c.columns))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return c.positions[order[i]] < c.positions[order[j]] })
+	columns := make([]string, len(order))
+	positions := make([]int, len(order))
+	for i, idx := range order {
+		columns[i], positions[i] = c.columns[idx], c.positions[idx]
+	}
+	if len(c.foreignColumns) == len(order) {
+		foreignColumns := make([]string, len(order))
+		for i, idx := range order {
+			foreignColumns[i] = c.foreignColumns[idx]
+		}
+		c.foreignColumns = foreignColumns
+	}
+	c.columns, c.positions = columns, positions
+}
+
+// columnGenerationSQL renders the DEFAULT, identity or generation clause of a column
+func columnGenerationSQL(col Column) string {
+	switch {
+	case col.IsIdentity:
+		generation := col.IdentityGeneration
+		if len(generation) == 0 {
+			generation = "BY DEFAULT"
+		}
+		return " GENERATED " + generation + " AS IDENTITY"
+	case col.IsGenerated:
+		return " GENERATED ALWAYS AS (" + col.GenerationExpression + ") STORED"
+	case col.HasDefault:
+		return " DEFAULT " + col.Default
+	}
+	return ""
+}
+
+// columnTypeSQL renders the declared type of a column, postgresColumnType is a fallback for sources which do not
+// report it and loses lengths, precisions and types it does not map
+func columnTypeSQL(col Column) string {
+	if len(col.FormattedType) > 0 {
+		return col.FormattedType
+	}
+	return postgresColumnType(col)
+}
+
+// typeDefinitionsSQL lists the statements creating the domains and composite types the tables use, domains first as
+// composite types may have domain attributes
+func typeDefinitionsSQL(tables []Table) []string {
+	domains := []string{}
+	composites := []string{}
+	seen := map[string]bool{}
+	for _, table := range tables {
+		for _, col := range table.Columns {
+			if len(col.TypeDefinition) == 0 || seen[col.TypeDefinition] {
+				continue
+			}
+			seen[col.TypeDefinition] = true
+			if strings.HasPrefix(col.TypeDefinition, "CREATE DOMAIN") {
+				domains = append(domains, col.TypeDefinition)
+			} else {
+				composites = append(composites, col.TypeDefinition)
+			}
+		}
+	}
+	return append(domains, composites...)
+}
+
+func columnDefinitionSQL(col Column) string {
+	def := quoteIdent(col.Name) + " " + columnTypeSQL(col)
+	if !col.IsNullable {
+		def += " NOT NULL"
+	}
+	return def + columnGenerationSQL(col)
+}
+
+// constraintDefinitionSQL renders the definition of a primary key, unique, foreign key or check constraint, empty for
+// the other constraints
+func constraintDefinitionSQL(c tableConstraint) string {
+	switch c.typ {
+	case ConstraintTypePrimaryKey:
+		return fmt.Sprintf("PRIMARY KEY (%s)", quoteIdents(c.columns))
+	case ConstraintTypeUnique:
+		return fmt.Sprintf("UNIQUE (%s)", quoteIdents(c.columns))
+	case ConstraintTypeForeignKey:
+		return fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s (%s)", quoteIdents(c.columns), quoteIdent(c.foreignTablename), quoteIdents(c.foreignColumns))
+	case ConstraintTypeCheck:
+		return c.definition
+	}
+	return ""
+}
+
+// addConstraintSQL renders the statement adding a constraint to an existing table
+func addConstraintSQL(tablename string, c tableConstraint) string {
+	return "ALTER TABLE " + quoteIdent(tablename) + " ADD CONSTRAINT " + quoteIdent(c.name) + " " + constraintDefinitionSQL(c) + ";"
+}
+
+// withoutForeignKeys strips the foreign keys of a table, for tables of a cycle created before the tables they reference
+func withoutForeignKeys(table Table) Table {
+	stripped := table
+	stripped.Columns = make([]Column, len(table.Columns))
+	for i, col := range table.Columns {
+		constraints := []Constraint{}
+		for _, c := range col.Constraints {
+			if c.Type != ConstraintTypeForeignKey {
+				constraints = append(constraints, c)
+			}
+		}
+		col.Constraints = constraints
+		stripped.Columns[i] = col
+	}
+	return stripped
+}
+
+// CreateTableSQL renders the postgres CREATE TABLE statement of a table, including its primary key, unique, foreign key
+// and check constraints, virtual columns are left out. A partition is created as PARTITION OF its parent with only the
+// defaults and constraints it does not inherit
+func CreateTableSQL(table Table) string {
+	partition := len(table.PartitionOf) > 0 && len(table.PartitionBound) > 0
+	lines := []string{}
+	for _, col := range table.Columns {
+		if col.IsVirtual {
+			continue
+		}
+		if !partition {
+			lines = append(lines, columnDefinitionSQL(col))
+		} else if col.HasDefault && !col.DefaultInherited && !col.IsIdentity && !col.IsGenerated {
+			lines = append(lines, quoteIdent(col.Name)+" WITH OPTIONS DEFAULT "+col.Default)
+		}
+	}
+	for _, c := range tableConstraints(table) {
+		if partition && c.inherited {
+			continue
+		}
+		if def := constraintDefinitionSQL(c); len(def) > 0 {
+			lines = append(lines, "CONSTRAINT "+quoteIdent(c.name)+" "+def)
+		}
+	}
+	if partition {
+		columns := ""
+		if len(lines) > 0 {
+			columns = " (\n\t" + strings.Join(lines, ",\n\t") + "\n)"
+		}
+		return "CREATE TABLE " + quoteIdent(table.Name) + " PARTITION OF " + quoteIdent(table.PartitionOf) + columns + " " + table.PartitionBound + ";"
+	}
+	partitionBy := ""
+	if table.Partitioning != nil && len(table.Partitioning.Definition) > 0 {
+		partitionBy = " PARTITION BY " + table.Partitioning.Definition
+	}
+	return "CREATE TABLE " + quoteIdent(table.Name) + " (\n\t" + strings.Join(lines, ",\n\t") + "\n)" + partitionBy + ";"
+}
+
+func CreateEnumSQL(enum Enum) string {
+	labels := make([]string, len(enum.Values))
+	for i, value := range enum.Values {
+		labels[i] = quoteLiteral(value.Label)
+	}
+	return "CREATE TYPE " + quoteIdent(enum.Name) + " AS ENUM (" + strings.Join(labels, ", ") + ");"
+}
@@ -0,0 +1,214 @@
+package inverseschema
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var lakehouseDecimalRe = regexp.MustCompile(`^decimal\(\s*\d+\s*,\s*\d+\s*\)$`)
+
+// deltaDatatypes maps Delta Lake primitive types, float and double have no Datatype and stay unknown like postgres
+// double precision does
+var deltaDatatypes = map[string]Datatype{
+	"string":        DatatypeText,
+	"long":          DatatypeBigint,
+	"integer":       DatatypeInt,
+	"short":         DatatypeSmallint,
+	"byte":          DatatypeSmallint,
+	"boolean":       DatatypeBoolean,
+	"binary":        DatatypeBytea,
+	"date":          DatatypeDate,
+	"timestamp":     DatatypeTimestampz,
+	"timestamp_ntz": DatatypeTimestamp,
+}
+
+// NewDeltaAdapter reads the schema of Delta Lake tables from their transaction log, every path is the root of a table
+// (the directory holding _delta_log) and the table is named after it
+func NewDeltaAdapter(paths ...string) *DeltaAdapter {
+	return &DeltaAdapter{paths: paths}
+}
+
+type DeltaAdapter struct {
+	paths []string
+}
+
+type deltaMetadata struct {
+	Description      string   `json:"description"`
+	SchemaString     string   `json:"schemaString"`
+	PartitionColumns []string `json:"partitionColumns"`
+}
+
+type deltaField struct {
+	Name     string                 `json:"name"`
+	Type     json.RawMessage        `json:"type"`
+	Nullable bool                   `json:"nullable"`
+	Metadata map[string]interface{} `json:"metadata"`
+}
+
+type deltaType struct {
+	Type              string          `json:"type"`
+	Fields            []deltaField    `json:"fields"`
+	ElementType       json.RawMessage `json:"elementType"`
+	ContainsNull      bool            `json:"containsNull"`
+	KeyType           json.RawMessage `json:"keyType"`
+	ValueType         json.RawMessage `json:"valueType"`
+	ValueContainsNull bool            `json:"valueContainsNull"`
+}
+
+// deltaMetadataOf replays the JSON commits of a transaction log in order, the last metaData action holds the current
+// schema. Logs whose JSON commits were cleaned up after a parquet checkpoint cannot be read
+func deltaMetadataOf(path string) (*deltaMetadata, error) {
+	logDir := filepath.Join(path, "_delta_log")
+	entries, err := os.ReadDir(logDir)
+	if err != nil {
+		return nil, err
+	}
+	commits := []string{}
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+			commits = append(commits, entry.Name())
+		}
+	}
+	sort.Strings(commits)
+
+	var metadata *deltaMetadata
+	for _, commit := range commits {
+		f, err := os.Open(filepath.Join(logDir, commit))
+		if err != nil {
+			return nil, err
+		}
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+		for scanner.Scan() {
+			action := struct {
+				MetaData *deltaMetadata `json:"metaData"`
+			}{}
+			if err := json.Unmarshal(scanner.Bytes(), &action); err != nil {
+				f.Close()
+				return nil, fmt.Errorf("delta log %s: %w", commit, err)
+			}
+			if action.MetaData != nil {
+				metadata = action.MetaData
+			}
+		}
+		err = scanner.Err()
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if metadata == nil {
+		return nil, fmt.Errorf("delta log %s: no metaData action in the JSON commits, parquet checkpoints are not supported", logDir)
+	}
+	return metadata, nil
+}
+
+// deltaColumn maps a Delta Lake field, structs keep their fields in Column.Fields and maps become jsonb like they
+// would when projected into postgres
+func deltaColumn(field deltaField, position int) (Column, error) {
+	col := Column{OrdinalPosition: position, Name: field.Name, IsNullable: field.Nullable}
+	if comment, ok := field.Metadata["comment"].(string); ok {
+		col.Comments = comment
+	}
+	raw := field.Type
+	for {
+		var name string
+		if err := json.Unmarshal(raw, &name); err == nil {
+			col.DatatypeRaw = name
+			if datatype, ok := deltaDatatypes[name]; ok {
+				col.Datatype = datatype
+			} else if lakehouseDecimalRe.MatchString(name) || name == "decimal" {
+				col.Datatype = DatatypeNumeric
+			}
+			return col, nil
+		}
+		typ := deltaType{}
+		if err := json.Unmarshal(raw, &typ); err != nil {
+			return col, fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		switch typ.Type {
+		case "array":
+			if col.IsArray {
+				// arrays of arrays are flattened, the column model has a single array level
+				col.DatatypeRaw = "array"
+				return col, nil
+			}
+			col.IsArray = true
+			raw = typ.ElementType
+		case "struct":
+			col.DatatypeRaw = "struct"
+			col.Datatype = DatatypeJsonb
+			for i, nested := range typ.Fields {
+				nestedCol, err := deltaColumn(nested, i+1)
+				if err != nil {
+					return col, fmt.Errorf("field %s: %w", field.Name, err)
+				}
+				col.Fields = append(col.Fields, nestedCol)
+			}
+			return col, nil
+		case "map":
+			col.DatatypeRaw = "map"
+			col.Datatype = DatatypeJsonb
+			return col, nil
+		default:
+			return col, fmt.Errorf("field %s: unsupported type %s", field.Name, typ.Type)
+		}
+	}
+}
+
+func deltaTable(path string) (Table, error) {
+	table := Table{Name: filepath.Base(filepath.Clean(path)), Columns: []Column{}}
+	metadata, err := deltaMetadataOf(path)
+	if err != nil {
+		return table, err
+	}
+	table.Comments = metadata.Description
+	schema := deltaType{}
+	if err := json.Unmarshal([]byte(metadata.SchemaString), &schema); err != nil {
+		return table, fmt.Errorf("delta table %s: %w", table.Name, err)
+	}
+	table.ColumnsByName = make(map[string]Column, len(schema.Fields))
+	for i, field := range schema.Fields {
+		col, err := deltaColumn(field, i+1)
+		if err != nil {
+			return table, fmt.Errorf("delta table %s: %w", table.Name, err)
+		}
+		table.Columns = append(table.Columns, col)
+		table.ColumnsByName[col.Name] = col
+	}
+	if len(metadata.PartitionColumns) > 0 {
+		// delta partitions hold one value of each partition column, the key reads as a postgres list key
+		table.Partitioning = &Partitioning{
+			Strategy:   "list",
+			Columns:    metadata.PartitionColumns,
+			Definition: "LIST (" + quoteIdents(metadata.PartitionColumns) + ")",
+		}
+	}
+	return table, nil
+}
+
+func (a *DeltaAdapter) Tables(ctx context.Context) ([]Table, error) {
+	tables := []Table{}
+	for _, path := range a.paths {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		table, err := deltaTable(path)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+func (a *DeltaAdapter) Enums(ctx context.Context) ([]Enum, error) {
+	return []Enum{}, nil
+}
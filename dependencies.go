@@ -0,0 +1,112 @@
+package inverseschema
+
+import (
+	"strings"
+)
+
+// referencedTables lists the parent of a partition and the tables a table references through foreign keys, self
+// references excluded
+func referencedTables(table Table) []string {
+	seen := map[string]bool{}
+	referenced := []string{}
+	// a partition is created after its parent
+	if len(table.PartitionOf) > 0 {
+		seen[table.PartitionOf] = true
+		referenced = append(referenced, table.PartitionOf)
+	}
+	for _, col := range table.Columns {
+		if !col.IsReference || col.ForeignTablename == table.Name || seen[col.ForeignTablename] {
+			continue
+		}
+		seen[col.ForeignTablename] = true
+		referenced = append(referenced, col.ForeignTablename)
+	}
+	return referenced
+}
+
+// dependencyOrder orders tables so that referenced tables come before the tables referencing them, keeping the
+// schema order otherwise. Tables within or depending on a foreign key cycle cannot be ordered, they are appended
+// in schema order and returned as cyclic
+func dependencyOrder(tables []Table) ([]Table, []string) {
+	known := make(map[string]bool, len(tables))
+	for _, table := range tables {
+		known[table.Name] = true
+	}
+	placed := make(map[string]bool, len(tables))
+	ordered := make([]Table, 0, len(tables))
+	for progress := true; progress; {
+		progress = false
+		for _, table := range tables {
+			if placed[table.Name] {
+				continue
+			}
+			ready := true
+			for _, name := range referencedTables(table) {
+				if known[name] && !placed[name] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				placed[table.Name] = true
+				ordered = append(ordered, table)
+				progress = true
+			}
+		}
+	}
+	cyclic := []string{}
+	for _, table := range tables {
+		if !placed[table.Name] {
+			cyclic = append(cyclic, table.Name)
+			ordered = append(ordered, table)
+		}
+	}
+	return ordered, cyclic
+}
+
+// TableOrder lists table names in the order rows can be loaded or removed. Deferred holds the tables within or
+// depending on a foreign key cycle, no order satisfies their foreign keys: these have to be DEFERRABLE and deferred
+// with SET CONSTRAINTS ALL DEFERRED, or disabled, while the rows are written
+type TableOrder struct {
+	Tables   []string `json:"tables,omitempty"`
+	Deferred []string `json:"deferred,omitempty"`
+}
+
+// SeedOrder orders the tables so that referenced tables are filled before the tables referencing them, tables of
+// foreign key cycles come last
+func (s *Schema) SeedOrder() TableOrder {
+	tables, cyclic := dependencyOrder(s.Tables)
+	order := TableOrder{Tables: make([]string, len(tables)), Deferred: cyclic}
+	for i, table := range tables {
+		order.Tables[i] = table.Name
+	}
+	return order
+}
+
+// TruncateOrder is the reverse of SeedOrder, tables are emptied before the tables they reference
+func (s *Schema) TruncateOrder() TableOrder {
+	order := s.SeedOrder()
+	for i, j := 0, len(order.Tables)-1; i < j; i, j = i+1, j-1 {
+		order.Tables[i], order.Tables[j] = order.Tables[j], order.Tables[i]
+	}
+	return order
+}
+
+// TruncateSQL renders a script emptying every table and restarting their sequences, for resetting a database between
+// integration tests. A single TRUNCATE covers the foreign keys between the tables, partitions are emptied through
+// their parent
+func (s *Schema) TruncateSQL() string {
+	names := []string{}
+	for _, name := range s.TruncateOrder().Tables {
+		if table, ok := s.TableByName(name); ok && len(table.PartitionOf) > 0 {
+			if _, ok := s.TableByName(table.PartitionOf); ok {
+				continue
+			}
+		}
+		names = append(names, quoteIdent(name))
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	return "TRUNCATE TABLE " + strings.Join(names, ", ") + " RESTART IDENTITY;\n"
+}
@@ -0,0 +1,192 @@
+package inverseschema
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var dictionaryColumnHeader = []string{"table", "owner", "column", "type", "nullable", "default", "primary", "unique", "references", "derived_from", "encrypted", "security_label", "comments"}
+
+func dictionaryColumnRow(table Table, col Column) []string {
+	references := ""
+	if col.IsReference {
+		references = col.ForeignTablename + "." + col.ForeignColumnname
+	}
+	return []string{
+		table.Name,
+		table.Owner,
+		col.Name,
+		columnTypeSQL(col),
+		strconv.FormatBool(col.IsNullable),
+		col.Default,
+		strconv.FormatBool(col.IsPrimary),
+		strconv.FormatBool(col.IsUnique),
+		references,
+		strings.Join(col.DerivedFrom, ", "),
+		strconv.FormatBool(col.IsEncrypted),
+		col.SecurityLabel,
+		col.Comments,
+	}
+}
+
+// WriteDictionaryCSV writes a data dictionary with a row per column
+func (s *Schema) WriteDictionaryCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(dictionaryColumnHeader); err != nil {
+		return err
+	}
+	for _, table := range s.Tables {
+		for _, col := range table.Columns {
+			if err := cw.Write(dictionaryColumnRow(table, col)); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+type xlsxSheet struct {
+	name string
+	rows [][]string
+}
+
+func xlsxColumnName(idx int) string {
+	name := ""
+	for idx++; idx > 0; idx = (idx - 1) / 26 {
+		name = string(rune('A'+(idx-1)%26)) + name
+	}
+	return name
+}
+
+func xlsxEscape(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+// xlsxSheetName strips the characters excel rejects in sheet names, keeps it within 31 characters and unique
+func xlsxSheetName(name string, used map[string]bool) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	if len([]rune(name)) > 31 {
+		name = string([]rune(name)[:31])
+	}
+	candidate := name
+	for i := 2; used[strings.ToLower(candidate)]; i++ {
+		suffix := fmt.Sprintf("~%d", i)
+		runes := []rune(name)
+		if len(runes)+len(suffix) > 31 {
+			runes = runes[:31-len(suffix)]
+		}
+		candidate = string(runes) + suffix
+	}
+	used[strings.ToLower(candidate)] = true
+	return candidate
+}
+
+func (sheet xlsxSheet) xml() string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for r, row := range sheet.rows {
+		fmt.Fprintf(&b, `<row r="%d">`, r+1)
+		for c, value := range row {
+			style := ""
+			if r == 0 {
+				style = ` s="1"`
+			}
+			fmt.Fprintf(&b, `<c r="%s%d" t="inlineStr"%s><is><t xml:space="preserve">%s</t></is></c>`, xlsxColumnName(c), r+1, style, xlsxEscape(value))
+		}
+		b.WriteString(`</row>`)
+	}
+	b.WriteString(`</sheetData></worksheet>`)
+	return b.String()
+}
+
+const xlsxStyles = xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="1"><fill><patternFill patternType="none"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>` +
+	`</styleSheet>`
+
+// WriteDictionaryXLSX writes a data dictionary workbook holding an index sheet, an enum sheet and a sheet per table
+func (s *Schema) WriteDictionaryXLSX(w io.Writer) error {
+	used := map[string]bool{}
+	index := xlsxSheet{name: xlsxSheetName("Index", used), rows: [][]string{{"table", "sheet", "columns", "owner", "comments"}}}
+	enums := xlsxSheet{name: xlsxSheetName("Enums", used), rows: [][]string{{"enum", "label", "order", "deprecated"}}}
+	sheets := []xlsxSheet{}
+	for _, table := range s.Tables {
+		sheet := xlsxSheet{name: xlsxSheetName(table.Name, used), rows: [][]string{dictionaryColumnHeader[2:]}}
+		for _, col := range table.Columns {
+			sheet.rows = append(sheet.rows, dictionaryColumnRow(table, col)[2:])
+		}
+		index.rows = append(index.rows, []string{table.Name, sheet.name, strconv.Itoa(len(table.Columns)), table.Owner, table.Comments})
+		sheets = append(sheets, sheet)
+	}
+	for _, enum := range s.Enums {
+		for _, value := range enum.Values {
+			enums.rows = append(enums.rows, []string{enum.Name, value.Label, strconv.Itoa(value.Order), strconv.FormatBool(value.Deprecated)})
+		}
+	}
+	sheets = append([]xlsxSheet{index, enums}, sheets...)
+
+	var contentTypes, workbook, workbookRels strings.Builder
+	contentTypes.WriteString(xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	workbook.WriteString(xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	workbookRels.WriteString(xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i, sheet := range sheets {
+		fmt.Fprintf(&contentTypes, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i+1)
+		fmt.Fprintf(&workbook, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xlsxEscape(sheet.name), i+1, i+1)
+		fmt.Fprintf(&workbookRels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1)
+	}
+	contentTypes.WriteString(`</Types>`)
+	workbook.WriteString(`</sheets></workbook>`)
+	fmt.Fprintf(&workbookRels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/></Relationships>`, len(sheets)+1)
+
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", contentTypes.String()},
+		{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+		{"xl/workbook.xml", workbook.String()},
+		{"xl/_rels/workbook.xml.rels", workbookRels.String()},
+		{"xl/styles.xml", xlsxStyles},
+	}
+	for i, sheet := range sheets {
+		files = append(files, struct {
+			name    string
+			content string
+		}{fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), sheet.xml()})
+	}
+
+	zw := zip.NewWriter(w)
+	for _, file := range files {
+		f, err := zw.Create(file.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, file.content); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
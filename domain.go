@@ -0,0 +1,109 @@
+package inverseschema
+
+import (
+	"path"
+	"sort"
+)
+
+const DomainUnassigned = "unassigned"
+
+type Domain struct {
+	Name     string   `json:"name,omitempty"`
+	Patterns []string `json:"patterns,omitempty"`
+}
+
+type DomainDependency struct {
+	Domain            string `json:"domain,omitempty"`
+	Tablename         string `json:"tablename,omitempty"`
+	Columnname        string `json:"columnname,omitempty"`
+	ForeignDomain     string `json:"foreign_domain,omitempty"`
+	ForeignTablename  string `json:"foreign_tablename,omitempty"`
+	ForeignColumnname string `json:"foreign_columnname,omitempty"`
+}
+
+type Grouping struct {
+	Domains      map[string]*Schema `json:"domains,omitempty"`
+	Dependencies []DomainDependency `json:"dependencies,omitempty"`
+}
+
+// DomainOf resolves the domain of a table, a "@domain:<name>" tag within the table comments
+// takes precedence over the patterns, tables matching neither are DomainUnassigned
+func DomainOf(table Table, domains []Domain) string {
+	if name, ok := commentTag(table.Comments, "domain"); ok && len(name) > 0 {
+		return name
+	}
+	for _, domain := range domains {
+		for _, pattern := range domain.Patterns {
+			if ok, _ := path.Match(pattern, table.Name); ok {
+				return domain.Name
+			}
+		}
+	}
+	return DomainUnassigned
+}
+
+// Group splits the schema into per domain sub-schemas and reports every foreign key crossing a domain boundary
+func (s *Schema) Group(domains ...Domain) *Grouping {
+	g := &Grouping{
+		Domains:      map[string]*Schema{},
+		Dependencies: []DomainDependency{},
+	}
+	tableDomains := make(map[string]string, len(s.Tables))
+	for _, table := range s.Tables {
+		name := DomainOf(table, domains)
+		tableDomains[table.Name] = name
+		sub, ok := g.Domains[name]
+		if !ok {
+			sub = &Schema{adapter: s.adapter, Tables: []Table{}, Enums: []Enum{}}
+			g.Domains[name] = sub
+		}
+		sub.Tables = append(sub.Tables, table)
+	}
+
+	enumsByName := make(map[string]Enum, len(s.Enums))
+	for _, enum := range s.Enums {
+		enumsByName[enum.Name] = enum
+	}
+	for _, sub := range g.Domains {
+		seen := map[string]bool{}
+		for _, table := range sub.Tables {
+			for _, col := range table.Columns {
+				if col.UserDefinedType == nil || seen[col.UserDefinedType.Name] {
+					continue
+				}
+				if enum, ok := enumsByName[col.UserDefinedType.Name]; ok {
+					seen[enum.Name] = true
+					sub.Enums = append(sub.Enums, enum)
+				}
+			}
+		}
+	}
+
+	for _, table := range s.Tables {
+		for _, col := range table.Columns {
+			if !col.IsReference {
+				continue
+			}
+			foreignDomain, ok := tableDomains[col.ForeignTablename]
+			if !ok || foreignDomain == tableDomains[table.Name] {
+				continue
+			}
+			g.Dependencies = append(g.Dependencies, DomainDependency{
+				Domain:            tableDomains[table.Name],
+				Tablename:         table.Name,
+				Columnname:        col.Name,
+				ForeignDomain:     foreignDomain,
+				ForeignTablename:  col.ForeignTablename,
+				ForeignColumnname: col.ForeignColumnname,
+			})
+		}
+	}
+	sort.Slice(g.Dependencies, func(i, j int) bool {
+		a, b := g.Dependencies[i], g.Dependencies[j]
+		if a.Tablename != b.Tablename {
+			return a.Tablename < b.Tablename
+		}
+		return a.Columnname < b.Columnname
+	})
+	return g
+}
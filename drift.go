@@ -0,0 +1,176 @@
+package inverseschema
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+)
+
+type StructMismatch struct {
+	Field      string `json:"field,omitempty"`
+	Columnname string `json:"columnname,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+}
+
+type structField struct {
+	name     string
+	column   string
+	typ      reflect.Type
+	nullable bool
+	notNull  bool
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// goDatatypes lists the datatypes a go kind can be stored in, kinds missing from the map are not type checked
+var goDatatypes = map[reflect.Kind][]Datatype{
+	reflect.String:  {DatatypeText, DatatypeVarchar, DatatypeUuid, DatatypeUserdefined, DatatypeNumeric, DatatypeDecimal, DatatypeJson, DatatypeJsonb},
+	reflect.Bool:    {DatatypeBoolean},
+	reflect.Int:     {DatatypeBigint, DatatypeInt, DatatypeSmallint},
+	reflect.Int64:   {DatatypeBigint, DatatypeInt, DatatypeSmallint},
+	reflect.Int32:   {DatatypeInt, DatatypeSmallint},
+	reflect.Int16:   {DatatypeSmallint},
+	reflect.Uint64:  {DatatypeBigint, DatatypeInt, DatatypeSmallint},
+	reflect.Uint32:  {DatatypeBigint, DatatypeInt, DatatypeSmallint},
+	reflect.Float64: {DatatypeNumeric, DatatypeDecimal, DatatypeVariableNumeric},
+	reflect.Float32: {DatatypeNumeric, DatatypeDecimal, DatatypeVariableNumeric},
+}
+
+func snakeCase(s string) string {
+	var b strings.Builder
+	runes := []rune(s)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// structFields resolves the columns a struct maps to from its db or gorm tags, untagged fields fall back to snake_case
+func structFields(t reflect.Type) []structField {
+	fields := []structField{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct && f.Tag.Get("db") == "" {
+			fields = append(fields, structFields(f.Type)...)
+			continue
+		}
+		if len(f.PkgPath) > 0 {
+			continue
+		}
+		field := structField{name: f.Name, column: snakeCase(f.Name), typ: f.Type}
+		if tag, ok := f.Tag.Lookup("db"); ok {
+			name := strings.Split(tag, ",")[0]
+			if name == "-" {
+				continue
+			}
+			if len(name) > 0 {
+				field.column = name
+			}
+		}
+		if tag, ok := f.Tag.Lookup("gorm"); ok {
+			if tag == "-" {
+				continue
+			}
+			for _, part := range strings.Split(tag, ";") {
+				part = strings.TrimSpace(part)
+				switch {
+				case strings.HasPrefix(strings.ToLower(part), "column:"):
+					field.column = part[len("column:"):]
+				case strings.EqualFold(part, "not null"):
+					field.notNull = true
+				}
+			}
+		}
+		// pointers and sql.Null* wrappers are the nullable go representations
+		if field.typ.Kind() == reflect.Ptr {
+			field.nullable = true
+			field.typ = field.typ.Elem()
+		} else if field.typ.PkgPath() == "database/sql" && strings.HasPrefix(field.typ.Name(), "Null") && field.typ.NumField() == 2 {
+			field.nullable = true
+			field.typ = field.typ.Field(0).Type
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+func structTypeMatches(typ reflect.Type, col Column) bool {
+	if typ == timeType {
+		return col.Datatype == DatatypeTimestamp || col.Datatype == DatatypeTimestampz || col.Datatype == DatatypeDate
+	}
+	if typ.Kind() == reflect.Slice {
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return col.IsArray || col.Datatype == DatatypeBytea || col.Datatype == DatatypeJson || col.Datatype == DatatypeJsonb
+		}
+		if col.Datatype == DatatypeJson || col.Datatype == DatatypeJsonb {
+			return true
+		}
+		if !col.IsArray {
+			return false
+		}
+		elem := col
+		elem.IsArray = false
+		return structTypeMatches(typ.Elem(), elem)
+	}
+	datatypes, ok := goDatatypes[typ.Kind()]
+	if !ok || col.Datatype == DatatypeUnknown {
+		return true
+	}
+	if col.IsArray {
+		return false
+	}
+	for _, datatype := range datatypes {
+		if datatype == col.Datatype {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckStruct compares an annotated go struct (or pointer to one) against a table, reporting mismatching names, types and nullability
+func CheckStruct(table Table, model interface{}) []StructMismatch {
+	t := reflect.TypeOf(model)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	mismatches := []StructMismatch{}
+	if t.Kind() != reflect.Struct {
+		return append(mismatches, StructMismatch{Reason: fmt.Sprintf("%s is not a struct", t)})
+	}
+
+	mapped := map[string]bool{}
+	for _, field := range structFields(t) {
+		mapped[field.column] = true
+		col, ok := table.ColumnsByName[field.column]
+		if !ok {
+			mismatches = append(mismatches, StructMismatch{Field: field.name, Columnname: field.column, Reason: "column does not exist"})
+			continue
+		}
+		if !structTypeMatches(field.typ, col) {
+			mismatches = append(mismatches, StructMismatch{
+				Field:      field.name,
+				Columnname: field.column,
+				Reason:     fmt.Sprintf("field type %s does not match column type %s", field.typ, postgresColumnType(col)),
+			})
+		}
+		if col.IsNullable && field.notNull {
+			mismatches = append(mismatches, StructMismatch{Field: field.name, Columnname: field.column, Reason: "model says NOT NULL, column is nullable"})
+		} else if col.IsNullable && !field.nullable && field.typ.Kind() != reflect.Slice && field.typ.Kind() != reflect.Map && field.typ.Kind() != reflect.Interface {
+			mismatches = append(mismatches, StructMismatch{Field: field.name, Columnname: field.column, Reason: "column is nullable, field cannot hold NULL"})
+		}
+	}
+	for _, col := range table.Columns {
+		if !mapped[col.Name] {
+			mismatches = append(mismatches, StructMismatch{Columnname: col.Name, Reason: "column is not mapped by any field"})
+		}
+	}
+	return mismatches
+}
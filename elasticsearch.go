@@ -0,0 +1,161 @@
+package inverseschema
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+var elasticsearchDatatypes = map[string]Datatype{
+	"text":             DatatypeText,
+	"keyword":          DatatypeText,
+	"constant_keyword": DatatypeText,
+	"wildcard":         DatatypeText,
+	"match_only_text":  DatatypeText,
+	"long":             DatatypeBigint,
+	"integer":          DatatypeInt,
+	"short":            DatatypeSmallint,
+	"byte":             DatatypeSmallint,
+	"scaled_float":     DatatypeNumeric,
+	"boolean":          DatatypeBoolean,
+	"date":             DatatypeTimestampz,
+	"date_nanos":       DatatypeTimestampz,
+	"binary":           DatatypeBytea,
+	"object":           DatatypeJsonb,
+	"nested":           DatatypeJsonb,
+	"flattened":        DatatypeJsonb,
+}
+
+// NewElasticsearchAdapter reads the mappings of Elasticsearch or OpenSearch indices (every index but the hidden ones
+// when none is given) as tables named after the index, fields become nullable columns with object and nested fields
+// in Column.Fields
+func NewElasticsearchAdapter(baseURL string, httpClient *http.Client, indices ...string) *ElasticsearchAdapter {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &ElasticsearchAdapter{baseURL: strings.TrimRight(baseURL, "/"), httpClient: httpClient, indices: indices}
+}
+
+type ElasticsearchAdapter struct {
+	baseURL    string
+	httpClient *http.Client
+	indices    []string
+}
+
+type elasticsearchField struct {
+	Type       string                         `json:"type"`
+	Properties map[string]*elasticsearchField `json:"properties"`
+}
+
+type elasticsearchMappings struct {
+	Meta       map[string]interface{}         `json:"_meta"`
+	Properties map[string]*elasticsearchField `json:"properties"`
+}
+
+func (a *ElasticsearchAdapter) get(ctx context.Context, path string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	res, err := a.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("elasticsearch responded with %d: %s", res.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+// elasticsearchColumns maps the properties of a mapping, properties without a type are objects. Every field may hold
+// several values, nested fields are the only ones marked as arrays since they are indexed as separate documents
+func elasticsearchColumns(properties map[string]*elasticsearchField) []Column {
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	cols := []Column{}
+	for _, name := range names {
+		field := properties[name]
+		if field == nil {
+			continue
+		}
+		typ := field.Type
+		if len(typ) == 0 {
+			typ = "object"
+		}
+		col := Column{OrdinalPosition: len(cols) + 1, Name: name, IsNullable: true, DatatypeRaw: typ, Datatype: elasticsearchDatatypes[typ]}
+		if typ == "nested" {
+			col.IsArray = true
+		}
+		if len(field.Properties) > 0 {
+			col.Fields = elasticsearchColumns(field.Properties)
+		}
+		cols = append(cols, col)
+	}
+	return cols
+}
+
+func (a *ElasticsearchAdapter) Tables(ctx context.Context) ([]Table, error) {
+	path := "/_mapping"
+	if len(a.indices) > 0 {
+		escaped := make([]string, len(a.indices))
+		for i, index := range a.indices {
+			escaped[i] = url.PathEscape(index)
+		}
+		path = "/" + strings.Join(escaped, ",") + "/_mapping"
+	}
+	response := map[string]struct {
+		Mappings json.RawMessage `json:"mappings"`
+	}{}
+	if err := a.get(ctx, path, &response); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(response))
+	for name := range response {
+		if len(a.indices) == 0 && strings.HasPrefix(name, ".") {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	tables := []Table{}
+	for _, name := range names {
+		mappings := elasticsearchMappings{}
+		if err := json.Unmarshal(response[name].Mappings, &mappings); err != nil {
+			return nil, fmt.Errorf("index %s: %w", name, err)
+		}
+		if mappings.Properties == nil {
+			// indices created before 7.0 nest their mapping under a type name
+			typed := map[string]elasticsearchMappings{}
+			if err := json.Unmarshal(response[name].Mappings, &typed); err == nil && len(typed) == 1 {
+				for _, m := range typed {
+					mappings = m
+				}
+			}
+		}
+		table := Table{Name: name, Columns: elasticsearchColumns(mappings.Properties)}
+		if description, ok := mappings.Meta["description"].(string); ok {
+			table.Comments = description
+		}
+		table.ColumnsByName = make(map[string]Column, len(table.Columns))
+		for _, col := range table.Columns {
+			table.ColumnsByName[col.Name] = col
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+func (a *ElasticsearchAdapter) Enums(ctx context.Context) ([]Enum, error) {
+	return []Enum{}, nil
+}
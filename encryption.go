@@ -0,0 +1,52 @@
+package inverseschema
+
+import (
+	"strings"
+)
+
+// detectEncryption flags columns following encrypted storage conventions: an @encrypted annotation,
+// a pgsodium "ENCRYPT WITH KEY ..." security label or a bytea column named *_encrypted / encrypted_*
+func detectEncryption(col *Column) {
+	if _, ok := commentTag(col.Comments, "encrypted"); ok {
+		col.IsEncrypted = true
+	}
+	label := strings.ToUpper(col.SecurityLabel)
+	if strings.HasPrefix(label, "ENCRYPT WITH KEY ") {
+		col.IsEncrypted = true
+		fields := strings.Fields(col.SecurityLabel)
+		if len(fields) >= 5 {
+			col.EncryptionKey = fields[4]
+		}
+	}
+	if col.Datatype == DatatypeBytea && !col.IsArray {
+		name := strings.ToLower(col.Name)
+		if strings.HasSuffix(name, "_encrypted") || strings.HasPrefix(name, "encrypted_") {
+			col.IsEncrypted = true
+		}
+	}
+}
+
+// opaqueColumn is an encrypted column as generators render it: its ciphertext storage type, binary for bytea and text
+// otherwise, without the enum, length or restriction of the plaintext, and with its comments marking it encrypted
+func opaqueColumn(col Column) Column {
+	if !col.IsEncrypted {
+		return col
+	}
+	if col.Datatype != DatatypeBytea {
+		col.Datatype = DatatypeText
+	}
+	col.IsUserDefined = false
+	col.UserDefinedType = nil
+	col.CharacterMaxLength = 0
+	col.Restriction = nil
+	col.Fields = nil
+	note := "encrypted"
+	if len(col.EncryptionKey) > 0 {
+		note += " with key " + col.EncryptionKey
+	}
+	if len(col.Comments) > 0 {
+		note += ", " + col.Comments
+	}
+	col.Comments = note
+	return col
+}
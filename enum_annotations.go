@@ -0,0 +1,48 @@
+package inverseschema
+
+import (
+	"strings"
+)
+
+// EnumAnnotations marks enum values as deprecated and records renames as old label -> new label
+type EnumAnnotations struct {
+	Deprecated []string          `json:"deprecated,omitempty"`
+	Renamed    map[string]string `json:"renamed,omitempty"`
+}
+
+// enumAnnotationsFromComment reads "@deprecated:<label>" and "@renamed:<old>=<new>" tags from an enum type comment
+func enumAnnotationsFromComment(comment string) EnumAnnotations {
+	tags := commentTags(comment)
+	annotations := EnumAnnotations{Deprecated: tags["deprecated"]}
+	for _, value := range tags["renamed"] {
+		idx := strings.Index(value, "=")
+		if idx <= 0 {
+			continue
+		}
+		if annotations.Renamed == nil {
+			annotations.Renamed = map[string]string{}
+		}
+		annotations.Renamed[value[:idx]] = value[idx+1:]
+	}
+	return annotations
+}
+
+func applyEnumAnnotations(enum *Enum, annotations EnumAnnotations) {
+	deprecated := make(map[string]bool, len(annotations.Deprecated))
+	for _, label := range annotations.Deprecated {
+		deprecated[label] = true
+	}
+	renamedFrom := make(map[string]string, len(annotations.Renamed))
+	for from, to := range annotations.Renamed {
+		renamedFrom[to] = from
+	}
+	for i := range enum.Values {
+		value := &enum.Values[i]
+		if deprecated[value.Label] {
+			value.Deprecated = true
+		}
+		if from, ok := renamedFrom[value.Label]; ok {
+			value.RenamedFrom = from
+		}
+	}
+}
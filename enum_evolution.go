@@ -0,0 +1,166 @@
+package inverseschema
+
+import (
+	"fmt"
+)
+
+type EnumChangeKind int
+
+const (
+	EnumChangeRemoved EnumChangeKind = iota + 1
+	EnumChangeValueRemoved
+	EnumChangeValueRenamed
+	EnumChangeValueReordered
+	EnumChangeValueInserted
+	EnumChangeValueAppended
+)
+
+// EnumChange is a change between two versions of an enum, unsafe changes break existing rows or clients
+type EnumChange struct {
+	Enumname string         `json:"enumname,omitempty"`
+	Label    string         `json:"label,omitempty"`
+	Kind     EnumChangeKind `json:"kind,omitempty"`
+	Unsafe   bool           `json:"unsafe,omitempty"`
+	Guidance string         `json:"guidance,omitempty"`
+	SQL      string         `json:"sql,omitempty"`
+}
+
+// increasingPositions returns the indexes of the longest increasing subsequence of positions, the values kept in place
+func increasingPositions(positions []int) map[int]bool {
+	lengths := make([]int, len(positions))
+	previous := make([]int, len(positions))
+	best := -1
+	for i := range positions {
+		lengths[i], previous[i] = 1, -1
+		for j := 0; j < i; j++ {
+			if positions[j] < positions[i] && lengths[j]+1 > lengths[i] {
+				lengths[i], previous[i] = lengths[j]+1, j
+			}
+		}
+		if best < 0 || lengths[i] > lengths[best] {
+			best = i
+		}
+	}
+	kept := map[int]bool{}
+	for i := best; i >= 0; i = previous[i] {
+		kept[i] = true
+	}
+	return kept
+}
+
+// CheckEnumEvolution validates the enum changes between a previous schema (usually a snapshot) and the current one:
+// removed enums and values, renames (declared with @renamed), reordered values and values inserted before existing
+// ones are reported with guidance, appended values are reported as the safe way of extending an enum. Removing a value
+// marked @deprecated in the previous schema is not considered unsafe
+func CheckEnumEvolution(previous *Schema, current *Schema) []EnumChange {
+	changes := []EnumChange{}
+	for _, before := range previous.Enums {
+		after, ok := current.EnumByName(before.Name)
+		if !ok {
+			changes = append(changes, EnumChange{
+				Enumname: before.Name,
+				Kind:     EnumChangeRemoved,
+				Unsafe:   true,
+				Guidance: "columns of this type have to be migrated to another type before it is dropped",
+			})
+			continue
+		}
+
+		positions := make(map[string]int, len(after.Values))
+		renamedTo := map[string]string{}
+		for i, value := range after.Values {
+			positions[value.Label] = i
+			if len(value.RenamedFrom) > 0 {
+				renamedTo[value.RenamedFrom] = value.Label
+			}
+		}
+
+		existing := map[string]bool{}
+		kept := []EnumValue{}
+		keptPositions := []int{}
+		for _, value := range before.Values {
+			label := value.Label
+			if _, ok := positions[label]; !ok {
+				if to, ok := renamedTo[label]; ok {
+					changes = append(changes, EnumChange{
+						Enumname: before.Name,
+						Label:    label,
+						Kind:     EnumChangeValueRenamed,
+						Unsafe:   true,
+						Guidance: fmt.Sprintf("clients sending %q fail once renamed to %q, deploy clients accepting both labels first", label, to),
+						SQL:      fmt.Sprintf("ALTER TYPE %s RENAME VALUE %s TO %s;", quoteIdent(before.Name), quoteLiteral(label), quoteLiteral(to)),
+					})
+					label = to
+				} else {
+					change := EnumChange{
+						Enumname: before.Name,
+						Label:    label,
+						Kind:     EnumChangeValueRemoved,
+						Unsafe:   !value.Deprecated,
+						Guidance: "postgres cannot drop enum values, the type has to be recreated and rows holding the value migrated first",
+					}
+					if value.Deprecated {
+						change.Guidance = "the value was deprecated, verify no rows hold it anymore, the type has to be recreated to drop it"
+					}
+					changes = append(changes, change)
+					continue
+				}
+			}
+			existing[label] = true
+			kept = append(kept, value)
+			keptPositions = append(keptPositions, positions[label])
+		}
+
+		inPlace := increasingPositions(keptPositions)
+		for i, value := range kept {
+			if inPlace[i] {
+				continue
+			}
+			changes = append(changes, EnumChange{
+				Enumname: before.Name,
+				Label:    after.Values[keptPositions[i]].Label,
+				Kind:     EnumChangeValueReordered,
+				Unsafe:   true,
+				Guidance: fmt.Sprintf("%q moved, ORDER BY and comparisons on the column change meaning and postgres requires recreating the type", value.Label),
+			})
+		}
+
+		first, last := len(after.Values), -1
+		for _, position := range keptPositions {
+			if position < first {
+				first = position
+			}
+			if position > last {
+				last = position
+			}
+		}
+		for i, value := range after.Values {
+			if existing[value.Label] {
+				continue
+			}
+			if i < last {
+				// statements are meant to run in order, the previous value then always exists
+				position := "BEFORE " + quoteLiteral(after.Values[first].Label)
+				if i > 0 {
+					position = "AFTER " + quoteLiteral(after.Values[i-1].Label)
+				}
+				changes = append(changes, EnumChange{
+					Enumname: before.Name,
+					Label:    value.Label,
+					Kind:     EnumChangeValueInserted,
+					Guidance: "inserted before existing values, clients relying on the order or on ordinals of the values are affected",
+					SQL:      fmt.Sprintf("ALTER TYPE %s ADD VALUE %s %s;", quoteIdent(before.Name), quoteLiteral(value.Label), position),
+				})
+				continue
+			}
+			changes = append(changes, EnumChange{
+				Enumname: before.Name,
+				Label:    value.Label,
+				Kind:     EnumChangeValueAppended,
+				Guidance: "appending is safe, the new value cannot be used within the transaction adding it",
+				SQL:      fmt.Sprintf("ALTER TYPE %s ADD VALUE %s;", quoteIdent(before.Name), quoteLiteral(value.Label)),
+			})
+		}
+	}
+	return changes
+}
@@ -0,0 +1,150 @@
+package inverseschema
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// EnvironmentRow is an object differing between environments, Values holds its signature per environment in the
+// order of EnvironmentMatrix.Environments, empty when the object is missing there
+type EnvironmentRow struct {
+	Kind       SearchKind `json:"kind,omitempty"`
+	Tablename  string     `json:"tablename,omitempty"`
+	Columnname string     `json:"columnname,omitempty"`
+	Enumname   string     `json:"enumname,omitempty"`
+	Label      string     `json:"label,omitempty"`
+	Values     []string   `json:"values,omitempty"`
+}
+
+func (r EnvironmentRow) object() string {
+	switch r.Kind {
+	case SearchKindTable:
+		return "table " + r.Tablename
+	case SearchKindColumn:
+		return "column " + r.Tablename + "." + r.Columnname
+	case SearchKindEnum:
+		return "enum " + r.Enumname
+	}
+	return "enum value " + r.Enumname + "." + r.Label
+}
+
+type EnvironmentMatrix struct {
+	Environments []string         `json:"environments,omitempty"`
+	Rows         []EnvironmentRow `json:"rows,omitempty"`
+}
+
+// objectPresent is the signature of objects which only differ by their presence
+const objectPresent = "present"
+
+func columnSignature(col Column) string {
+	signature := postgresColumnType(col)
+	if !col.IsNullable {
+		signature += " NOT NULL"
+	}
+	signature += columnGenerationSQL(col)
+	if col.IsReference {
+		signature += " REFERENCES " + col.ForeignTablename + "(" + col.ForeignColumnname + ")"
+	}
+	return signature
+}
+
+type environmentObjects struct {
+	rows       []EnvironmentRow
+	signatures map[string][]string
+}
+
+func (o *environmentObjects) add(row EnvironmentRow, environments int, env int, signature string) {
+	key := row.object()
+	if _, ok := o.signatures[key]; !ok {
+		o.rows = append(o.rows, row)
+		o.signatures[key] = make([]string, environments)
+	}
+	o.signatures[key][env] = signature
+}
+
+// CompareEnvironments compares the schemas of several environments (typically from a Runner report), the matrix
+// lists tables, columns, enums and enum values missing from some environments and columns whose type, nullability,
+// default or reference differ. Columns and values of a table or enum missing from an environment are not repeated
+func CompareEnvironments(environments []string, schemas map[string]*Schema) (*EnvironmentMatrix, error) {
+	objects := &environmentObjects{signatures: map[string][]string{}}
+	n := len(environments)
+	for env, name := range environments {
+		schema, ok := schemas[name]
+		if !ok {
+			return nil, fmt.Errorf("no schema for environment %s", name)
+		}
+		for _, table := range schema.Tables {
+			objects.add(EnvironmentRow{Kind: SearchKindTable, Tablename: table.Name}, n, env, objectPresent)
+			for _, col := range table.Columns {
+				objects.add(EnvironmentRow{Kind: SearchKindColumn, Tablename: table.Name, Columnname: col.Name}, n, env, columnSignature(col))
+			}
+		}
+		for _, enum := range schema.Enums {
+			objects.add(EnvironmentRow{Kind: SearchKindEnum, Enumname: enum.Name}, n, env, objectPresent)
+			for _, value := range enum.Values {
+				objects.add(EnvironmentRow{Kind: SearchKindEnumValue, Enumname: enum.Name, Label: value.Label}, n, env, objectPresent)
+			}
+		}
+	}
+
+	matrix := &EnvironmentMatrix{Environments: environments, Rows: []EnvironmentRow{}}
+	for _, row := range objects.rows {
+		values := objects.signatures[row.object()]
+		parent := ""
+		switch row.Kind {
+		case SearchKindColumn:
+			parent = EnvironmentRow{Kind: SearchKindTable, Tablename: row.Tablename}.object()
+		case SearchKindEnumValue:
+			parent = EnvironmentRow{Kind: SearchKindEnum, Enumname: row.Enumname}.object()
+		}
+		differs := false
+		compared := false
+		reference := ""
+		for env, value := range values {
+			// columns and values are only compared where their table or enum exists
+			if len(parent) > 0 && len(objects.signatures[parent][env]) == 0 {
+				continue
+			}
+			if !compared {
+				compared, reference = true, value
+				continue
+			}
+			if value != reference {
+				differs = true
+			}
+		}
+		if !differs {
+			continue
+		}
+		row.Values = values
+		matrix.Rows = append(matrix.Rows, row)
+	}
+	return matrix, nil
+}
+
+func markdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+// WriteMarkdown renders the matrix as a markdown table, missing objects are marked as such
+func (m *EnvironmentMatrix) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("| object |")
+	for _, env := range m.Environments {
+		b.WriteString(" " + markdownCell(env) + " |")
+	}
+	b.WriteString("\n|---|" + strings.Repeat("---|", len(m.Environments)) + "\n")
+	for _, row := range m.Rows {
+		b.WriteString("| " + markdownCell(row.object()) + " |")
+		for _, value := range row.Values {
+			if len(value) == 0 {
+				value = "missing"
+			}
+			b.WriteString(" " + markdownCell(value) + " |")
+		}
+		b.WriteString("\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
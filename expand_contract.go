@@ -0,0 +1,209 @@
+package inverseschema
+
+import (
+	"strings"
+)
+
+// ExpandContractPhase names the phase of a zero-downtime rollout a step belongs to: expand runs before the new
+// version of the application is deployed, backfill once it writes the new columns and contract once no instance of
+// the previous version is left
+type ExpandContractPhase string
+
+const (
+	PhaseExpand   ExpandContractPhase = "expand"
+	PhaseBackfill ExpandContractPhase = "backfill"
+	PhaseContract ExpandContractPhase = "contract"
+)
+
+// ExpandContractStep holds the statements of a change run in one phase, Locks annotates them with the lock they take
+type ExpandContractStep struct {
+	Phase         ExpandContractPhase `json:"phase,omitempty"`
+	Change        Change              `json:"change,omitempty"`
+	SQL           string              `json:"sql,omitempty"`
+	NoTransaction bool                `json:"no_transaction,omitempty"`
+	Note          string              `json:"note,omitempty"`
+	Locks         []StatementLock     `json:"locks,omitempty"`
+}
+
+// setNotNullSQL renders the statements adding a NOT NULL constraint, from postgres 12 on a CHECK constraint is
+// validated first under a SHARE UPDATE EXCLUSIVE lock so that SET NOT NULL skips its scan
+func setNotNullSQL(tablename string, columnname string, version int) []string {
+	set := alterColumnSQL(tablename, columnname, "SET NOT NULL")
+	if version > 0 && version < 120000 {
+		return []string{set}
+	}
+	table := "ALTER TABLE " + quoteIdent(tablename)
+	check := quoteIdent(fitIdentifier(tablename+"_"+columnname, "_not_null"))
+	return []string{
+		table + " ADD CONSTRAINT " + check + " CHECK (" + quoteIdent(columnname) + " IS NOT NULL) NOT VALID;",
+		table + " VALIDATE CONSTRAINT " + check + ";",
+		set,
+		table + " DROP CONSTRAINT " + check + ";",
+	}
+}
+
+// columnConstraintsSQL renders the statements adding the constraints of the current table involving a column
+func columnConstraintsSQL(table *Table, columnname string) []string {
+	statements := []string{}
+	for _, c := range tableConstraints(*table) {
+		for _, name := range c.columns {
+			if name == columnname && len(constraintDefinitionSQL(c)) > 0 {
+				statements = append(statements, addConstraintSQL(table.Name, c))
+				break
+			}
+		}
+	}
+	return statements
+}
+
+// columnIndexesSQL renders the statements building concurrently the indexes of the current table over a column which
+// back no constraint
+func columnIndexesSQL(table *Table, columnname string) []string {
+	constraintNames := map[string]bool{}
+	for _, c := range tableConstraints(*table) {
+		constraintNames[c.name] = true
+	}
+	statements := []string{}
+	for _, index := range table.Indexes {
+		if constraintNames[index.Name] || index.IsPrimary || len(index.Definition) == 0 {
+			continue
+		}
+		for _, name := range index.Columns {
+			if name == columnname {
+				statements = append(statements, strings.Replace(index.Definition, " INDEX ", " INDEX CONCURRENTLY ", 1)+";")
+				break
+			}
+		}
+	}
+	return statements
+}
+
+// ExpandContract turns the statements of MigrationSQL into a zero-downtime rollout. The changes rated breaking are
+// split into phases instead of being applied in place:
+//
+//   - a NOT NULL column is added nullable, backfilled with its default and constrained in the contract phase
+//   - a renamed column is added under its new name, backfilled from the old one which the contract phase drops
+//   - a column changing type is added as a shadow column, backfilled with a cast and swapped in the contract phase
+//   - a renamed table keeps its old name as a view until the contract phase
+//   - drops and the other breaking changes run in the contract phase
+//
+// The other changes run in the expand phase, except for drops which always wait for the contract phase. Every step
+// carries the locks its statements take on the server version of the previous schema. Writes made by the previous
+// version of the application between the backfill and the contract phase are not copied, the backfill can be run
+// again right before the contract phase
+func (c *ChangeSet) ExpandContract(previous *Schema, current *Schema) []ExpandContractStep {
+	m := newMigrationSchemas(c.Changes, previous, current)
+	version := migrationServerVersion(previous, current)
+	phases := map[ExpandContractPhase][]ExpandContractStep{}
+	add := func(phase ExpandContractPhase, change Change, statements []string, note string) {
+		if len(statements) == 0 {
+			return
+		}
+		phases[phase] = append(phases[phase], ExpandContractStep{Phase: phase, Change: change, SQL: joinStatements(statements), Note: note})
+	}
+	expanded := map[Change]bool{}
+	// rebuilt holds the columns added with their final definition, the other changes of the column are part of it
+	rebuilt := map[string]bool{}
+	for _, statement := range c.MigrationSQL(previous, current) {
+		change := statement.Change
+		if expanded[change] {
+			// a follow-up statement of an expanded change, adding the constraints of the new column
+			add(PhaseExpand, change, []string{statement.Up}, "")
+			continue
+		}
+		if len(change.Columnname) > 0 && rebuilt[change.Tablename+"."+change.Columnname] {
+			continue
+		}
+		table := quoteIdent(change.Tablename)
+		column := quoteIdent(change.Columnname)
+		from, to := m.columns(change)
+		after, _ := current.TableByName(change.Tablename)
+		breaking := change.Severity == SeverityBreaking
+		switch {
+		case change.Kind == ChangeTableRemoved, change.Kind == ChangeColumnRemoved, change.Kind == ChangeEnumRemoved:
+			add(PhaseContract, change, []string{statement.Up}, "")
+		case !breaking:
+			phases[PhaseExpand] = append(phases[PhaseExpand], ExpandContractStep{Phase: PhaseExpand, Change: change, SQL: statement.Up, NoTransaction: statement.NoTransaction})
+		case change.Kind == ChangeColumnAdded && !to.IsIdentity && !to.IsGenerated:
+			expanded[change] = true
+			nullable := to
+			nullable.IsNullable = true
+			add(PhaseExpand, change, []string{strings.Replace(statement.Up, columnDefinitionSQL(to), columnDefinitionSQL(nullable), 1)}, "")
+			if to.HasDefault {
+				add(PhaseBackfill, change, []string{"UPDATE " + table + " SET " + column + " = " + to.Default + " WHERE " + column + " IS NULL;"}, "run in batches on large tables")
+			} else {
+				add(PhaseBackfill, change, []string{"-- fill " + change.Tablename + "." + change.Columnname + " for the existing rows"}, "the column has no default to backfill with")
+			}
+			add(PhaseContract, change, setNotNullSQL(change.Tablename, change.Columnname, version), "")
+		case change.Kind == ChangeColumnNotNull:
+			expanded[change] = true
+			if to.HasDefault {
+				add(PhaseBackfill, change, []string{"UPDATE " + table + " SET " + column + " = " + to.Default + " WHERE " + column + " IS NULL;"}, "run in batches on large tables")
+			} else {
+				add(PhaseBackfill, change, []string{"-- fill the nulls of " + change.Tablename + "." + change.Columnname}, "the column has no default to backfill with")
+			}
+			add(PhaseContract, change, setNotNullSQL(change.Tablename, change.Columnname, version), "")
+		case change.Kind == ChangeColumnRenamed && !to.IsIdentity && !to.IsGenerated && after != nil:
+			expanded[change] = true
+			rebuilt[change.Tablename+"."+change.Columnname] = true
+			nullable := to
+			nullable.IsNullable = true
+			add(PhaseExpand, change, []string{"ALTER TABLE " + table + " ADD COLUMN " + columnDefinitionSQL(nullable) + ";"}, "")
+			value := quoteIdent(change.From)
+			if columnTypeSQL(from) != columnTypeSQL(to) {
+				value += "::" + columnTypeSQL(to)
+			}
+			add(PhaseBackfill, change, []string{"UPDATE " + table + " SET " + column + " = " + value + ";"}, "run in batches on large tables, or keep both columns in sync with a trigger")
+			contract := []string{"ALTER TABLE " + table + " DROP COLUMN " + quoteIdent(change.From) + ";"}
+			if !to.IsNullable {
+				contract = append(contract, setNotNullSQL(change.Tablename, change.Columnname, version)...)
+			}
+			add(PhaseContract, change, append(contract, columnConstraintsSQL(after, change.Columnname)...), "")
+			add(PhaseContract, change, columnIndexesSQL(after, change.Columnname), "")
+		case (change.Kind == ChangeColumnNarrowed || change.Kind == ChangeColumnTypeChanged) && !from.IsGenerated && !to.IsGenerated && after != nil:
+			expanded[change] = true
+			rebuilt[change.Tablename+"."+change.Columnname] = true
+			shadow := to
+			shadow.Name = fitIdentifier(change.Columnname, "_new")
+			shadow.IsNullable, shadow.HasDefault, shadow.IsIdentity = true, false, false
+			add(PhaseExpand, change, []string{strings.Replace(statement.Up, columnTypeChangeSQL(change.Tablename, to), "ALTER TABLE "+table+" ADD COLUMN "+columnDefinitionSQL(shadow)+";", 1)}, "")
+			note := "run in batches on large tables, or keep both columns in sync with a trigger"
+			if change.Kind == ChangeColumnNarrowed {
+				note = "fails on the values which do not fit the narrower type, " + note
+			}
+			add(PhaseBackfill, change, []string{"UPDATE " + table + " SET " + quoteIdent(shadow.Name) + " = " + column + "::" + columnTypeSQL(to) + ";"}, note)
+			contract := []string{
+				"ALTER TABLE " + table + " DROP COLUMN " + column + ";",
+				"ALTER TABLE " + table + " RENAME COLUMN " + quoteIdent(shadow.Name) + " TO " + column + ";",
+			}
+			if to.HasDefault {
+				contract = append(contract, columnDefaultSQL(change.Tablename, to))
+			}
+			if !to.IsNullable {
+				contract = append(contract, setNotNullSQL(change.Tablename, change.Columnname, version)...)
+			}
+			add(PhaseContract, change, append(contract, columnConstraintsSQL(after, change.Columnname)...), "")
+			add(PhaseContract, change, columnIndexesSQL(after, change.Columnname), "")
+		case change.Kind == ChangeTableRenamed:
+			expanded[change] = true
+			// the previous version of the application keeps reading and writing the table through a view
+			add(PhaseExpand, change, []string{statement.Up, "CREATE VIEW " + quoteIdent(change.From) + " AS SELECT * FROM " + table + ";"}, "")
+			add(PhaseContract, change, []string{"DROP VIEW " + quoteIdent(change.From) + ";"}, "")
+		default:
+			phases[PhaseContract] = append(phases[PhaseContract], ExpandContractStep{Phase: PhaseContract, Change: change, SQL: statement.Up, NoTransaction: statement.NoTransaction})
+		}
+	}
+
+	steps := []ExpandContractStep{}
+	for _, phase := range []ExpandContractPhase{PhaseExpand, PhaseBackfill, PhaseContract} {
+		for _, step := range phases[phase] {
+			// indexes are built concurrently outside of a transaction
+			step.NoTransaction = step.NoTransaction || strings.Contains(step.SQL, " INDEX CONCURRENTLY ")
+			if locks := AnnotateLocks(step.SQL, version); len(locks) > 0 {
+				step.Locks = locks
+			}
+			steps = append(steps, step)
+		}
+	}
+	return steps
+}
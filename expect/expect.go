@@ -0,0 +1,191 @@
+package expect
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/oiime/inverseschema"
+)
+
+func New() *Expectations {
+	return &Expectations{}
+}
+
+// Expectations declares what the application assumes about the database, Verify fails with every mismatch at once
+type Expectations struct {
+	tables []*TableExpectation
+	enums  []*EnumExpectation
+}
+
+type TableExpectation struct {
+	name    string
+	columns []*ColumnExpectation
+}
+
+type ColumnExpectation struct {
+	table    *TableExpectation
+	name     string
+	datatype inverseschema.Datatype
+	checks   []func(col inverseschema.Column) string
+}
+
+type EnumExpectation struct {
+	name   string
+	labels []string
+}
+
+type Error struct {
+	Mismatches []string
+}
+
+func (e *Error) Error() string {
+	return "schema does not match expectations:\n\t" + strings.Join(e.Mismatches, "\n\t")
+}
+
+func (e *Expectations) Table(name string) *TableExpectation {
+	t := &TableExpectation{name: name}
+	e.tables = append(e.tables, t)
+	return t
+}
+
+// Enum expects an enum holding at least the given labels
+func (e *Expectations) Enum(name string, labels ...string) *EnumExpectation {
+	enum := &EnumExpectation{name: name, labels: labels}
+	e.enums = append(e.enums, enum)
+	return enum
+}
+
+func (t *TableExpectation) Column(name string, datatype inverseschema.Datatype) *ColumnExpectation {
+	c := &ColumnExpectation{table: t, name: name, datatype: datatype}
+	t.columns = append(t.columns, c)
+	return c
+}
+
+// Column declares another column on the same table, allowing a single chain per table
+func (c *ColumnExpectation) Column(name string, datatype inverseschema.Datatype) *ColumnExpectation {
+	return c.table.Column(name, datatype)
+}
+
+func (c *ColumnExpectation) check(fn func(col inverseschema.Column) string) *ColumnExpectation {
+	c.checks = append(c.checks, fn)
+	return c
+}
+
+func (c *ColumnExpectation) NotNull() *ColumnExpectation {
+	return c.check(func(col inverseschema.Column) string {
+		if col.IsNullable {
+			return "expected NOT NULL, column is nullable"
+		}
+		return ""
+	})
+}
+
+func (c *ColumnExpectation) Nullable() *ColumnExpectation {
+	return c.check(func(col inverseschema.Column) string {
+		if !col.IsNullable {
+			return "expected nullable, column is NOT NULL"
+		}
+		return ""
+	})
+}
+
+func (c *ColumnExpectation) Unique() *ColumnExpectation {
+	return c.check(func(col inverseschema.Column) string {
+		if !col.IsUnique && !col.IsPrimary {
+			return "expected unique, column has no unique constraint"
+		}
+		return ""
+	})
+}
+
+func (c *ColumnExpectation) Primary() *ColumnExpectation {
+	return c.check(func(col inverseschema.Column) string {
+		if !col.IsPrimary {
+			return "expected primary key, column is not part of the primary key"
+		}
+		return ""
+	})
+}
+
+func (c *ColumnExpectation) Array() *ColumnExpectation {
+	return c.check(func(col inverseschema.Column) string {
+		if !col.IsArray {
+			return "expected an array, column is not an array"
+		}
+		return ""
+	})
+}
+
+func (c *ColumnExpectation) MaxLength(length int) *ColumnExpectation {
+	return c.check(func(col inverseschema.Column) string {
+		if col.CharacterMaxLength != length {
+			return fmt.Sprintf("expected max length %d, column max length is %d", length, col.CharacterMaxLength)
+		}
+		return ""
+	})
+}
+
+func (c *ColumnExpectation) References(tablename string, columnname string) *ColumnExpectation {
+	return c.check(func(col inverseschema.Column) string {
+		if !col.IsReference || col.ForeignTablename != tablename || col.ForeignColumnname != columnname {
+			return fmt.Sprintf("expected a reference to %s.%s", tablename, columnname)
+		}
+		return ""
+	})
+}
+
+// Verify introspects the database through the adapter and checks it against the expectations
+func (e *Expectations) Verify(ctx context.Context, adapter inverseschema.Adapter) error {
+	schema := inverseschema.NewSchema(adapter)
+	if err := schema.ParseContext(ctx); err != nil {
+		return err
+	}
+	return e.Check(schema)
+}
+
+func (e *Expectations) Check(schema *inverseschema.Schema) error {
+	mismatches := []string{}
+	for _, t := range e.tables {
+		table, ok := schema.TableByName(t.name)
+		if !ok {
+			mismatches = append(mismatches, fmt.Sprintf("%s: table does not exist", t.name))
+			continue
+		}
+		for _, c := range t.columns {
+			col, ok := table.ColumnsByName[c.name]
+			if !ok {
+				mismatches = append(mismatches, fmt.Sprintf("%s.%s: column does not exist", t.name, c.name))
+				continue
+			}
+			if col.Datatype != c.datatype {
+				mismatches = append(mismatches, fmt.Sprintf("%s.%s: expected datatype %s, column datatype is %s (%s)", t.name, c.name, c.datatype, col.Datatype, col.DatatypeRaw))
+			}
+			for _, check := range c.checks {
+				if msg := check(col); len(msg) > 0 {
+					mismatches = append(mismatches, fmt.Sprintf("%s.%s: %s", t.name, c.name, msg))
+				}
+			}
+		}
+	}
+	for _, e := range e.enums {
+		enum, ok := schema.EnumByName(e.name)
+		if !ok {
+			mismatches = append(mismatches, fmt.Sprintf("%s: enum does not exist", e.name))
+			continue
+		}
+		labels := map[string]bool{}
+		for _, value := range enum.Values {
+			labels[value.Label] = true
+		}
+		for _, label := range e.labels {
+			if !labels[label] {
+				mismatches = append(mismatches, fmt.Sprintf("%s: enum has no value %q", e.name, label))
+			}
+		}
+	}
+	if len(mismatches) > 0 {
+		return &Error{Mismatches: mismatches}
+	}
+	return nil
+}
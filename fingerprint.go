@@ -0,0 +1,53 @@
+package inverseschema
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// structuralTable strips what depends on the data rather than on the structure of a table: activity statistics,
+// index sizes and sampled json shapes, ColumnsByName is left out as it repeats the columns
+func structuralTable(table Table) Table {
+	table.Stats = nil
+	table.ColumnsByName = nil
+	table.Columns = append([]Column{}, table.Columns...)
+	for i := range table.Columns {
+		table.Columns[i].InferredShape = nil
+	}
+	table.Indexes = append([]Index{}, table.Indexes...)
+	for i := range table.Indexes {
+		table.Indexes[i].SizeBytes = 0
+	}
+	return table
+}
+
+func structuralTables(tables []Table) []Table {
+	stripped := make([]Table, len(tables))
+	for i, table := range tables {
+		stripped[i] = structuralTable(table)
+	}
+	return stripped
+}
+
+// Fingerprint is a sha256 over the structure of the schema: tables, enums, views and materialized views without
+// statistics, sizes or sampled data, permissions and overlay. Identical schemas share a fingerprint whatever data
+// they hold
+func (s *Schema) Fingerprint() (string, error) {
+	matviews := make([]MaterializedView, len(s.MaterializedViews))
+	for i, view := range s.MaterializedViews {
+		view.IsPopulated = false
+		matviews[i] = view
+	}
+	data, err := json.Marshal(struct {
+		Tables            []Table            `json:"tables"`
+		Enums             []Enum             `json:"enums"`
+		Views             []Table            `json:"views"`
+		MaterializedViews []MaterializedView `json:"materialized_views"`
+	}{structuralTables(s.Tables), s.Enums, structuralTables(s.Views), matviews})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,73 @@
+package inverseschema
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/template"
+)
+
+type generatorOptions struct {
+	packageName string
+	incremental bool
+	views       bool
+	templateDir string
+	// templateHash fingerprints the template overrides in use, so that incremental runs rewrite files when they change
+	templateHash string
+}
+
+type GeneratorOption func(o *generatorOptions)
+
+// WithPackage sets the package (or namespace) generated code is declared in
+func WithPackage(name string) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.packageName = name
+	}
+}
+
+// WithTemplateDir overrides templates of the template based generators: the .tmpl files found in
+// <dir>/<generator> (cue, jpa, sqlalchemy or sqitch) are parsed over the built-in templates, so a file holding a single
+// {{define "field"}} block replaces the field rendering and leaves the rest untouched
+func WithTemplateDir(dir string) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.templateDir = dir
+	}
+}
+
+func newGeneratorOptions(defaultPackage string, opts []GeneratorOption) *generatorOptions {
+	o := &generatorOptions{packageName: defaultPackage}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// template returns the templates of a generator with the overrides of the template directory applied
+func (o *generatorOptions) template(generator string, builtin *template.Template) (*template.Template, error) {
+	if len(o.templateDir) == 0 {
+		return builtin, nil
+	}
+	files, err := filepath.Glob(filepath.Join(o.templateDir, generator, "*.tmpl"))
+	if err != nil || len(files) == 0 {
+		return builtin, err
+	}
+	tmpl, err := builtin.Clone()
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.New()
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tmpl.Parse(string(content)); err != nil {
+			return nil, fmt.Errorf("template %s: %w", file, err)
+		}
+		hash.Write(content)
+	}
+	o.templateHash = hex.EncodeToString(hash.Sum(nil))
+	return tmpl, nil
+}
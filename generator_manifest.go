@@ -0,0 +1,227 @@
+package inverseschema
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/template"
+)
+
+// GeneratorManifestFile is the manifest directory generators write next to their output
+const GeneratorManifestFile = ".inverseschema.json"
+
+// GeneratedFile is a file written by a generator, Path is relative to the output directory and Fingerprint covers
+// the tables and enums the file renders
+type GeneratedFile struct {
+	Path        string   `json:"path,omitempty"`
+	Tables      []string `json:"tables,omitempty"`
+	Enums       []string `json:"enums,omitempty"`
+	Fingerprint string   `json:"fingerprint,omitempty"`
+}
+
+// GeneratorManifest lists the files a directory generator produced and the fingerprint of the schema they were
+// generated from
+type GeneratorManifest struct {
+	Generator   string          `json:"generator,omitempty"`
+	Fingerprint string          `json:"fingerprint,omitempty"`
+	Files       []GeneratedFile `json:"files,omitempty"`
+}
+
+// ReadGeneratorManifest reads the manifest of an output directory, it returns nil without error when there is none
+func ReadGeneratorManifest(dir string) (*GeneratorManifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, GeneratorManifestFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	manifest := &GeneratorManifest{}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// Artifacts maps the generated files to the tables they render, as expected by PlanRename
+func (m *GeneratorManifest) Artifacts() map[string][]string {
+	artifacts := map[string][]string{}
+	for _, file := range m.Files {
+		artifacts[file.Path] = file.Tables
+	}
+	return artifacts
+}
+
+// WithIncremental only rewrites the files of a directory generator whose tables or enums changed since the run
+// recorded in the manifest of the output directory
+func WithIncremental() GeneratorOption {
+	return func(o *generatorOptions) {
+		o.incremental = true
+	}
+}
+
+// generatorOutput writes the files of a directory generator and its manifest, files whose previous version is no
+// longer produced are removed
+type generatorOutput struct {
+	dir      string
+	options  *generatorOptions
+	schema   *Schema
+	previous map[string]GeneratedFile
+	manifest GeneratorManifest
+}
+
+func (s *Schema) newGeneratorOutput(dir string, generator string, o *generatorOptions) (*generatorOutput, error) {
+	fingerprint, err := s.Fingerprint()
+	if err != nil {
+		return nil, err
+	}
+	out := &generatorOutput{
+		dir:      dir,
+		options:  o,
+		schema:   s,
+		previous: map[string]GeneratedFile{},
+		manifest: GeneratorManifest{Generator: generator, Fingerprint: fingerprint, Files: []GeneratedFile{}},
+	}
+	previous, err := ReadGeneratorManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	if previous != nil && previous.Generator == generator {
+		for _, file := range previous.Files {
+			out.previous[file.Path] = file
+		}
+	}
+	return out, nil
+}
+
+// sourceFingerprint hashes the structure of the tables and enums a file renders along with the template overrides,
+// statistics and sizes change without the generated code changing
+func (out *generatorOutput) sourceFingerprint(tables []string, enums []string) (string, error) {
+	source := struct {
+		Generator string  `json:"generator"`
+		Package   string  `json:"package"`
+		Templates string  `json:"templates"`
+		Tables    []Table `json:"tables"`
+		Enums     []Enum  `json:"enums"`
+	}{Generator: out.manifest.Generator, Package: out.options.packageName, Templates: out.options.templateHash}
+	for _, name := range tables {
+		if table, ok := out.schema.TableByName(name); ok {
+			source.Tables = append(source.Tables, structuralTable(*table))
+		}
+	}
+	for _, name := range enums {
+		if enum, ok := out.schema.EnumByName(name); ok {
+			source.Enums = append(source.Enums, *enum)
+		}
+	}
+	data, err := json.Marshal(source)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// write renders a template into path (relative to the output directory) unless the generation is incremental and
+// the tables and enums it renders did not change
+func (out *generatorOutput) write(path string, tmpl *template.Template, name string, data interface{}, tables []string, enums []string) error {
+	return out.writeFile(path, tables, enums, func(target string) error {
+		return writeTemplateFile(target, tmpl, name, data)
+	})
+}
+
+// writeFile records path in the manifest and calls render with its location unless the generation is incremental
+// and the tables and enums it renders did not change
+func (out *generatorOutput) writeFile(path string, tables []string, enums []string, render func(target string) error) error {
+	tables, enums = uniqueNames(tables), uniqueNames(enums)
+	fingerprint, err := out.sourceFingerprint(tables, enums)
+	if err != nil {
+		return err
+	}
+	out.manifest.Files = append(out.manifest.Files, GeneratedFile{Path: path, Tables: tables, Enums: enums, Fingerprint: fingerprint})
+	target := filepath.Join(out.dir, path)
+	if previous, ok := out.previous[path]; ok && out.options.incremental && previous.Fingerprint == fingerprint {
+		if _, err := os.Stat(target); err == nil {
+			return nil
+		}
+	}
+	return render(target)
+}
+
+// WriteGeneratedFile runs a writer generator such as WriteCUE, WriteSQLAlchemy or WriteXSD into path within dir and
+// records the file in the manifest of dir, as directory generators do. The file renders every table and enum, with
+// WithIncremental it is only rewritten when one of them changed. A directory holds the output of a single generator
+func (s *Schema) WriteGeneratedFile(dir string, path string, generator string, write func(w io.Writer, opts ...GeneratorOption) error, opts ...GeneratorOption) error {
+	o := newGeneratorOptions("", opts)
+	out, err := s.newGeneratorOutput(dir, generator, o)
+	if err != nil {
+		return err
+	}
+	tables := []string{}
+	for _, table := range o.tables(s) {
+		tables = append(tables, table.Name)
+	}
+	enums := make([]string, len(s.Enums))
+	for i, enum := range s.Enums {
+		enums[i] = enum.Name
+	}
+	err = out.writeFile(path, tables, enums, func(target string) error {
+		f, err := os.Create(target)
+		if err != nil {
+			return err
+		}
+		if err := write(f, opts...); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	})
+	if err != nil {
+		return err
+	}
+	return out.close()
+}
+
+// uniqueNames sorts a copy of names without duplicates
+func uniqueNames(names []string) []string {
+	if len(names) == 0 {
+		return nil
+	}
+	sorted := append([]string{}, names...)
+	sort.Strings(sorted)
+	unique := sorted[:1]
+	for _, name := range sorted[1:] {
+		if name != unique[len(unique)-1] {
+			unique = append(unique, name)
+		}
+	}
+	return unique
+}
+
+// close removes the files of the previous run which were not produced again and writes the manifest
+func (out *generatorOutput) close() error {
+	produced := map[string]bool{}
+	for _, file := range out.manifest.Files {
+		produced[file.Path] = true
+	}
+	for path := range out.previous {
+		if produced[path] || !filepath.IsLocal(path) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(out.dir, path)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(out.manifest); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(out.dir, GeneratorManifestFile), b.Bytes(), 0644)
+}
@@ -0,0 +1,203 @@
+package inverseschema
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Glossary holds descriptions maintained outside of the database, Tables by table name and Columns by
+// "table.column" or "*.column" for a column of every table, the qualified form takes precedence
+type Glossary struct {
+	Tables  map[string]string `json:"tables,omitempty"`
+	Columns map[string]string `json:"columns,omitempty"`
+}
+
+func newGlossary() *Glossary {
+	return &Glossary{Tables: map[string]string{}, Columns: map[string]string{}}
+}
+
+// add files a description under a key: "table", "table.column" or "*.column"
+func (g *Glossary) add(key string, description string) error {
+	key, description = strings.TrimSpace(key), strings.TrimSpace(description)
+	if len(key) == 0 {
+		return fmt.Errorf("glossary entry without key")
+	}
+	if len(description) == 0 {
+		return nil
+	}
+	if strings.Contains(key, ".") {
+		g.Columns[key] = description
+	} else {
+		g.Tables[key] = description
+	}
+	return nil
+}
+
+// LoadGlossaryCSV reads a glossary from a CSV file whose rows are a key (table, table.column or *.column) and a
+// description, a first row starting with "key" is taken as header
+func LoadGlossaryCSV(r io.Reader) (*Glossary, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	glossary := newGlossary()
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if line == 1 && strings.EqualFold(strings.TrimSpace(record[0]), "key") {
+			continue
+		}
+		if len(record) < 2 {
+			return nil, fmt.Errorf("glossary line %d: expected a key and a description", line)
+		}
+		if err := glossary.add(record[0], record[1]); err != nil {
+			return nil, fmt.Errorf("glossary line %d: %w", line, err)
+		}
+	}
+	return glossary, nil
+}
+
+// LoadGlossaryYAML reads a glossary from a flat YAML mapping of keys (table, table.column or *.column) to
+// descriptions, values may be plain or quoted scalars on a single line
+func LoadGlossaryYAML(r io.Reader) (*Glossary, error) {
+	glossary := newGlossary()
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if len(text) == 0 || strings.HasPrefix(text, "#") || text == "---" {
+			continue
+		}
+		key, value, err := yamlEntry(text)
+		if err != nil {
+			return nil, fmt.Errorf("glossary line %d: %w", line, err)
+		}
+		if err := glossary.add(key, value); err != nil {
+			return nil, fmt.Errorf("glossary line %d: %w", line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return glossary, nil
+}
+
+// yamlEntry splits a "key: value" line, keys and values may be quoted
+func yamlEntry(text string) (string, string, error) {
+	key, rest, err := yamlScalar(text, ":")
+	if err != nil {
+		return "", "", err
+	}
+	rest = strings.TrimSpace(rest)
+	if !strings.HasPrefix(rest, ":") {
+		return "", "", fmt.Errorf("expected key: value")
+	}
+	value, rest, err := yamlScalar(strings.TrimSpace(rest[1:]), " #")
+	if err != nil {
+		return "", "", err
+	}
+	if rest = strings.TrimSpace(rest); len(rest) > 0 && !strings.HasPrefix(rest, "#") {
+		return "", "", fmt.Errorf("unexpected %q after value", rest)
+	}
+	return key, value, nil
+}
+
+// yamlScalar reads a quoted scalar, or a plain one up to the terminator, returning the remaining text
+func yamlScalar(text string, terminator string) (string, string, error) {
+	switch {
+	case strings.HasPrefix(text, `"`):
+		for i := 1; i < len(text); i++ {
+			if text[i] == '\\' {
+				i++
+				continue
+			}
+			if text[i] == '"' {
+				value, err := strconv.Unquote(text[:i+1])
+				return value, text[i+1:], err
+			}
+		}
+		return "", "", fmt.Errorf("unterminated quoted string")
+	case strings.HasPrefix(text, "'"):
+		var b strings.Builder
+		for i := 1; i < len(text); i++ {
+			if text[i] == '\'' {
+				if i+1 < len(text) && text[i+1] == '\'' {
+					b.WriteByte('\'')
+					i++
+					continue
+				}
+				return b.String(), text[i+1:], nil
+			}
+			b.WriteByte(text[i])
+		}
+		return "", "", fmt.Errorf("unterminated quoted string")
+	}
+	if idx := strings.Index(text, terminator); idx >= 0 {
+		return strings.TrimSpace(text[:idx]), text[idx:], nil
+	}
+	return strings.TrimSpace(text), "", nil
+}
+
+func (g *Glossary) ColumnDescription(tablename string, columnname string) (string, bool) {
+	if description, ok := g.Columns[tablename+"."+columnname]; ok {
+		return description, true
+	}
+	description, ok := g.Columns["*."+columnname]
+	return description, ok
+}
+
+// glossaryComment merges a description into a comment: comments holding only annotations are considered missing and
+// keep their annotations after the description, others are kept unless override is set
+func glossaryComment(comment string, description string, override bool) (string, bool) {
+	annotations := []string{}
+	described := false
+	for _, field := range strings.Fields(comment) {
+		if len(field) > 1 && field[0] == '@' {
+			annotations = append(annotations, field)
+		} else {
+			described = true
+		}
+	}
+	if described && !override {
+		return comment, false
+	}
+	return strings.Join(append([]string{description}, annotations...), " "), true
+}
+
+// ApplyGlossary fills the missing comments of tables and columns from the glossary, database comments win unless
+// override is set. Annotations of the comments are kept, it returns the number of comments set
+func (s *Schema) ApplyGlossary(glossary *Glossary, override bool) int {
+	applied := 0
+	for i := range s.Tables {
+		table := &s.Tables[i]
+		if description, ok := glossary.Tables[table.Name]; ok {
+			if comment, set := glossaryComment(table.Comments, description, override); set {
+				table.Comments = comment
+				applied++
+			}
+		}
+		for j := range table.Columns {
+			col := &table.Columns[j]
+			description, ok := glossary.ColumnDescription(table.Name, col.Name)
+			if !ok {
+				continue
+			}
+			comment, set := glossaryComment(col.Comments, description, override)
+			if !set {
+				continue
+			}
+			col.Comments = comment
+			applied++
+			if table.ColumnsByName != nil {
+				table.ColumnsByName[col.Name] = *col
+			}
+		}
+	}
+	return applied
+}
@@ -0,0 +1,76 @@
+package inverseschema
+
+// HistoryPeriod holds the validity columns of a history table, either a From and To pair or a single Range column
+// (the temporal_tables sys_period convention)
+type HistoryPeriod struct {
+	From  string `json:"from,omitempty"`
+	To    string `json:"to,omitempty"`
+	Range string `json:"range,omitempty"`
+}
+
+var historyPeriodPairs = [][2]string{
+	{"valid_from", "valid_to"},
+	{"valid_from", "valid_until"},
+	{"period_start", "period_end"},
+	{"effective_from", "effective_to"},
+	{"sys_start", "sys_end"},
+	{"row_start", "row_end"},
+}
+
+var historyPeriodRanges = map[string]bool{"sys_period": true, "valid_period": true, "validity": true, "period": true}
+
+func historyPeriod(table Table) *HistoryPeriod {
+	names := map[string]Column{}
+	for _, col := range table.Columns {
+		names[col.Name] = col
+	}
+	for _, pair := range historyPeriodPairs {
+		_, from := names[pair[0]]
+		_, to := names[pair[1]]
+		if from && to {
+			return &HistoryPeriod{From: pair[0], To: pair[1]}
+		}
+	}
+	for _, col := range table.Columns {
+		if historyPeriodRanges[col.Name] && (col.DatatypeRaw == "tstzrange" || col.DatatypeRaw == "tsrange" || col.DatatypeRaw == "daterange") {
+			return &HistoryPeriod{Range: col.Name}
+		}
+	}
+	return nil
+}
+
+// historyMirrors tells whether a history table holds every column of its parent with the same type
+func historyMirrors(parent Table, history Table) bool {
+	columns := map[string]Column{}
+	for _, col := range history.Columns {
+		columns[col.Name] = col
+	}
+	for _, col := range parent.Columns {
+		mirrored, ok := columns[col.Name]
+		if !ok || mirrored.DatatypeRaw != col.DatatypeRaw || mirrored.IsArray != col.IsArray {
+			return false
+		}
+	}
+	return len(parent.Columns) > 0
+}
+
+// PairHistoryTables pairs history tables with the table they record: named after it (orders_history, audit_orders,
+// ...), holding every column of it and validity period columns. The pairing is set on both tables, ParseContext runs
+// it after parsing
+func (s *Schema) PairHistoryTables() {
+	for i := range s.Tables {
+		history := &s.Tables[i]
+		parentname, ok := s.historyParent(*history)
+		if !ok || len(history.HistoryOf) > 0 {
+			continue
+		}
+		parent, _ := s.TableByName(parentname)
+		period := historyPeriod(*history)
+		if period == nil || len(parent.HistoryTable) > 0 || !historyMirrors(*parent, *history) {
+			continue
+		}
+		history.HistoryOf = parent.Name
+		history.HistoryPeriod = period
+		parent.HistoryTable = history.Name
+	}
+}
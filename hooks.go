@@ -0,0 +1,48 @@
+package inverseschema
+
+import (
+	"errors"
+)
+
+// ErrSkipColumn and ErrSkipTable can be returned by hooks to drop the column or table from the result
+var (
+	ErrSkipColumn = errors.New("skip this column")
+	ErrSkipTable  = errors.New("skip this table")
+)
+
+type ColumnHook func(col *Column) error
+type TableHook func(table *Table) error
+
+// applyHooks runs column hooks followed by table hooks, it reports whether the table should be kept
+func applyHooks(table *Table, columnHooks []ColumnHook, tableHooks []TableHook) (bool, error) {
+	if len(columnHooks) > 0 {
+		cols := make([]Column, 0, len(table.Columns))
+	COLUMNS:
+		for _, col := range table.Columns {
+			for _, hook := range columnHooks {
+				if err := hook(&col); err != nil {
+					if errors.Is(err, ErrSkipColumn) {
+						continue COLUMNS
+					}
+					return false, err
+				}
+			}
+			cols = append(cols, col)
+		}
+		table.Columns = cols
+		table.ColumnsByName = make(map[string]Column, len(cols))
+		for _, col := range cols {
+			table.ColumnsByName[col.Name] = col
+		}
+	}
+
+	for _, hook := range tableHooks {
+		if err := hook(table); err != nil {
+			if errors.Is(err, ErrSkipTable) {
+				return false, nil
+			}
+			return false, err
+		}
+	}
+	return true, nil
+}
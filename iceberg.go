@@ -0,0 +1,236 @@
+package inverseschema
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var icebergDatatypes = map[string]Datatype{
+	"boolean":        DatatypeBoolean,
+	"int":            DatatypeInt,
+	"long":           DatatypeBigint,
+	"date":           DatatypeDate,
+	"timestamp":      DatatypeTimestamp,
+	"timestamp_ns":   DatatypeTimestamp,
+	"timestamptz":    DatatypeTimestampz,
+	"timestamptz_ns": DatatypeTimestampz,
+	"string":         DatatypeText,
+	"uuid":           DatatypeUuid,
+	"binary":         DatatypeBytea,
+}
+
+// NewIcebergAdapter reads the schema of Iceberg tables from their metadata files, every path is the location of a
+// table (the directory holding metadata/) and the table is named after it
+func NewIcebergAdapter(paths ...string) *IcebergAdapter {
+	return &IcebergAdapter{paths: paths}
+}
+
+type IcebergAdapter struct {
+	paths []string
+}
+
+type icebergField struct {
+	ID       int             `json:"id"`
+	Name     string          `json:"name"`
+	Required bool            `json:"required"`
+	Type     json.RawMessage `json:"type"`
+	Doc      string          `json:"doc"`
+}
+
+type icebergType struct {
+	Type            string          `json:"type"`
+	SchemaID        int             `json:"schema-id"`
+	Fields          []icebergField  `json:"fields"`
+	Element         json.RawMessage `json:"element"`
+	ElementRequired bool            `json:"element-required"`
+}
+
+type icebergMetadata struct {
+	CurrentSchemaID int               `json:"current-schema-id"`
+	Schema          *icebergType      `json:"schema"`
+	Schemas         []icebergType     `json:"schemas"`
+	DefaultSpecID   int               `json:"default-spec-id"`
+	Properties      map[string]string `json:"properties"`
+	PartitionSpecs  []struct {
+		SpecID int `json:"spec-id"`
+		Fields []struct {
+			Name      string `json:"name"`
+			Transform string `json:"transform"`
+			SourceID  int    `json:"source-id"`
+		} `json:"fields"`
+	} `json:"partition-specs"`
+}
+
+// icebergMetadataVersion is the version of a metadata file, named v3.metadata.json or 00003-<uuid>.metadata.json
+func icebergMetadataVersion(name string) int {
+	name = strings.TrimPrefix(name, "v")
+	end := 0
+	for end < len(name) && name[end] >= '0' && name[end] <= '9' {
+		end++
+	}
+	version, err := strconv.Atoi(name[:end])
+	if err != nil {
+		return -1
+	}
+	return version
+}
+
+// icebergMetadataFile finds the current metadata file of a table, from version-hint.text when present, otherwise the
+// highest versioned metadata file
+func icebergMetadataFile(path string) (string, error) {
+	metadataDir := filepath.Join(path, "metadata")
+	if hint, err := os.ReadFile(filepath.Join(metadataDir, "version-hint.text")); err == nil {
+		file := filepath.Join(metadataDir, "v"+strings.TrimSpace(string(hint))+".metadata.json")
+		if _, err := os.Stat(file); err == nil {
+			return file, nil
+		}
+	}
+	entries, err := os.ReadDir(metadataDir)
+	if err != nil {
+		return "", err
+	}
+	files := []string{}
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".metadata.json") && icebergMetadataVersion(entry.Name()) >= 0 {
+			files = append(files, entry.Name())
+		}
+	}
+	if len(files) == 0 {
+		return "", fmt.Errorf("iceberg table %s: no metadata file", path)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return icebergMetadataVersion(files[i]) < icebergMetadataVersion(files[j])
+	})
+	return filepath.Join(metadataDir, files[len(files)-1]), nil
+}
+
+// icebergColumn maps an Iceberg field, structs keep their fields in Column.Fields, maps become jsonb and float,
+// double, time and fixed stay unknown
+func icebergColumn(field icebergField, position int) (Column, error) {
+	col := Column{OrdinalPosition: position, Name: field.Name, IsNullable: !field.Required, Comments: field.Doc}
+	raw := field.Type
+	for {
+		var name string
+		if err := json.Unmarshal(raw, &name); err == nil {
+			col.DatatypeRaw = name
+			if datatype, ok := icebergDatatypes[name]; ok {
+				col.Datatype = datatype
+			} else if lakehouseDecimalRe.MatchString(name) {
+				col.Datatype = DatatypeNumeric
+			}
+			return col, nil
+		}
+		typ := icebergType{}
+		if err := json.Unmarshal(raw, &typ); err != nil {
+			return col, fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		switch typ.Type {
+		case "list":
+			if col.IsArray {
+				col.DatatypeRaw = "list"
+				return col, nil
+			}
+			col.IsArray = true
+			raw = typ.Element
+		case "struct":
+			col.DatatypeRaw = "struct"
+			col.Datatype = DatatypeJsonb
+			for i, nested := range typ.Fields {
+				nestedCol, err := icebergColumn(nested, i+1)
+				if err != nil {
+					return col, fmt.Errorf("field %s: %w", field.Name, err)
+				}
+				col.Fields = append(col.Fields, nestedCol)
+			}
+			return col, nil
+		case "map":
+			col.DatatypeRaw = "map"
+			col.Datatype = DatatypeJsonb
+			return col, nil
+		default:
+			return col, fmt.Errorf("field %s: unsupported type %s", field.Name, typ.Type)
+		}
+	}
+}
+
+func icebergTable(path string) (Table, error) {
+	table := Table{Name: filepath.Base(filepath.Clean(path)), Columns: []Column{}}
+	file, err := icebergMetadataFile(path)
+	if err != nil {
+		return table, err
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return table, err
+	}
+	metadata := icebergMetadata{}
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return table, fmt.Errorf("iceberg table %s: %w", table.Name, err)
+	}
+	schema := metadata.Schema
+	for i := range metadata.Schemas {
+		if metadata.Schemas[i].SchemaID == metadata.CurrentSchemaID {
+			schema = &metadata.Schemas[i]
+		}
+	}
+	if schema == nil {
+		return table, fmt.Errorf("iceberg table %s: no current schema in %s", table.Name, file)
+	}
+	table.Comments = metadata.Properties["comment"]
+
+	table.ColumnsByName = make(map[string]Column, len(schema.Fields))
+	names := map[int]string{}
+	for i, field := range schema.Fields {
+		col, err := icebergColumn(field, i+1)
+		if err != nil {
+			return table, fmt.Errorf("iceberg table %s: %w", table.Name, err)
+		}
+		names[field.ID] = field.Name
+		table.Columns = append(table.Columns, col)
+		table.ColumnsByName[col.Name] = col
+	}
+
+	for _, spec := range metadata.PartitionSpecs {
+		if spec.SpecID != metadata.DefaultSpecID || len(spec.Fields) == 0 {
+			continue
+		}
+		table.Partitioning = &Partitioning{Strategy: "list"}
+		transforms := []string{}
+		for _, field := range spec.Fields {
+			// like postgres expression keys, transformed partition fields hold an empty column name
+			column, key := "", field.Transform+"("+quoteIdent(names[field.SourceID])+")"
+			if field.Transform == "identity" {
+				column, key = names[field.SourceID], quoteIdent(names[field.SourceID])
+			}
+			table.Partitioning.Columns = append(table.Partitioning.Columns, column)
+			transforms = append(transforms, key)
+		}
+		table.Partitioning.Definition = "LIST (" + strings.Join(transforms, ", ") + ")"
+	}
+	return table, nil
+}
+
+func (a *IcebergAdapter) Tables(ctx context.Context) ([]Table, error) {
+	tables := []Table{}
+	for _, path := range a.paths {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		table, err := icebergTable(path)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+func (a *IcebergAdapter) Enums(ctx context.Context) ([]Enum, error) {
+	return []Enum{}, nil
+}
@@ -0,0 +1,92 @@
+package inverseschema
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// Dependent is a database object depending on a column, Kind is view, policy, trigger, generated (a generated column
+// or default computed from the column), function or constraint
+type Dependent struct {
+	Kind string `json:"kind,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+// DependencyAdapter is implemented by adapters able to list the objects depending on a column
+type DependencyAdapter interface {
+	ColumnDependents(ctx context.Context, tablename string, columnname string) ([]Dependent, error)
+}
+
+// ColumnImpact aggregates everything affected by dropping or renaming a column
+type ColumnImpact struct {
+	Tablename    string      `json:"tablename,omitempty"`
+	Columnname   string      `json:"columnname,omitempty"`
+	ReferencedBy []ColumnRef `json:"referenced_by,omitempty"`
+	Constraints  []string    `json:"constraints,omitempty"`
+	Indexes      []string    `json:"indexes,omitempty"`
+	Dependents   []Dependent `json:"dependents,omitempty"`
+}
+
+func (s *Schema) Impact(tablename string, columnname string) (*ColumnImpact, error) {
+	return s.ImpactContext(context.Background(), tablename, columnname)
+}
+
+// ImpactContext reports the foreign keys referencing a column, the constraints and indexes covering it (expression
+// and partial indexes included) and, when the adapter supports it, the views, policies, triggers and generated
+// columns depending on it
+func (s *Schema) ImpactContext(ctx context.Context, tablename string, columnname string) (*ColumnImpact, error) {
+	table, ok := s.TableByName(tablename)
+	if !ok {
+		return nil, fmt.Errorf("unknown table %s", tablename)
+	}
+	col, ok := table.ColumnsByName[columnname]
+	if !ok {
+		return nil, fmt.Errorf("unknown column %s.%s", tablename, columnname)
+	}
+	impact := &ColumnImpact{Tablename: tablename, Columnname: columnname}
+
+	for _, other := range s.Tables {
+		for _, ref := range other.Columns {
+			if ref.IsReference && ref.ForeignTablename == tablename && ref.ForeignColumnname == columnname {
+				impact.ReferencedBy = append(impact.ReferencedBy, ColumnRef{Tablename: other.Name, Columnname: ref.Name})
+			}
+		}
+	}
+
+	constraints := map[string]bool{}
+	for _, c := range col.Constraints {
+		if !constraints[c.Name] {
+			constraints[c.Name] = true
+			impact.Constraints = append(impact.Constraints, c.Name)
+		}
+	}
+
+	mention := regexp.MustCompile(`(^|[^A-Za-z0-9_$])"?` + regexp.QuoteMeta(columnname) + `"?($|[^A-Za-z0-9_$])`)
+	for _, index := range table.Indexes {
+		covered := mention.MatchString(index.Predicate)
+		for _, indexed := range index.Columns {
+			if indexed == columnname || mention.MatchString(indexed) {
+				covered = true
+			}
+		}
+		if covered {
+			impact.Indexes = append(impact.Indexes, index.Name)
+		}
+	}
+
+	if adapter, ok := s.adapter.(DependencyAdapter); ok {
+		dependents, err := adapter.ColumnDependents(ctx, tablename, columnname)
+		if err != nil && !errors.Is(err, ErrNotSupported) {
+			return nil, err
+		}
+		for _, dependent := range dependents {
+			if dependent.Kind == "constraint" && constraints[dependent.Name] {
+				continue
+			}
+			impact.Dependents = append(impact.Dependents, dependent)
+		}
+	}
+	return impact, nil
+}
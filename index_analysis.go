@@ -0,0 +1,177 @@
+package inverseschema
+
+import (
+	"sort"
+	"strings"
+)
+
+type RedundantIndex struct {
+	Tablename string `json:"tablename,omitempty"`
+	Index     string `json:"index,omitempty"`
+	CoveredBy string `json:"covered_by,omitempty"`
+	// Duplicates lists every index of the group of identical indexes the index belongs to, the kept one included
+	Duplicates  []string `json:"duplicates,omitempty"`
+	Reason      string   `json:"reason,omitempty"`
+	WastedBytes int64    `json:"wasted_bytes,omitempty"`
+	DropSQL     string   `json:"drop_sql,omitempty"`
+	// Lock is the lock dropping the index takes on the server version of the schema
+	Lock *LockImpact `json:"lock,omitempty"`
+}
+
+func columnsHavePrefix(columns []string, prefix []string) bool {
+	if len(prefix) > len(columns) {
+		return false
+	}
+	for i := range prefix {
+		if columns[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// indexKeys splits the definition of an index into its key elements, with their collation, operator class and
+// ordering, and its INCLUDE list. Indexes without a definition fall back to their key columns
+func indexKeys(index Index) ([]string, string) {
+	using := strings.Index(index.Definition, " USING ")
+	if using < 0 {
+		return index.Columns, ""
+	}
+	open := strings.Index(index.Definition[using:], "(")
+	if open < 0 {
+		return index.Columns, ""
+	}
+	open += using
+	keys := []string{}
+	depth, start, quote := 0, open+1, byte(0)
+	for i := open; i < len(index.Definition); i++ {
+		switch c := index.Definition[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			if depth--; depth == 0 {
+				keys = append(keys, strings.TrimSpace(index.Definition[start:i]))
+				include := ""
+				if rest := strings.TrimSpace(index.Definition[i+1:]); strings.HasPrefix(rest, "INCLUDE (") {
+					include, _, _ = strings.Cut(strings.TrimPrefix(rest, "INCLUDE ("), ")")
+				}
+				return keys, include
+			}
+		case c == ',' && depth == 1:
+			keys = append(keys, strings.TrimSpace(index.Definition[start:i]))
+			start = i + 1
+		}
+	}
+	return index.Columns, ""
+}
+
+// indexesIdentical reports whether two indexes share their method, predicate, keys and INCLUDE list
+func indexesIdentical(a Index, b Index) bool {
+	if a.Method != b.Method || a.Predicate != b.Predicate {
+		return false
+	}
+	aKeys, aInclude := indexKeys(a)
+	bKeys, bInclude := indexKeys(b)
+	return strings.Join(aKeys, ",") == strings.Join(bKeys, ",") && aInclude == bInclude
+}
+
+// duplicateGroups groups the identical indexes of a table sharing their uniqueness, primary keys aside, and picks the
+// one kept of each group: an index backing a constraint, otherwise the one sorting first
+func duplicateGroups(table Table, constraintNames map[string]bool) (map[string][]string, map[string]string) {
+	groups, kept := map[string][]string{}, map[string]string{}
+	for _, a := range table.Indexes {
+		if a.IsPrimary {
+			continue
+		}
+		group := []string{}
+		keep := ""
+		for _, b := range table.Indexes {
+			if b.IsPrimary || a.IsUnique != b.IsUnique || !indexesIdentical(a, b) {
+				continue
+			}
+			group = append(group, b.Name)
+			switch {
+			case len(keep) == 0,
+				constraintNames[b.Name] && !constraintNames[keep],
+				constraintNames[b.Name] == constraintNames[keep] && b.Name < keep:
+				keep = b.Name
+			}
+		}
+		if len(group) > 1 {
+			sort.Strings(group)
+			groups[a.Name], kept[a.Name] = group, keep
+		}
+	}
+	return groups, kept
+}
+
+// indexRedundantWith reports whether index a is made redundant by index b, and why. Keys are compared with their
+// operator class and ordering, and INCLUDE lists have to match. Of identical indexes only the kept one covers the others
+func indexRedundantWith(a Index, b Index, kept string) (string, bool) {
+	if a.IsPrimary || a.Method != b.Method || a.Predicate != b.Predicate {
+		return "", false
+	}
+	aKeys, aInclude := indexKeys(a)
+	bKeys, bInclude := indexKeys(b)
+	identical := strings.Join(aKeys, ",") == strings.Join(bKeys, ",") && aInclude == bInclude
+	switch {
+	case identical && a.IsUnique && b.IsPrimary:
+		return "unique index duplicates the primary key", true
+	case identical && a.IsUnique == b.IsUnique && !b.IsPrimary:
+		if b.Name == kept && a.Name != kept {
+			return "identical definition", true
+		}
+	case identical && !a.IsUnique && (b.IsUnique || b.IsPrimary):
+		return "identical definition, the other index is unique", true
+	case !a.IsUnique && a.Method == "btree" && len(aInclude) == 0 && len(aKeys) < len(bKeys) && columnsHavePrefix(bKeys, aKeys):
+		return "columns are a prefix of another index", true
+	}
+	return "", false
+}
+
+// RedundantIndexes detects indexes covered by another index of the same table: identical definitions,
+// btree prefix duplicates and unique indexes duplicating the primary key. Indexes backing a constraint are never
+// reported, dropping them means dropping the constraint
+func (s *Schema) RedundantIndexes() []RedundantIndex {
+	redundant := []RedundantIndex{}
+	for _, table := range s.Tables {
+		constraintNames := map[string]bool{}
+		for _, c := range tableConstraints(table) {
+			constraintNames[c.name] = true
+		}
+		groups, kept := duplicateGroups(table, constraintNames)
+		for _, a := range table.Indexes {
+			if constraintNames[a.Name] {
+				continue
+			}
+			for _, b := range table.Indexes {
+				if a.Name == b.Name {
+					continue
+				}
+				reason, ok := indexRedundantWith(a, b, kept[a.Name])
+				if !ok {
+					continue
+				}
+				redundant = append(redundant, RedundantIndex{
+					Tablename:   table.Name,
+					Index:       a.Name,
+					CoveredBy:   b.Name,
+					Duplicates:  groups[a.Name],
+					Reason:      reason,
+					WastedBytes: a.SizeBytes,
+					DropSQL:     "DROP INDEX " + quoteIdent(a.Name) + ";",
+				})
+				index := &redundant[len(redundant)-1]
+				index.Lock = statementLock(index.DropSQL, s.ServerVersion)
+				break
+			}
+		}
+	}
+	return redundant
+}
@@ -0,0 +1,61 @@
+package inverseschema
+
+import (
+	"path"
+	"strings"
+)
+
+// DefaultInternalPatterns match the housekeeping tables and columns of common migration tools and frameworks
+var DefaultInternalPatterns = []string{
+	"schema_migrations",
+	"goose_db_version",
+	"__diesel_schema_migrations",
+	"_prisma_migrations",
+	"flyway_schema_history",
+	"databasechangelog",
+	"databasechangeloglock",
+	"knex_migrations",
+	"knex_migrations_lock",
+	"ar_internal_metadata",
+	"django_migrations",
+	"alembic_version",
+	"hdb_*",
+	"postgraphile_*",
+}
+
+// InternalPatternsHook returns a table hook dropping internal objects: patterns without a dot match table names and
+// patterns of the form table.column match columns, both with path.Match syntax
+func InternalPatternsHook(patterns ...string) TableHook {
+	return func(table *Table) error {
+		cols := make([]Column, 0, len(table.Columns))
+		for _, col := range table.Columns {
+			if !internalObject(patterns, table.Name+"."+col.Name, true) {
+				cols = append(cols, col)
+			}
+		}
+		if internalObject(patterns, table.Name, false) {
+			return ErrSkipTable
+		}
+		if len(cols) == len(table.Columns) {
+			return nil
+		}
+		table.Columns = cols
+		table.ColumnsByName = make(map[string]Column, len(cols))
+		for _, col := range cols {
+			table.ColumnsByName[col.Name] = col
+		}
+		return nil
+	}
+}
+
+func internalObject(patterns []string, name string, column bool) bool {
+	for _, pattern := range patterns {
+		if strings.Contains(pattern, ".") != column {
+			continue
+		}
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
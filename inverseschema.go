@@ -2,30 +2,119 @@ package inverseschema
 
 import (
 	"context"
+	"errors"
+	"log/slog"
+	"time"
 )
 
-func NewSchema(adapter Adapter) *Schema {
-	return &Schema{adapter: adapter}
+func NewSchema(adapter Adapter, opts ...SchemaOption) *Schema {
+	s := &Schema{adapter: adapter}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 type Schema struct {
-	adapter Adapter
-	Tables  []Table
-	Enums   []Enum
+	adapter     Adapter
+	logger      *slog.Logger
+	objects     ObjectKind
+	Tables      []Table
+	Enums       []Enum
+	Permissions *PermissionGraph `json:"permissions,omitempty"`
+	// Views is filled by ParseViews
+	Views []Table `json:"views,omitempty"`
+	// MaterializedViews is filled by ParseMaterializedViews
+	MaterializedViews []MaterializedView `json:"materialized_views,omitempty"`
+	Overlay           *Overlay           `json:"overlay,omitempty"`
+	// ServerVersion is the server_version_num of the database, 0 when the adapter does not tell it
+	ServerVersion int `json:"server_version,omitempty"`
 }
 
 func (s *Schema) Parse() error {
 	return s.ParseContext(context.Background())
 }
+
+// ParseContext fills the schema from its adapter, when the adapter truncated its tables on a limit the enums are
+// still parsed and the *LimitError is returned once done. Only the kinds selected with WithObjects are parsed
 func (s *Schema) ParseContext(ctx context.Context) error {
 	var err error
-	s.Tables, err = s.adapter.Tables(ctx)
-	if err != nil {
-		return err
+	var truncated error
+	started := time.Now()
+	kinds := s.objects
+	if kinds == 0 {
+		kinds = ObjectDefault
+	} else {
+		ctx = ContextWithObjects(ctx, kinds)
+	}
+	if kinds&ObjectTables != 0 {
+		s.Tables, err = s.adapter.Tables(ctx)
+		var limitErr *LimitError
+		if errors.As(err, &limitErr) && limitErr.Truncated {
+			s.log().WarnContext(ctx, "tables truncated", "error", err, "tables", len(s.Tables))
+			truncated = err
+		} else if err != nil {
+			return err
+		}
+		s.PairHistoryTables()
+		s.DetectNaturalKeys()
+	}
+	if kinds&ObjectEnums != 0 {
+		if s.Enums, err = s.adapter.Enums(ctx); err != nil {
+			return err
+		}
+	}
+	if kinds&ObjectViews != 0 {
+		if err := s.ParseViews(ctx); errors.Is(err, ErrNotSupported) {
+			s.log().DebugContext(ctx, "views not supported by adapter")
+		} else if err != nil {
+			return err
+		}
+	}
+	if kinds&ObjectMaterializedViews != 0 {
+		if err := s.ParseMaterializedViews(ctx); errors.Is(err, ErrNotSupported) {
+			s.log().DebugContext(ctx, "materialized views not supported by adapter")
+		} else if err != nil {
+			return err
+		}
+	}
+	if adapter, ok := s.adapter.(VersionAdapter); ok {
+		if s.ServerVersion, err = adapter.ServerVersion(ctx); errors.Is(err, ErrNotSupported) {
+			s.ServerVersion = 0
+		} else if err != nil {
+			return err
+		}
 	}
-	s.Enums, err = s.adapter.Enums(ctx)
+	s.log().InfoContext(ctx, "parsed schema", "tables", len(s.Tables), "enums", len(s.Enums), "views", len(s.Views), "duration", time.Since(started))
+	return truncated
+}
+
+// ParseEnums only fills the enums of the schema
+func (s *Schema) ParseEnums(ctx context.Context) error {
+	started := time.Now()
+	enums, err := s.adapter.Enums(ctx)
 	if err != nil {
 		return err
 	}
+	s.Enums = enums
+	s.log().InfoContext(ctx, "parsed enums", "enums", len(s.Enums), "duration", time.Since(started))
 	return nil
 }
+
+func (s *Schema) TableByName(name string) (*Table, bool) {
+	for i := range s.Tables {
+		if s.Tables[i].Name == name {
+			return &s.Tables[i], true
+		}
+	}
+	return nil, false
+}
+
+func (s *Schema) EnumByName(name string) (*Enum, bool) {
+	for i := range s.Enums {
+		if s.Enums[i].Name == name {
+			return &s.Enums[i], true
+		}
+	}
+	return nil, false
+}
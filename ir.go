@@ -0,0 +1,113 @@
+package inverseschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// IRError lists every problem found while validating an IR document
+type IRError struct {
+	Problems []string
+}
+
+func (e *IRError) Error() string {
+	return "invalid IR document:\n\t" + strings.Join(e.Problems, "\n\t")
+}
+
+// ValidateIR validates a JSON IR document (the snapshot format, see IR.md), in strict mode unknown fields are rejected
+func ValidateIR(data []byte, strict bool) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if strict {
+		dec.DisallowUnknownFields()
+	}
+	schema := &Schema{}
+	if err := dec.Decode(schema); err != nil {
+		return &IRError{Problems: []string{err.Error()}}
+	}
+	if problems := schema.validate(); len(problems) > 0 {
+		return &IRError{Problems: problems}
+	}
+	return nil
+}
+
+func (s *Schema) validate() []string {
+	problems := []string{}
+	addf := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	tables := map[string]map[string]bool{}
+	for i, table := range s.Tables {
+		if len(table.Name) == 0 {
+			addf("tables[%d]: missing name", i)
+			continue
+		}
+		if _, ok := tables[table.Name]; ok {
+			addf("%s: duplicate table", table.Name)
+			continue
+		}
+		cols := map[string]bool{}
+		tables[table.Name] = cols
+		for j, col := range table.Columns {
+			if len(col.Name) == 0 {
+				addf("%s.columns[%d]: missing name", table.Name, j)
+				continue
+			}
+			if cols[col.Name] {
+				addf("%s.%s: duplicate column", table.Name, col.Name)
+			}
+			cols[col.Name] = true
+			if _, ok := datatypeNames[col.Datatype]; !ok {
+				addf("%s.%s: unknown datatype %d", table.Name, col.Name, col.Datatype)
+			}
+			if col.IsUserDefined && col.UserDefinedType == nil {
+				addf("%s.%s: user defined column without user_defined_type", table.Name, col.Name)
+			}
+		}
+		if table.ColumnsByName != nil {
+			for name := range table.ColumnsByName {
+				if !cols[name] {
+					addf("%s: columns_by_name holds %s which is not in columns", table.Name, name)
+				}
+			}
+		}
+	}
+	for _, table := range s.Tables {
+		for _, col := range table.Columns {
+			if !col.IsReference {
+				continue
+			}
+			if cols, ok := tables[col.ForeignTablename]; !ok || !cols[col.ForeignColumnname] {
+				addf("%s.%s: references unknown column %s.%s", table.Name, col.Name, col.ForeignTablename, col.ForeignColumnname)
+			}
+		}
+		if _, ok := tables[table.HistoryOf]; len(table.HistoryOf) > 0 && !ok {
+			addf("%s: history of unknown table %s", table.Name, table.HistoryOf)
+		}
+		if _, ok := tables[table.HistoryTable]; len(table.HistoryTable) > 0 && !ok {
+			addf("%s: unknown history table %s", table.Name, table.HistoryTable)
+		}
+	}
+
+	enums := map[string]bool{}
+	for i, enum := range s.Enums {
+		if len(enum.Name) == 0 {
+			addf("enums[%d]: missing name", i)
+			continue
+		}
+		if enums[enum.Name] {
+			addf("%s: duplicate enum", enum.Name)
+		}
+		enums[enum.Name] = true
+		labels := map[string]bool{}
+		for _, value := range enum.Values {
+			if labels[value.Label] {
+				addf("%s: duplicate enum value %q", enum.Name, value.Label)
+			}
+			labels[value.Label] = true
+		}
+	}
+	return problems
+}
@@ -0,0 +1,442 @@
+package inverseschema
+
+import (
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+var javaDatatypes = map[Datatype]string{
+	DatatypeBigint:          "Long",
+	DatatypeInt:             "Integer",
+	DatatypeSmallint:        "Short",
+	DatatypeDecimal:         "BigDecimal",
+	DatatypeNumeric:         "BigDecimal",
+	DatatypeVariableNumeric: "BigDecimal",
+	DatatypeJsonb:           "String",
+	DatatypeJson:            "String",
+	DatatypeText:            "String",
+	DatatypeVarchar:         "String",
+	DatatypeBoolean:         "Boolean",
+	DatatypeDate:            "LocalDate",
+	DatatypeTimestamp:       "LocalDateTime",
+	DatatypeTimestampz:      "OffsetDateTime",
+	DatatypeUuid:            "UUID",
+	DatatypeBytea:           "byte[]",
+}
+
+var javaImports = map[string]string{
+	"BigDecimal":     "java.math.BigDecimal",
+	"LocalDate":      "java.time.LocalDate",
+	"LocalDateTime":  "java.time.LocalDateTime",
+	"OffsetDateTime": "java.time.OffsetDateTime",
+	"UUID":           "java.util.UUID",
+}
+
+var javaKeywords = map[string]bool{
+	"abstract": true, "assert": true, "boolean": true, "break": true, "byte": true, "case": true, "catch": true,
+	"char": true, "class": true, "const": true, "continue": true, "default": true, "do": true, "double": true,
+	"else": true, "enum": true, "extends": true, "final": true, "finally": true, "float": true, "for": true,
+	"goto": true, "if": true, "implements": true, "import": true, "instanceof": true, "int": true, "interface": true,
+	"long": true, "native": true, "new": true, "package": true, "private": true, "protected": true, "public": true,
+	"return": true, "short": true, "static": true, "strictfp": true, "super": true, "switch": true, "synchronized": true,
+	"this": true, "throw": true, "throws": true, "transient": true, "try": true, "void": true, "volatile": true,
+	"while": true, "true": true, "false": true, "null": true, "record": true, "var": true, "yield": true,
+}
+
+var javaInvalidIdentRe = regexp.MustCompile(`[^A-Za-z0-9_$]`)
+
+func javaComment(comment string) string {
+	return strings.ReplaceAll(singleLine(comment), "*/", "* /")
+}
+
+func javaIdent(name string) string {
+	name = javaInvalidIdentRe.ReplaceAllString(name, "_")
+	if len(name) == 0 || (name[0] >= '0' && name[0] <= '9') || javaKeywords[name] {
+		name = "_" + name
+	}
+	return name
+}
+
+var jpaTemplate = template.Must(template.New("jpa").Parse(`{{define "entity"}}package {{.Package}};
+
+import jakarta.persistence.*;
+{{range .Imports}}import {{.}};
+{{end}}
+{{if .Comment}}/** {{.Comment}} */
+{{end}}@Entity
+@Table(name = "{{.Tablename}}"){{if .IdClass}}
+@IdClass({{.IdClass}}.class){{end}}
+public class {{.Name}} {
+{{range $i, $f := .Fields}}{{if $i}}
+{{end}}{{template "field" $f}}{{end}}{{range .Fields}}
+{{template "accessors" .}}{{end}}}
+{{end}}
+
+{{define "field"}}{{if .Comment}}    /** {{.Comment}} */
+{{end}}{{range .Annotations}}    {{.}}
+{{end}}    private {{.Type}} {{.Name}};
+{{end}}
+
+{{define "accessors"}}    public {{.Type}} get{{.Accessor}}() {
+        return {{.Name}};
+    }
+{{if not .ReadOnly}}
+    public void set{{.Accessor}}({{.Type}} {{.Name}}) {
+        this.{{.Name}} = {{.Name}};
+    }
+{{end}}{{end}}
+
+{{define "row"}}package {{.Package}};
+
+{{if .Imports}}{{range .Imports}}import {{.}};
+{{end}}
+{{end}}/** {{if .Comment}}{{.Comment}}, {{end}}row of view {{.Tablename}} which has no key to map an entity on */
+public final class {{.Name}} {
+{{range .Fields}}    private final {{.Type}} {{.Name}};
+{{end}}
+    public {{.Name}}({{.Parameters}}) {
+{{range .Fields}}        this.{{.Name}} = {{.Name}};
+{{end}}    }
+{{range .Fields}}
+{{template "accessors" .}}{{end}}}
+{{end}}
+
+{{define "enum"}}package {{.Package}};
+
+public enum {{.Name}} {
+{{range $i, $v := .Values}}{{if $i}},
+{{end}}    {{$v.Name}}({{$v.Label}}){{end}};
+
+    private final String label;
+
+    {{.Name}}(String label) {
+        this.label = label;
+    }
+
+    /** the label of the value in the database */
+    public String getLabel() {
+        return label;
+    }
+
+    public static {{.Name}} fromLabel(String label) {
+        for ({{.Name}} value : values()) {
+            if (value.label.equals(label)) {
+                return value;
+            }
+        }
+        throw new IllegalArgumentException("unknown {{.Enumname}} label: " + label);
+    }
+}
+{{end}}
+
+{{define "converter"}}package {{.Package}};
+
+import jakarta.persistence.AttributeConverter;
+import jakarta.persistence.Converter;
+
+/** Maps {{.Name}} to its database labels, which need not be valid java identifiers */
+@Converter
+public class {{.Name}}Converter implements AttributeConverter<{{.Name}}, String> {
+    @Override
+    public String convertToDatabaseColumn({{.Name}} value) {
+        return value == null ? null : value.getLabel();
+    }
+
+    @Override
+    public {{.Name}} convertToEntityAttribute(String label) {
+        return label == null ? null : {{.Name}}.fromLabel(label);
+    }
+}
+{{end}}
+
+{{define "id"}}package {{.Package}};
+
+import java.io.Serializable;
+import java.util.Objects;
+{{range .Imports}}import {{.}};
+{{end}}
+/** Composite key of {{.Entity}} */
+public class {{.Name}} implements Serializable {
+{{range .Fields}}    private {{.Type}} {{.Name}};
+{{end}}
+    public {{.Name}}() {
+    }
+
+    public {{.Name}}({{.Parameters}}) {
+{{range .Fields}}        this.{{.Name}} = {{.Name}};
+{{end}}    }
+{{range .Fields}}
+{{template "accessors" .}}{{end}}
+    @Override
+    public boolean equals(Object o) {
+        if (this == o) {
+            return true;
+        }
+        if (!(o instanceof {{.Name}})) {
+            return false;
+        }
+        {{.Name}} other = ({{.Name}}) o;
+        return {{.Equals}};
+    }
+
+    @Override
+    public int hashCode() {
+        return Objects.hash({{.Hash}});
+    }
+}
+{{end}}`))
+
+type jpaEntity struct {
+	Package   string
+	Imports   []string
+	Name      string
+	Tablename string
+	Comment   string
+	Fields    []jpaField
+	// Keyless views are rendered as immutable row classes, Parameters lists the arguments of their constructor
+	Keyless    bool
+	Parameters string
+	// IdClass names the class holding a composite primary key
+	IdClass string
+	// tables and enums rendered by the entity, recorded in the manifest
+	tables []string
+	enums  []string
+}
+
+type jpaField struct {
+	Name        string
+	Accessor    string
+	Type        string
+	Comment     string
+	Annotations []string
+	// ReadOnly fields belong to views, they get no setter
+	ReadOnly bool
+}
+
+// jpaEnum is rendered with a converter persisting its labels, postgres labels are rarely valid java identifiers
+type jpaEnum struct {
+	Package  string
+	Name     string
+	Enumname string
+	Values   []jpaEnumValue
+}
+
+type jpaEnumValue struct {
+	Name  string
+	Label string
+}
+
+// jpaIdClass is the @IdClass of an entity keyed on several columns
+type jpaIdClass struct {
+	Package    string
+	Imports    []string
+	Name       string
+	Entity     string
+	Fields     []jpaField
+	Parameters string
+	Equals     string
+	Hash       string
+	table      string
+}
+
+// jpaParameters lists the fields as constructor parameters
+func jpaParameters(fields []jpaField) string {
+	parameters := make([]string, len(fields))
+	for i, field := range fields {
+		parameters[i] = field.Type + " " + field.Name
+	}
+	return strings.Join(parameters, ", ")
+}
+
+func writeTemplateFile(path string, tmpl *template.Template, name string, data interface{}) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := tmpl.ExecuteTemplate(f, name, data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// WriteJPA writes a JPA annotated entity class per table and a java enum per enum into dir, along with a manifest,
+// foreign keys to tables within the schema are mapped as @ManyToOne associations, or @OneToOne when the column is unique.
+// Views rendered with WithViews get neither setters nor insertable or updatable columns, views without a key become
+// immutable classes outside of the persistence context
+func (s *Schema) WriteJPA(dir string, opts ...GeneratorOption) error {
+	o := newGeneratorOptions("entities", opts)
+	collisions := newNameCollisions("jpa")
+
+	enums := []jpaEnum{}
+	enumTypes := map[string]string{}
+	for _, enum := range s.Enums {
+		e := jpaEnum{Package: o.packageName, Name: pascalCase(enum.Name), Enumname: enum.Name}
+		collisions.addEnum(e.Name, enum)
+		for _, value := range enum.Values {
+			name := javaIdent(value.Label)
+			collisions.addEnumValue(name, enum, value)
+			e.Values = append(e.Values, jpaEnumValue{Name: name, Label: strconv.Quote(value.Label)})
+		}
+		enumTypes[enum.Name] = e.Name
+		enums = append(enums, e)
+	}
+
+	tables := o.tables(s)
+	entityTypes := map[string]string{}
+	for _, table := range tables {
+		entityTypes[table.Name] = tableTypeName(table)
+		collisions.addTable(entityTypes[table.Name], table)
+	}
+
+	entities := []jpaEntity{}
+	idClasses := []jpaIdClass{}
+	for _, table := range tables {
+		entity := jpaEntity{
+			Package:   o.packageName,
+			Name:      entityTypes[table.Name],
+			Tablename: table.Name,
+			Comment:   javaComment(table.Comments),
+			Keyless:   keylessView(table),
+			tables:    append([]string{table.Name}, referencedTables(table)...),
+		}
+		imports := map[string]bool{}
+		for _, col := range table.Columns {
+			col = opaqueColumn(col)
+			field := jpaField{Name: javaIdent(columnFieldName(col)), Comment: javaComment(col.Comments), ReadOnly: table.IsView}
+			if col.IsPrimary {
+				field.Annotations = append(field.Annotations, "@Id")
+			}
+			if col.IsIdentity {
+				field.Annotations = append(field.Annotations, "@GeneratedValue(strategy = GenerationType.IDENTITY)")
+			}
+			nullable := "false"
+			if col.IsNullable {
+				nullable = "true"
+			}
+
+			if entityType, ok := entityTypes[col.ForeignTablename]; ok && col.IsReference && !col.IsPrimary && !col.IsArray && !entity.Keyless {
+				name := strings.TrimSuffix(col.Name, "_id")
+				if len(name) == 0 || name == col.Name {
+					name = col.Name + "_ref"
+				}
+				field.Name = javaIdent(camelCase(name))
+				if len(col.AppName) > 0 {
+					field.Name = javaIdent(col.AppName)
+				}
+				field.Type = entityType
+				association := "@ManyToOne"
+				if cardinality, _ := referenceCardinality(table, col); cardinality == CardinalityOneToOne {
+					association = "@OneToOne"
+				}
+				field.Annotations = append(field.Annotations,
+					association+"(fetch = FetchType.LAZY, optional = "+nullable+")",
+					`@JoinColumn(name = "`+col.Name+`", referencedColumnName = "`+col.ForeignColumnname+`", nullable = `+nullable+`)`,
+				)
+			} else {
+				field.Type = "String"
+				columnAnnotation := `@Column(name = "` + col.Name + `", nullable = ` + nullable
+				if col.IsUserDefined && col.UserDefinedType != nil {
+					if enumType, ok := enumTypes[col.UserDefinedType.Name]; ok {
+						field.Type = enumType
+						entity.enums = append(entity.enums, col.UserDefinedType.Name)
+						field.Annotations = append(field.Annotations, "@Convert(converter = "+enumType+"Converter.class)")
+						columnAnnotation += `, columnDefinition = "` + col.UserDefinedType.Name + `"`
+					}
+				} else if t, ok := javaDatatypes[col.Datatype]; ok {
+					field.Type = t
+				}
+				if col.Datatype == DatatypeVarchar && col.CharacterMaxLength > 0 {
+					columnAnnotation += ", length = " + strconv.Itoa(col.CharacterMaxLength)
+				}
+				if col.IsUnique && !col.IsPrimary {
+					columnAnnotation += ", unique = true"
+				}
+				if col.IsGenerated || table.IsView {
+					columnAnnotation += ", insertable = false, updatable = false"
+				}
+				field.Annotations = append(field.Annotations, columnAnnotation+")")
+				if imp, ok := javaImports[field.Type]; ok {
+					imports[imp] = true
+				}
+				if col.IsArray {
+					field.Type += "[]"
+				}
+			}
+			field.Accessor = strings.TrimPrefix(pascalCase(field.Name), "_")
+			if entity.Keyless {
+				field.Annotations = nil
+			}
+			collisions.addColumn(field.Name, table, col)
+			entity.Fields = append(entity.Fields, field)
+		}
+		if entity.Keyless {
+			entity.Parameters = jpaParameters(entity.Fields)
+		} else if keys := primaryKeyColumns(table); len(keys) > 1 {
+			idClass := jpaIdClass{Package: o.packageName, Name: entity.Name + "Id", Entity: entity.Name, table: table.Name}
+			equals, hash := []string{}, []string{}
+			for i, col := range table.Columns {
+				if !col.IsPrimary {
+					continue
+				}
+				field := entity.Fields[i]
+				idClass.Fields = append(idClass.Fields, jpaField{Name: field.Name, Accessor: field.Accessor, Type: field.Type})
+				equals = append(equals, "Objects.equals("+field.Name+", other."+field.Name+")")
+				hash = append(hash, field.Name)
+				if imp, ok := javaImports[strings.TrimSuffix(field.Type, "[]")]; ok {
+					idClass.Imports = append(idClass.Imports, imp)
+				}
+			}
+			idClass.Imports = uniqueNames(idClass.Imports)
+			idClass.Parameters = jpaParameters(idClass.Fields)
+			idClass.Equals = strings.Join(equals, " && ")
+			idClass.Hash = strings.Join(hash, ", ")
+			entity.IdClass = idClass.Name
+			idClasses = append(idClasses, idClass)
+		}
+		for imp := range imports {
+			entity.Imports = append(entity.Imports, imp)
+		}
+		sort.Strings(entity.Imports)
+		entities = append(entities, entity)
+	}
+	if err := collisions.err(); err != nil {
+		return err
+	}
+
+	tmpl, err := o.template("jpa", jpaTemplate)
+	if err != nil {
+		return err
+	}
+	out, err := s.newGeneratorOutput(dir, "jpa", o)
+	if err != nil {
+		return err
+	}
+	for i, e := range enums {
+		if err := out.write(e.Name+".java", tmpl, "enum", e, nil, []string{s.Enums[i].Name}); err != nil {
+			return err
+		}
+		if err := out.write(e.Name+"Converter.java", tmpl, "converter", e, nil, []string{s.Enums[i].Name}); err != nil {
+			return err
+		}
+	}
+	for _, idClass := range idClasses {
+		if err := out.write(idClass.Name+".java", tmpl, "id", idClass, []string{idClass.table}, nil); err != nil {
+			return err
+		}
+	}
+	for _, entity := range entities {
+		name := "entity"
+		if entity.Keyless {
+			name = "row"
+		}
+		if err := out.write(entity.Name+".java", tmpl, name, entity, entity.tables, entity.enums); err != nil {
+			return err
+		}
+	}
+	return out.close()
+}
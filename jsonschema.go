@@ -0,0 +1,225 @@
+package inverseschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+var jsonSchemaFormats = map[string]Datatype{
+	"date-time": DatatypeTimestampz,
+	"date":      DatatypeDate,
+	"uuid":      DatatypeUuid,
+}
+
+var jsonSchemaDatatypes = map[string]Datatype{
+	"string":  DatatypeText,
+	"integer": DatatypeBigint,
+	"number":  DatatypeNumeric,
+	"boolean": DatatypeBoolean,
+	"object":  DatatypeJsonb,
+}
+
+type jsonSchemaNode struct {
+	Type        json.RawMessage            `json:"type"`
+	Title       string                     `json:"title"`
+	Description string                     `json:"description"`
+	Format      string                     `json:"format"`
+	Properties  map[string]*jsonSchemaNode `json:"properties"`
+	Required    []string                   `json:"required"`
+	Items       *jsonSchemaNode            `json:"items"`
+	Enum        []interface{}              `json:"enum"`
+	Default     json.RawMessage            `json:"default"`
+	MinLength   *int                       `json:"minLength"`
+	MaxLength   *int                       `json:"maxLength"`
+	Minimum     *float64                   `json:"minimum"`
+	Maximum     *float64                   `json:"maximum"`
+	Pattern     string                     `json:"pattern"`
+	// property order is lost when decoding into a map, it is recovered from the raw document
+	order []string
+}
+
+func (n *jsonSchemaNode) UnmarshalJSON(data []byte) error {
+	type plain jsonSchemaNode
+	// numbers are kept as written so that enum values such as 1000000 do not render as 1e+06
+	number := json.NewDecoder(bytes.NewReader(data))
+	number.UseNumber()
+	if err := number.Decode((*plain)(n)); err != nil {
+		return err
+	}
+	raw := struct {
+		Properties json.RawMessage `json:"properties"`
+	}{}
+	if err := json.Unmarshal(data, &raw); err != nil || len(raw.Properties) == 0 {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw.Properties))
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		n.order = append(n.order, fmt.Sprint(key))
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// types lists the JSON types of a node, "null" excluded, and whether null is allowed
+func (n *jsonSchemaNode) types() ([]string, bool) {
+	if len(n.Type) == 0 && n.Properties != nil {
+		return []string{"object"}, false
+	}
+	var single string
+	if err := json.Unmarshal(n.Type, &single); err == nil {
+		return []string{single}, single == "null"
+	}
+	var many []string
+	json.Unmarshal(n.Type, &many)
+	types, nullable := []string{}, false
+	for _, t := range many {
+		if t == "null" {
+			nullable = true
+			continue
+		}
+		types = append(types, t)
+	}
+	return types, nullable
+}
+
+// jsonSchemaColumn maps a JSON Schema property to a column, objects keep their properties in Column.Fields and
+// enum, length, range and pattern keywords become the column Restriction
+func jsonSchemaColumn(name string, node *jsonSchemaNode, position int, required bool) Column {
+	col := Column{OrdinalPosition: position, Name: name, Comments: node.Description, IsNullable: !required}
+	types, nullable := node.types()
+	if nullable {
+		col.IsNullable = true
+	}
+	if len(node.Default) > 0 {
+		col.HasDefault = true
+		col.Default = string(node.Default)
+	}
+	if len(types) == 1 && types[0] == "array" && node.Items != nil {
+		col.IsArray = true
+		node = node.Items
+		types, _ = node.types()
+	}
+	if len(types) != 1 {
+		col.DatatypeRaw = "json"
+		col.Datatype = DatatypeJsonb
+		return col
+	}
+	col.DatatypeRaw = types[0]
+	col.Datatype = jsonSchemaDatatypes[types[0]]
+	if datatype, ok := jsonSchemaFormats[node.Format]; ok && types[0] == "string" {
+		col.DatatypeRaw = node.Format
+		col.Datatype = datatype
+	}
+	if types[0] == "object" {
+		col.Fields = jsonSchemaColumns(node)
+	}
+
+	r := &Restriction{MinLength: node.MinLength, MaxLength: node.MaxLength, Minimum: node.Minimum, Maximum: node.Maximum, Pattern: node.Pattern}
+	for _, v := range node.Enum {
+		if v != nil {
+			r.Values = append(r.Values, fmt.Sprint(v))
+		}
+	}
+	if r.Values != nil || r.Minimum != nil || r.Maximum != nil || r.MinLength != nil || r.MaxLength != nil || len(r.Pattern) > 0 {
+		col.Restriction = r
+	}
+	return col
+}
+
+// jsonSchemaColumns maps the properties of an object schema to columns, in document order
+func jsonSchemaColumns(node *jsonSchemaNode) []Column {
+	required := map[string]bool{}
+	for _, name := range node.Required {
+		required[name] = true
+	}
+	names := node.order
+	if len(names) != len(node.Properties) {
+		names = make([]string, 0, len(node.Properties))
+		for name := range node.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	cols := []Column{}
+	for i, name := range names {
+		if property, ok := node.Properties[name]; ok && property != nil {
+			cols = append(cols, jsonSchemaColumn(name, property, i+1, required[name]))
+		}
+	}
+	return cols
+}
+
+// jsonSchemaTable maps an object JSON Schema to a table
+func jsonSchemaTable(name string, definition string) (Table, error) {
+	table := Table{Name: name, Columns: []Column{}}
+	node := &jsonSchemaNode{}
+	if err := json.Unmarshal([]byte(definition), node); err != nil {
+		return table, fmt.Errorf("json schema %s: %w", name, err)
+	}
+	if types, _ := node.types(); len(node.Properties) == 0 && (len(types) != 1 || types[0] != "object") {
+		return table, fmt.Errorf("json schema %s: top level type is not an object", name)
+	}
+	table.Comments = node.Description
+	table.Columns = jsonSchemaColumns(node)
+	table.ColumnsByName = make(map[string]Column, len(table.Columns))
+	for _, col := range table.Columns {
+		table.ColumnsByName[col.Name] = col
+	}
+	return table, nil
+}
+
+// JSONSchemaHook returns a table hook describing the payload of json and jsonb columns with a JSON Schema, taken from
+// schemas keyed by "table.column" or from the file named by an @jsonschema:<file> annotation in the column comment,
+// resolved against dir. The properties become nested virtual columns in Column.Fields
+func JSONSchemaHook(dir string, schemas map[string][]byte) TableHook {
+	return func(table *Table) error {
+		for i := range table.Columns {
+			col := &table.Columns[i]
+			if col.Datatype != DatatypeJsonb && col.Datatype != DatatypeJson {
+				continue
+			}
+			data, ok := schemas[table.Name+"."+col.Name]
+			if !ok {
+				file, tagged := commentTag(col.Comments, "jsonschema")
+				if !tagged || len(file) == 0 {
+					continue
+				}
+				var err error
+				if data, err = os.ReadFile(filepath.Join(dir, file)); err != nil {
+					return fmt.Errorf("json schema of %s.%s: %w", table.Name, col.Name, err)
+				}
+			}
+			node := &jsonSchemaNode{}
+			if err := json.Unmarshal(data, node); err != nil {
+				return fmt.Errorf("json schema of %s.%s: %w", table.Name, col.Name, err)
+			}
+			col.Fields = jsonSchemaColumns(node)
+			markVirtual(col.Fields)
+			if table.ColumnsByName != nil {
+				table.ColumnsByName[col.Name] = *col
+			}
+		}
+		return nil
+	}
+}
+
+func markVirtual(cols []Column) {
+	for i := range cols {
+		cols[i].IsVirtual = true
+		markVirtual(cols[i].Fields)
+	}
+}
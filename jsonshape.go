@@ -0,0 +1,120 @@
+package inverseschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// JSONShape is the structure inferred from sampled json values. Samples counts the values merged into the shape and
+// Types the JSON types seen among them (object, array, string, integer, number, boolean, null), objects list their
+// Keys and arrays the shape of their Items. Truncated is set when nesting went deeper than the profiling depth
+type JSONShape struct {
+	Samples   int        `json:"samples,omitempty"`
+	Types     []string   `json:"types,omitempty"`
+	Keys      []JSONKey  `json:"keys,omitempty"`
+	Items     *JSONShape `json:"items,omitempty"`
+	Truncated bool       `json:"truncated,omitempty"`
+}
+
+// JSONKey is a key seen in sampled objects, a key whose Shape.Samples is lower than the number of sampled objects
+// was missing from some of them
+type JSONKey struct {
+	Name  string     `json:"name,omitempty"`
+	Shape *JSONShape `json:"shape,omitempty"`
+}
+
+type jsonShapeBuilder struct {
+	samples   int
+	types     map[string]bool
+	keys      map[string]*jsonShapeBuilder
+	items     *jsonShapeBuilder
+	truncated bool
+}
+
+func newJSONShapeBuilder() *jsonShapeBuilder {
+	return &jsonShapeBuilder{types: map[string]bool{}, keys: map[string]*jsonShapeBuilder{}}
+}
+
+// add merges a decoded value, objects and arrays nested deeper than maxDepth are only counted
+func (b *jsonShapeBuilder) add(v interface{}, depth int, maxDepth int) {
+	b.samples++
+	switch value := v.(type) {
+	case nil:
+		b.types["null"] = true
+	case bool:
+		b.types["boolean"] = true
+	case string:
+		b.types["string"] = true
+	case json.Number:
+		if strings.ContainsAny(value.String(), ".eE") {
+			b.types["number"] = true
+		} else {
+			b.types["integer"] = true
+		}
+	case map[string]interface{}:
+		b.types["object"] = true
+		if depth >= maxDepth {
+			b.truncated = true
+			return
+		}
+		for name, field := range value {
+			key, ok := b.keys[name]
+			if !ok {
+				key = newJSONShapeBuilder()
+				b.keys[name] = key
+			}
+			key.add(field, depth+1, maxDepth)
+		}
+	case []interface{}:
+		b.types["array"] = true
+		if depth >= maxDepth {
+			b.truncated = true
+			return
+		}
+		for _, item := range value {
+			if b.items == nil {
+				b.items = newJSONShapeBuilder()
+			}
+			b.items.add(item, depth+1, maxDepth)
+		}
+	}
+}
+
+func (b *jsonShapeBuilder) shape() *JSONShape {
+	shape := &JSONShape{Samples: b.samples, Truncated: b.truncated}
+	for typ := range b.types {
+		shape.Types = append(shape.Types, typ)
+	}
+	sort.Strings(shape.Types)
+	for name, key := range b.keys {
+		shape.Keys = append(shape.Keys, JSONKey{Name: name, Shape: key.shape()})
+	}
+	sort.Slice(shape.Keys, func(i, j int) bool {
+		return shape.Keys[i].Name < shape.Keys[j].Name
+	})
+	if b.items != nil {
+		shape.Items = b.items.shape()
+	}
+	return shape
+}
+
+// InferJSONShape infers the structure of json documents up to maxDepth levels of nesting, documents which do not
+// parse are ignored. It returns nil when no document parsed
+func InferJSONShape(documents [][]byte, maxDepth int) *JSONShape {
+	b := newJSONShapeBuilder()
+	for _, document := range documents {
+		dec := json.NewDecoder(bytes.NewReader(document))
+		dec.UseNumber()
+		var v interface{}
+		if err := dec.Decode(&v); err != nil {
+			continue
+		}
+		b.add(v, 0, maxDepth)
+	}
+	if b.samples == 0 {
+		return nil
+	}
+	return b.shape()
+}
@@ -0,0 +1,129 @@
+package inverseschema
+
+import (
+	"sort"
+)
+
+// postgres storage size and alignment of fixed width types, everything else is stored as a varlena
+var postgresFixedWidths = map[Datatype][2]int{
+	DatatypeBigint:     {8, 8},
+	DatatypeInt:        {4, 4},
+	DatatypeSmallint:   {2, 2},
+	DatatypeBoolean:    {1, 1},
+	DatatypeDate:       {4, 4},
+	DatatypeTimestamp:  {8, 8},
+	DatatypeTimestampz: {8, 8},
+	DatatypeUuid:       {16, 1},
+}
+
+// enum values are stored as 4 byte oids
+var postgresEnumWidth = [2]int{4, 4}
+
+// postgresBaseTypes resolves the base type of domains, as rendered by format_type, to a datatype
+var postgresBaseTypes = map[string]Datatype{
+	"bigint":                      DatatypeBigint,
+	"integer":                     DatatypeInt,
+	"smallint":                    DatatypeSmallint,
+	"boolean":                     DatatypeBoolean,
+	"date":                        DatatypeDate,
+	"timestamp without time zone": DatatypeTimestamp,
+	"timestamp with time zone":    DatatypeTimestampz,
+	"uuid":                        DatatypeUuid,
+}
+
+type TableLayout struct {
+	Tablename        string   `json:"tablename,omitempty"`
+	Padding          int      `json:"padding,omitempty"`
+	SuggestedPadding int      `json:"suggested_padding,omitempty"`
+	SuggestedOrder   []string `json:"suggested_order,omitempty"`
+	SuggestedDDL     string   `json:"suggested_ddl,omitempty"`
+	// DroppedColumns counts the dropped columns older rows still hold, only a table rewrite reclaims them
+	DroppedColumns int `json:"dropped_columns,omitempty"`
+}
+
+func columnWidth(col Column) (int, int, bool) {
+	if col.IsArray {
+		return 0, 0, false
+	}
+	datatype := col.Datatype
+	switch {
+	case col.TypeKind == "enum" || (col.IsUserDefined && len(col.TypeKind) == 0):
+		// schemas read before the type kind was recorded only resolve enums as user defined types
+		return postgresEnumWidth[0], postgresEnumWidth[1], true
+	case col.TypeKind == "domain":
+		// a domain is stored as its base type
+		base, ok := postgresBaseTypes[col.BaseType]
+		if !ok {
+			return 0, 0, false
+		}
+		datatype = base
+	case col.IsUserDefined || col.TypeKind == "composite":
+		// composite and other user defined types are stored as varlenas
+		return 0, 0, false
+	}
+	width, ok := postgresFixedWidths[datatype]
+	return width[0], width[1], ok
+}
+
+// columnsPadding estimates the alignment padding of a row holding non null values, varlena values are assumed to
+// use short (unaligned) headers and contribute no padding
+func columnsPadding(cols []Column) int {
+	offset, padding := 0, 0
+	for _, col := range cols {
+		size, align, ok := columnWidth(col)
+		if !ok {
+			continue
+		}
+		if rem := offset % align; rem != 0 {
+			padding += align - rem
+			offset += align - rem
+		}
+		offset += size
+	}
+	return padding
+}
+
+// Layout computes the alignment padding of a table and suggests a column order minimizing it, fixed width columns by
+// descending size followed by variable width columns in their current order
+func (t Table) Layout() TableLayout {
+	cols := []Column{}
+	for _, col := range t.Columns {
+		if !col.IsVirtual {
+			cols = append(cols, col)
+		}
+	}
+	layout := TableLayout{Tablename: t.Name, Padding: columnsPadding(cols), DroppedColumns: len(t.DroppedColumns)}
+
+	suggested := make([]Column, len(cols))
+	copy(suggested, cols)
+	sort.SliceStable(suggested, func(i, j int) bool {
+		si, _, fi := columnWidth(suggested[i])
+		sj, _, fj := columnWidth(suggested[j])
+		if fi != fj {
+			return fi
+		}
+		return si > sj
+	})
+	layout.SuggestedPadding = columnsPadding(suggested)
+	for _, col := range suggested {
+		layout.SuggestedOrder = append(layout.SuggestedOrder, col.Name)
+	}
+	if layout.SuggestedPadding < layout.Padding {
+		table := t
+		table.Columns = suggested
+		layout.SuggestedDDL = CreateTableSQL(table)
+	}
+	return layout
+}
+
+// LayoutAdvice returns the layout of every table whose padding can be reduced by reordering its columns or which holds
+// dropped columns
+func (s *Schema) LayoutAdvice() []TableLayout {
+	advice := []TableLayout{}
+	for _, table := range s.Tables {
+		if layout := table.Layout(); layout.SuggestedPadding < layout.Padding || layout.DroppedColumns > 0 {
+			advice = append(advice, layout)
+		}
+	}
+	return advice
+}
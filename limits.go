@@ -0,0 +1,52 @@
+package inverseschema
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// LimitError is returned when introspection exceeds WithMaxTables or WithMaxDuration, Truncated is set when the adapter
+// was configured with WithTruncate and returned the tables parsed up to the limit alongside the error
+type LimitError struct {
+	MaxTables   int
+	MaxDuration time.Duration
+	Truncated   bool
+}
+
+func (e *LimitError) Error() string {
+	if e.MaxTables > 0 {
+		return fmt.Sprintf("introspection exceeded the limit of %d tables", e.MaxTables)
+	}
+	return fmt.Sprintf("introspection exceeded the limit of %s", e.MaxDuration)
+}
+
+// WithMaxTables aborts Tables with a *LimitError once the schema holds more than n tables
+func WithMaxTables(n int) PostgresOption {
+	return func(a *PostgresAdapter) {
+		a.maxTables = n
+	}
+}
+
+// WithMaxDuration aborts Tables with a *LimitError once introspection took longer than d, checked between tables
+func WithMaxDuration(d time.Duration) PostgresOption {
+	return func(a *PostgresAdapter) {
+		a.maxDuration = d
+	}
+}
+
+// WithTruncate makes the limits truncate instead of aborting, the tables parsed so far are returned with the *LimitError
+func WithTruncate() PostgresOption {
+	return func(a *PostgresAdapter) {
+		a.truncate = true
+	}
+}
+
+func (a *PostgresAdapter) limitExceeded(ctx context.Context, tables []Table, err *LimitError) ([]Table, error) {
+	a.log().WarnContext(ctx, "introspection limit exceeded", "error", err, "tables", len(tables), "truncate", a.truncate)
+	if !a.truncate {
+		return nil, err
+	}
+	err.Truncated = true
+	return tables, err
+}
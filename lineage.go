@@ -0,0 +1,125 @@
+package inverseschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Lineage declares that a column of a derived schema (a reporting schema, a warehouse, ...) is computed from columns of
+// a source schema, Source names the source schema in reports and Transform describes a computation when the value is
+// not copied as is
+type Lineage struct {
+	Source    string      `json:"source,omitempty"`
+	From      []ColumnRef `json:"from,omitempty"`
+	To        ColumnRef   `json:"to,omitempty"`
+	Transform string      `json:"transform,omitempty"`
+}
+
+type LineageIssue struct {
+	Lineage Lineage `json:"lineage,omitempty"`
+	Reason  string  `json:"reason,omitempty"`
+}
+
+// LoadLineage reads a JSON list of lineage mappings
+func LoadLineage(r io.Reader) ([]Lineage, error) {
+	lineage := []Lineage{}
+	if err := json.NewDecoder(r).Decode(&lineage); err != nil {
+		return nil, err
+	}
+	return lineage, nil
+}
+
+// lineageWidening lists the types a value can be copied into without loss
+var lineageWidening = map[Datatype][]Datatype{
+	DatatypeSmallint:  {DatatypeInt, DatatypeBigint, DatatypeNumeric, DatatypeDecimal},
+	DatatypeInt:       {DatatypeBigint, DatatypeNumeric, DatatypeDecimal},
+	DatatypeBigint:    {DatatypeNumeric, DatatypeDecimal},
+	DatatypeVarchar:   {DatatypeText},
+	DatatypeUuid:      {DatatypeText},
+	DatatypeTimestamp: {DatatypeTimestampz},
+	DatatypeJson:      {DatatypeJsonb},
+}
+
+// lineageCompatible tells whether a source column can be copied into a derived column: the same type, or a wider one
+func lineageCompatible(from Column, to Column) (string, bool) {
+	reason, ok := columnsCompatible(from, to)
+	if ok {
+		return "", true
+	}
+	if from.IsArray != to.IsArray {
+		return reason, false
+	}
+	if from.Datatype == to.Datatype && from.Datatype == DatatypeVarchar {
+		if to.CharacterMaxLength == 0 || to.CharacterMaxLength >= from.CharacterMaxLength {
+			return "", true
+		}
+		return fmt.Sprintf("%s is narrower than %s", postgresColumnType(to), postgresColumnType(from)), false
+	}
+	for _, wider := range lineageWidening[from.Datatype] {
+		if wider == to.Datatype {
+			return "", true
+		}
+	}
+	return reason, false
+}
+
+// ValidateLineage checks lineage mappings against the source and derived schemas: every column exists and, for
+// columns copied as is (a single source column and no transform), the derived type can hold the source values
+func ValidateLineage(source *Schema, derived *Schema, lineage []Lineage) []LineageIssue {
+	issues := []LineageIssue{}
+	for _, mapping := range lineage {
+		to, ok := derived.columnByRef(mapping.To)
+		if !ok {
+			issues = append(issues, LineageIssue{Lineage: mapping, Reason: fmt.Sprintf("derived column %s does not exist", mapping.To)})
+			continue
+		}
+		if len(mapping.From) == 0 {
+			issues = append(issues, LineageIssue{Lineage: mapping, Reason: fmt.Sprintf("derived column %s has no source column", mapping.To)})
+			continue
+		}
+		froms := []Column{}
+		for _, ref := range mapping.From {
+			from, ok := source.columnByRef(ref)
+			if !ok {
+				issues = append(issues, LineageIssue{Lineage: mapping, Reason: fmt.Sprintf("source column %s does not exist", ref)})
+				continue
+			}
+			froms = append(froms, from)
+		}
+		if len(froms) != len(mapping.From) || len(froms) != 1 || len(mapping.Transform) > 0 {
+			continue
+		}
+		if reason, ok := lineageCompatible(froms[0], to); !ok {
+			issues = append(issues, LineageIssue{Lineage: mapping, Reason: reason})
+		}
+	}
+	return issues
+}
+
+// ApplyLineage records the source columns of the derived columns of the schema on Column.DerivedFrom, as
+// "source:table.column" (or "table.column" without source), so the dictionary and manifests export them
+func (s *Schema) ApplyLineage(lineage []Lineage) {
+	for _, mapping := range lineage {
+		table, ok := s.TableByName(mapping.To.Tablename)
+		if !ok {
+			continue
+		}
+		for i := range table.Columns {
+			col := &table.Columns[i]
+			if col.Name != mapping.To.Columnname {
+				continue
+			}
+			for _, ref := range mapping.From {
+				name := ref.String()
+				if len(mapping.Source) > 0 {
+					name = mapping.Source + ":" + name
+				}
+				col.DerivedFrom = append(col.DerivedFrom, name)
+			}
+			if table.ColumnsByName != nil {
+				table.ColumnsByName[col.Name] = *col
+			}
+		}
+	}
+}
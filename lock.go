@@ -0,0 +1,343 @@
+package inverseschema
+
+import (
+	"context"
+	"regexp"
+	"strings"
+)
+
+const (
+	LockAccessShare          = "ACCESS SHARE"
+	LockRowExclusive         = "ROW EXCLUSIVE"
+	LockShareUpdateExclusive = "SHARE UPDATE EXCLUSIVE"
+	LockShare                = "SHARE"
+	LockShareRowExclusive    = "SHARE ROW EXCLUSIVE"
+	LockExclusive            = "EXCLUSIVE"
+	LockAccessExclusive      = "ACCESS EXCLUSIVE"
+)
+
+var lockStrength = map[string]int{
+	LockAccessShare:          1,
+	LockRowExclusive:         2,
+	LockShareUpdateExclusive: 3,
+	LockShare:                4,
+	LockShareRowExclusive:    5,
+	LockExclusive:            6,
+	LockAccessExclusive:      7,
+}
+
+// volatileDefaultRe matches the volatile functions commonly used as defaults, adding a column with such a default
+// rewrites the table while stable ones such as now() are evaluated once
+var volatileDefaultRe = regexp.MustCompile(`(?i)\b(nextval|random|clock_timestamp|timeofday|gen_random_uuid|uuid_generate_v[14]\w*)\s*\(`)
+
+// LockImpact is the lock a statement takes on the relation it changes, an empty lock when it only creates new objects.
+// Rewrite and Scan tell whether it rewrites or reads the whole table while holding the lock
+type LockImpact struct {
+	Lock    string `json:"lock,omitempty"`
+	Rewrite bool   `json:"rewrite,omitempty"`
+	Scan    bool   `json:"scan,omitempty"`
+	Note    string `json:"note,omitempty"`
+}
+
+type StatementLock struct {
+	Statement string `json:"statement,omitempty"`
+	LockImpact
+}
+
+// VersionAdapter is implemented by adapters able to tell the version of the server, as server_version_num (e.g.
+// 160002 for 16.2)
+type VersionAdapter interface {
+	ServerVersion(ctx context.Context) (int, error)
+}
+
+func (m middlewareAdapter) ServerVersion(ctx context.Context) (int, error) {
+	adapter, ok := m.next.(VersionAdapter)
+	if !ok {
+		return 0, ErrNotSupported
+	}
+	return adapter.ServerVersion(ctx)
+}
+
+// lockStatement reads the tokens of a single statement
+type lockStatement struct {
+	tokens  []sqlToken
+	version int
+}
+
+func (l lockStatement) word(i int) string {
+	if i < 0 || i >= len(l.tokens) || l.tokens[i].kind != sqlTokenIdent {
+		return ""
+	}
+	return l.tokens[i].text
+}
+
+// has reports whether the words appear in sequence from i on
+func (l lockStatement) has(i int, words ...string) bool {
+	for ; i+len(words) <= len(l.tokens); i++ {
+		matched := true
+		for j, word := range words {
+			if l.word(i+j) != word {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// atLeast reports whether the server is at least the given version, an unknown version is taken as the latest one
+func (l lockStatement) atLeast(version int) bool {
+	return l.version == 0 || l.version >= version
+}
+
+// strongest keeps the stronger lock of two impacts and merges their flags and notes
+func strongest(a LockImpact, b LockImpact) LockImpact {
+	merged := a
+	if lockStrength[b.Lock] > lockStrength[a.Lock] {
+		merged.Lock = b.Lock
+	}
+	merged.Rewrite = a.Rewrite || b.Rewrite
+	merged.Scan = a.Scan || b.Scan
+	if len(a.Note) > 0 && len(b.Note) > 0 {
+		merged.Note = a.Note + ", " + b.Note
+	} else {
+		merged.Note = a.Note + b.Note
+	}
+	return merged
+}
+
+func (l lockStatement) impact() LockImpact {
+	switch l.word(0) {
+	case "create":
+		return l.create()
+	case "drop":
+		switch {
+		case l.word(1) == "index" && l.word(2) == "concurrently":
+			return LockImpact{Lock: LockShareUpdateExclusive, Note: "cannot run in a transaction block"}
+		case l.word(1) == "index":
+			return LockImpact{Lock: LockAccessExclusive, Note: "blocks reads and writes of the table, DROP INDEX CONCURRENTLY only takes SHARE UPDATE EXCLUSIVE"}
+		}
+		return LockImpact{Lock: LockAccessExclusive}
+	case "alter":
+		return l.alter()
+	case "insert", "update", "delete", "merge":
+		return LockImpact{Lock: LockRowExclusive}
+	case "comment":
+		return LockImpact{Lock: LockShareUpdateExclusive}
+	case "refresh":
+		if l.word(3) == "concurrently" {
+			return LockImpact{Lock: LockExclusive, Scan: true, Note: "reads keep working"}
+		}
+		return LockImpact{Lock: LockAccessExclusive, Rewrite: true}
+	case "begin", "commit", "rollback", "start", "set", "select":
+		return LockImpact{}
+	}
+	return LockImpact{Lock: LockAccessExclusive, Note: "unrecognized statement, assuming the strongest lock"}
+}
+
+func (l lockStatement) create() LockImpact {
+	i := 1
+	if l.word(i) == "unique" {
+		i++
+	}
+	switch l.word(i) {
+	case "index":
+		if l.word(i+1) == "concurrently" {
+			return LockImpact{Lock: LockShareUpdateExclusive, Scan: true, Note: "cannot run in a transaction block"}
+		}
+		return LockImpact{Lock: LockShare, Scan: true, Note: "blocks writes while the index builds, CREATE INDEX CONCURRENTLY does not"}
+	case "table":
+		if l.has(i, "partition", "of") {
+			return LockImpact{Lock: LockAccessExclusive, Note: "locks the parent table"}
+		}
+		if l.has(i, "references") {
+			return LockImpact{Lock: LockShareRowExclusive, Note: "locks the referenced tables"}
+		}
+		return LockImpact{}
+	case "view", "materialized", "or":
+		return LockImpact{Lock: LockAccessShare, Note: "reads the tables it selects from"}
+	}
+	return LockImpact{}
+}
+
+func (l lockStatement) alter() LockImpact {
+	switch l.word(1) {
+	case "index":
+		if l.has(2, "rename") && l.atLeast(120000) {
+			return LockImpact{Lock: LockShareUpdateExclusive}
+		}
+		return LockImpact{Lock: LockAccessExclusive}
+	case "type":
+		switch {
+		case l.has(2, "add", "value") && !l.atLeast(120000):
+			return LockImpact{Note: "cannot run in a transaction block before postgres 12"}
+		case l.has(2, "add", "value"):
+			return LockImpact{Note: "the new value cannot be used within the transaction adding it"}
+		case l.has(2, "rename", "value"):
+			return LockImpact{}
+		}
+		return LockImpact{Lock: LockAccessExclusive}
+	case "table":
+	default:
+		return LockImpact{Lock: LockAccessExclusive}
+	}
+	// skip the table name to reach the actions
+	i := 2
+	for l.word(i) == "if" || l.word(i) == "exists" || l.word(i) == "only" {
+		i++
+	}
+	for i++; i < len(l.tokens) && l.tokens[i].text == "."; i += 2 {
+	}
+	impact := LockImpact{}
+	depth, start := 0, i
+	for j := i; j <= len(l.tokens); j++ {
+		if j < len(l.tokens) {
+			switch l.tokens[j].text {
+			case "(":
+				depth++
+			case ")":
+				depth--
+			}
+			if l.tokens[j].text != "," || depth > 0 {
+				continue
+			}
+		}
+		action := lockStatement{tokens: l.tokens[start:j], version: l.version}
+		if start == i {
+			impact = action.alterTableAction()
+		} else {
+			impact = strongest(impact, action.alterTableAction())
+		}
+		start = j + 1
+	}
+	return impact
+}
+
+// alterTableAction classifies a single action of an ALTER TABLE statement
+func (l lockStatement) alterTableAction() LockImpact {
+	switch l.word(0) {
+	case "rename":
+		return LockImpact{Lock: LockAccessExclusive, Note: "catalog only"}
+	case "validate":
+		return LockImpact{Lock: LockShareUpdateExclusive, Scan: true}
+	case "attach":
+		if l.atLeast(120000) {
+			return LockImpact{Lock: LockShareUpdateExclusive, Scan: true, Note: "locks the attached table ACCESS EXCLUSIVE"}
+		}
+		return LockImpact{Lock: LockAccessExclusive, Scan: true}
+	case "detach":
+		if l.has(1, "concurrently") && l.atLeast(140000) {
+			return LockImpact{Lock: LockShareUpdateExclusive, Note: "cannot run in a transaction block"}
+		}
+		return LockImpact{Lock: LockAccessExclusive}
+	case "drop":
+		if l.word(1) == "constraint" {
+			return LockImpact{Lock: LockAccessExclusive, Note: "catalog only"}
+		}
+		return LockImpact{Lock: LockAccessExclusive, Note: "catalog only, the space is reclaimed by the next rewrite"}
+	case "add":
+		return l.add()
+	case "alter":
+		switch {
+		case l.has(1, "type"):
+			return LockImpact{Lock: LockAccessExclusive, Rewrite: true, Note: "no rewrite when the types are binary coercible, e.g. varchar to text or to a longer varchar"}
+		case l.has(1, "set", "not", "null") && l.atLeast(120000):
+			return LockImpact{Lock: LockAccessExclusive, Scan: true, Note: "the scan is skipped when a validated CHECK (column IS NOT NULL) constraint exists"}
+		case l.has(1, "set", "not", "null"):
+			return LockImpact{Lock: LockAccessExclusive, Scan: true}
+		case l.has(1, "add", "generated"):
+			return LockImpact{Lock: LockAccessExclusive}
+		}
+		return LockImpact{Lock: LockAccessExclusive, Note: "catalog only"}
+	case "set":
+		if l.word(1) == "logged" || l.word(1) == "unlogged" || l.word(1) == "tablespace" {
+			return LockImpact{Lock: LockAccessExclusive, Rewrite: true}
+		}
+	}
+	return LockImpact{Lock: LockAccessExclusive}
+}
+
+// add classifies ADD COLUMN and ADD CONSTRAINT actions
+func (l lockStatement) add() LockImpact {
+	i := 1
+	if l.word(i) == "constraint" {
+		i += 2
+	}
+	notValid := l.has(i, "not", "valid")
+	switch l.word(i) {
+	case "foreign":
+		return LockImpact{Lock: LockShareRowExclusive, Scan: !notValid, Note: "locks the referenced table SHARE ROW EXCLUSIVE as well"}
+	case "check":
+		return LockImpact{Lock: LockAccessExclusive, Scan: !notValid}
+	case "primary", "unique", "exclude":
+		if l.has(i, "using", "index") {
+			return LockImpact{Lock: LockAccessExclusive, Note: "catalog only"}
+		}
+		return LockImpact{Lock: LockAccessExclusive, Scan: true, Note: "builds the index while holding the lock, build it CONCURRENTLY first and add the constraint USING INDEX"}
+	}
+	impact := LockImpact{Lock: LockAccessExclusive}
+	switch {
+	case l.has(i, "generated", "always", "as", "("), l.has(i, "generated") && l.has(i, "identity"):
+		impact.Rewrite = true
+	case l.has(i, "default"):
+		statement := []string{}
+		for _, token := range l.tokens {
+			statement = append(statement, token.text)
+		}
+		if volatileDefaultRe.MatchString(strings.Join(statement, " ")) {
+			impact.Rewrite, impact.Note = true, "the default is volatile"
+		} else if !l.atLeast(110000) {
+			impact.Rewrite, impact.Note = true, "a default rewrites the table before postgres 11"
+		}
+	}
+	if l.has(i, "references") {
+		impact = strongest(impact, LockImpact{Note: "locks the referenced table SHARE ROW EXCLUSIVE"})
+	}
+	if l.has(i, "check") {
+		impact.Scan = true
+	}
+	return impact
+}
+
+// AnnotateLocks splits a script into statements and annotates each with the lock it takes on the relation it
+// changes, whether it rewrites or scans the table, on a server of the given version (as server_version_num, 0 when
+// unknown, the latest behavior is then assumed). It covers the statements this package generates, PlanRename,
+// CheckNaming, RedundantIndexes, BaselineSQL, CloneSQL and MigrationSQL, unknown statements are assumed to take
+// ACCESS EXCLUSIVE
+func AnnotateLocks(script string, serverVersion int) []StatementLock {
+	runes := []rune(script)
+	tokens := tokenizeSQL(script, 1)
+	locks := []StatementLock{}
+	start := 0
+	for i := 0; i <= len(tokens); i++ {
+		if i < len(tokens) && tokens[i].text != ";" {
+			continue
+		}
+		if i > start {
+			end := len(runes)
+			if i < len(tokens) {
+				end = tokens[i].pos + 1
+			}
+			statement := lockStatement{tokens: tokens[start:i], version: serverVersion}
+			locks = append(locks, StatementLock{
+				Statement:  strings.TrimSpace(string(runes[tokens[start].pos:end])),
+				LockImpact: statement.impact(),
+			})
+		}
+		start = i + 1
+	}
+	return locks
+}
+
+// statementLock annotates a single generated statement
+func statementLock(statement string, serverVersion int) *LockImpact {
+	locks := AnnotateLocks(statement, serverVersion)
+	if len(locks) == 0 {
+		return nil
+	}
+	impact := locks[0].LockImpact
+	return &impact
+}
@@ -0,0 +1,46 @@
+package inverseschema
+
+import (
+	"context"
+	"log/slog"
+)
+
+// discardHandler drops every record, it backs adapters and schemas created without a logger
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
+
+var discardLogger = slog.New(discardHandler{})
+
+// WithLogger makes the adapter log per table timings and skipped objects at debug level and exceeded limits as warnings
+func WithLogger(logger *slog.Logger) PostgresOption {
+	return func(a *PostgresAdapter) {
+		a.logger = logger
+	}
+}
+
+type SchemaOption func(s *Schema)
+
+// WithSchemaLogger makes the schema log every parse with its counts and duration
+func WithSchemaLogger(logger *slog.Logger) SchemaOption {
+	return func(s *Schema) {
+		s.logger = logger
+	}
+}
+
+func (a *PostgresAdapter) log() *slog.Logger {
+	if a.logger == nil {
+		return discardLogger
+	}
+	return a.logger
+}
+
+func (s *Schema) log() *slog.Logger {
+	if s.logger == nil {
+		return discardLogger
+	}
+	return s.logger
+}
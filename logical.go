@@ -0,0 +1,194 @@
+package inverseschema
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type TableKind string
+
+const (
+	TableKindEntity   TableKind = "entity"
+	TableKindLookup   TableKind = "lookup"
+	TableKindJunction TableKind = "junction"
+	TableKindHistory  TableKind = "history"
+)
+
+// lookupMaxRows is the number of live rows above which a table is no longer considered a lookup table
+const lookupMaxRows = 100
+
+var (
+	historyNameRe     = regexp.MustCompile(`^(?:(?:audit|history|hist)_(.+)|(.+?)_(?:history|hist|audit|audit_log|log|versions|archive))$`)
+	auditColumnRe     = regexp.MustCompile(`^(created|updated|modified|deleted|inserted)_(at|on|by|time|date)$|^(created|updated|modified)$|^version$`)
+	lookupLabelRe     = regexp.MustCompile(`^(name|label|code|title|value|key|slug|display_name)$`)
+	lookupExtraRe     = regexp.MustCompile(`^(description|sort_order|position|ordinal|rank|is_active|active|enabled)$`)
+	lookupLabelTypes  = map[Datatype]bool{DatatypeText: true, DatatypeVarchar: true}
+	lookupKeyDatatype = map[Datatype]bool{DatatypeBigint: true, DatatypeInt: true, DatatypeSmallint: true, DatatypeText: true, DatatypeVarchar: true, DatatypeUuid: true}
+)
+
+type LogicalTable struct {
+	Tablename string    `json:"tablename,omitempty"`
+	Kind      TableKind `json:"kind,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
+	// HistoryOf is the table a history table records, Links the tables a junction table relates
+	HistoryOf string   `json:"history_of,omitempty"`
+	Links     []string `json:"links,omitempty"`
+}
+
+// LogicalRelationship relates two tables, directly by the foreign key of Column or through a junction table (many to
+// many). Cardinality reads from From to To and Optional is set when the foreign key column is nullable
+type LogicalRelationship struct {
+	From        string      `json:"from,omitempty"`
+	To          string      `json:"to,omitempty"`
+	Column      string      `json:"column,omitempty"`
+	Through     string      `json:"through,omitempty"`
+	Many        bool        `json:"many,omitempty"`
+	Cardinality Cardinality `json:"cardinality,omitempty"`
+	Optional    bool        `json:"optional,omitempty"`
+}
+
+// LogicalModel is the logical layer over the physical schema, lookup, junction and history tables are told apart from
+// entities and junction tables are folded into many to many relationships
+type LogicalModel struct {
+	Tables        []LogicalTable        `json:"tables,omitempty"`
+	Relationships []LogicalRelationship `json:"relationships,omitempty"`
+}
+
+func (m *LogicalModel) Kind(tablename string) TableKind {
+	for _, table := range m.Tables {
+		if table.Tablename == tablename {
+			return table.Kind
+		}
+	}
+	return ""
+}
+
+// historyParent returns the table a history or audit table records, matched by name
+func (s *Schema) historyParent(table Table) (string, bool) {
+	match := historyNameRe.FindStringSubmatch(table.Name)
+	if match == nil {
+		return "", false
+	}
+	for _, name := range match[1:] {
+		if _, ok := s.TableByName(name); len(name) > 0 && ok {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// junctionLinks returns the tables a junction table relates: at least two foreign keys, every other column being a
+// surrogate key or an audit column, and no table referencing it
+func junctionLinks(table Table, referenced map[string]bool) ([]string, bool) {
+	if referenced[table.Name] {
+		return nil, false
+	}
+	links := []string{}
+	for _, col := range table.Columns {
+		switch {
+		case col.IsReference:
+			links = append(links, col.ForeignTablename)
+		case col.IsPrimary && !col.IsReference, auditColumnRe.MatchString(col.Name):
+		default:
+			return nil, false
+		}
+	}
+	return links, len(links) >= 2
+}
+
+// lookupColumns tells whether a table is a lookup table, returning its key and label columns: referenced by foreign
+// keys, referencing nothing, a key and a text label plus at most descriptive columns, and small when statistics are
+// available
+func lookupColumns(table Table, referenced map[string]bool) (string, string, bool) {
+	if !referenced[table.Name] {
+		return "", "", false
+	}
+	key, label := "", ""
+	for _, col := range table.Columns {
+		switch {
+		case col.IsReference:
+			return "", "", false
+		case col.IsPrimary && len(key) == 0 && lookupKeyDatatype[col.Datatype]:
+			key = col.Name
+		case lookupLabelTypes[col.Datatype] && len(label) == 0 && (lookupLabelRe.MatchString(col.Name) || col.IsUnique):
+			label = col.Name
+		case lookupExtraRe.MatchString(col.Name), auditColumnRe.MatchString(col.Name):
+		default:
+			return "", "", false
+		}
+	}
+	if len(key) == 0 || len(label) == 0 {
+		return "", "", false
+	}
+	if table.Stats != nil && table.Stats.LiveTuples > lookupMaxRows {
+		return "", "", false
+	}
+	return key, label, true
+}
+
+func referencedTableSet(tables []Table) map[string]bool {
+	referenced := map[string]bool{}
+	for _, table := range tables {
+		for _, col := range table.Columns {
+			if col.IsReference && col.ForeignTablename != table.Name {
+				referenced[col.ForeignTablename] = true
+			}
+		}
+	}
+	return referenced
+}
+
+// LogicalModel classifies the tables into entities, lookup tables, junction tables and history tables from their
+// structure, names and, when parsed, statistics
+func (s *Schema) LogicalModel() *LogicalModel {
+	model := &LogicalModel{Tables: []LogicalTable{}, Relationships: []LogicalRelationship{}}
+	referenced := referencedTableSet(s.Tables)
+
+	junctions := map[string]bool{}
+	for _, table := range s.Tables {
+		logical := LogicalTable{Tablename: table.Name, Kind: TableKindEntity}
+		if len(table.HistoryOf) > 0 {
+			logical.Kind = TableKindHistory
+			logical.HistoryOf = table.HistoryOf
+			logical.Reason = "history of " + table.HistoryOf + " with validity period"
+		} else if parent, ok := s.historyParent(table); ok {
+			logical.Kind = TableKindHistory
+			logical.HistoryOf = parent
+			logical.Reason = "records the rows of " + parent
+		} else if links, ok := junctionLinks(table, referenced); ok {
+			logical.Kind = TableKindJunction
+			logical.Links = links
+			logical.Reason = "relates " + strings.Join(links, ", ")
+			junctions[table.Name] = true
+			for i := 0; i < len(links); i++ {
+				for j := i + 1; j < len(links); j++ {
+					model.Relationships = append(model.Relationships, LogicalRelationship{From: links[i], To: links[j], Through: table.Name, Many: true, Cardinality: CardinalityManyToMany})
+				}
+			}
+		} else if key, label, ok := lookupColumns(table, referenced); ok {
+			logical.Kind = TableKindLookup
+			logical.Reason = fmt.Sprintf("key %s labelled by %s", key, label)
+		}
+		model.Tables = append(model.Tables, logical)
+	}
+
+	for _, table := range s.Tables {
+		if junctions[table.Name] {
+			continue
+		}
+		for _, col := range table.Columns {
+			if col.IsReference {
+				cardinality, optional := referenceCardinality(table, col)
+				model.Relationships = append(model.Relationships, LogicalRelationship{
+					From:        table.Name,
+					To:          col.ForeignTablename,
+					Column:      col.Name,
+					Cardinality: cardinality,
+					Optional:    optional,
+				})
+			}
+		}
+	}
+	return model
+}
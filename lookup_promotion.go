@@ -0,0 +1,118 @@
+package inverseschema
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PromotedColumn is a foreign key column to a lookup table replaced by a column of the promoted enum
+type PromotedColumn struct {
+	Tablename  string `json:"tablename,omitempty"`
+	Columnname string `json:"columnname,omitempty"`
+	Promoted   string `json:"promoted,omitempty"`
+}
+
+// LookupPromotion suggests replacing a static lookup table by a native enum, SQL creates the enum from the rows of the
+// table, converts the referencing columns and drops the table
+type LookupPromotion struct {
+	Tablename   string           `json:"tablename,omitempty"`
+	KeyColumn   string           `json:"key_column,omitempty"`
+	LabelColumn string           `json:"label_column,omitempty"`
+	Enumname    string           `json:"enumname,omitempty"`
+	Columns     []PromotedColumn `json:"columns,omitempty"`
+	SQL         string           `json:"sql,omitempty"`
+}
+
+// lookupChurned tells whether the statistics of a lookup table show more writes than its initial load, a table
+// without statistics cannot be shown static and counts as churned
+func lookupChurned(table Table) bool {
+	if table.Stats == nil {
+		return true
+	}
+	return table.Stats.TuplesUpdated+table.Stats.TuplesDeleted > 0 || table.Stats.TuplesInserted > lookupMaxRows
+}
+
+// lookupLabelUnique tells whether a unique constraint or index guarantees the labels become distinct enum values
+func lookupLabelUnique(table Table, label string) bool {
+	if table.ColumnsByName[label].IsUnique {
+		return true
+	}
+	for _, index := range table.Indexes {
+		if index.IsUnique && len(index.Predicate) == 0 && len(index.Columns) == 1 && index.Columns[0] == label {
+			return true
+		}
+	}
+	return false
+}
+
+// LookupPromotions lists the lookup tables (see LogicalModel) which are static according to their statistics, have
+// unique labels and are only referenced through their key, with the DDL promoting each of them to a native enum
+func (s *Schema) LookupPromotions() []LookupPromotion {
+	promotions := []LookupPromotion{}
+	referenced := referencedTableSet(s.Tables)
+	for _, table := range s.Tables {
+		key, label, ok := lookupColumns(table, referenced)
+		if !ok || lookupChurned(table) || !lookupLabelUnique(table, label) {
+			continue
+		}
+		promotion := LookupPromotion{Tablename: table.Name, KeyColumn: key, LabelColumn: label, Enumname: singular(table.Name)}
+		if _, ok := s.EnumByName(promotion.Enumname); ok || promotion.Enumname == table.Name {
+			promotion.Enumname += "_enum"
+		}
+		if _, ok := s.TableByName(promotion.Enumname); ok {
+			continue
+		}
+		convertible := true
+		for _, other := range s.Tables {
+			for _, col := range other.Columns {
+				if !col.IsReference || col.ForeignTablename != table.Name {
+					continue
+				}
+				if col.ForeignColumnname != key || col.IsArray {
+					convertible = false
+					continue
+				}
+				promoted := strings.TrimSuffix(col.Name, "_"+key)
+				if _, exists := other.ColumnsByName[promoted]; exists || promoted == col.Name {
+					promoted = col.Name + "_value"
+				}
+				promotion.Columns = append(promotion.Columns, PromotedColumn{Tablename: other.Name, Columnname: col.Name, Promoted: promoted})
+			}
+		}
+		if !convertible || len(promotion.Columns) == 0 {
+			continue
+		}
+		promotion.SQL = lookupPromotionSQL(s, table, promotion)
+		promotions = append(promotions, promotion)
+	}
+	return promotions
+}
+
+func lookupPromotionSQL(s *Schema, table Table, promotion LookupPromotion) string {
+	order := quoteIdent(promotion.KeyColumn)
+	for _, col := range table.Columns {
+		if col.Name == "sort_order" || col.Name == "position" || col.Name == "ordinal" || col.Name == "rank" {
+			order = quoteIdent(col.Name) + ", " + order
+			break
+		}
+	}
+	enumname := quoteIdent(promotion.Enumname)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "-- promote lookup table %s to enum %s\n", table.Name, promotion.Enumname)
+	fmt.Fprintf(&b, "DO $$\nBEGIN\n\tEXECUTE (SELECT format('CREATE TYPE %%I AS ENUM (%%s)', %s, string_agg(quote_literal(%s), ', ' ORDER BY %s)) FROM %s);\nEND\n$$;\n",
+		quoteLiteral(promotion.Enumname), quoteIdent(promotion.LabelColumn), order, quoteIdent(table.Name))
+	for _, col := range promotion.Columns {
+		tablename := quoteIdent(col.Tablename)
+		promoted := quoteIdent(col.Promoted)
+		fmt.Fprintf(&b, "ALTER TABLE %s ADD COLUMN %s %s;\n", tablename, promoted, enumname)
+		fmt.Fprintf(&b, "UPDATE %s SET %s = l.%s::%s FROM %s l WHERE l.%s = %s.%s;\n",
+			tablename, promoted, quoteIdent(promotion.LabelColumn), enumname, quoteIdent(table.Name), quoteIdent(promotion.KeyColumn), tablename, quoteIdent(col.Columnname))
+		if original, ok := s.columnByRef(ColumnRef{Tablename: col.Tablename, Columnname: col.Columnname}); ok && !original.IsNullable {
+			fmt.Fprintf(&b, "ALTER TABLE %s ALTER COLUMN %s SET NOT NULL;\n", tablename, promoted)
+		}
+		fmt.Fprintf(&b, "ALTER TABLE %s DROP COLUMN %s;\n", tablename, quoteIdent(col.Columnname))
+	}
+	fmt.Fprintf(&b, "DROP TABLE %s;\n", quoteIdent(table.Name))
+	return b.String()
+}
@@ -0,0 +1,115 @@
+package inverseschema
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const ManifestAPIVersion = "inverseschema.oiime.github.com/v1"
+
+var yamlPlainRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_ ./()\-]*$`)
+
+var yamlReserved = map[string]bool{
+	"true": true, "false": true, "yes": true, "no": true, "on": true, "off": true, "null": true, "y": true, "n": true,
+}
+
+func yamlString(s string) string {
+	if yamlPlainRe.MatchString(s) && !yamlReserved[strings.ToLower(s)] && !strings.HasSuffix(s, " ") {
+		return s
+	}
+	return strconv.Quote(s)
+}
+
+type yamlWriter struct {
+	w *bufio.Writer
+}
+
+func (y yamlWriter) line(indent int, s string) {
+	y.w.WriteString(strings.Repeat("  ", indent))
+	y.w.WriteString(s)
+	y.w.WriteString("\n")
+}
+
+func (y yamlWriter) field(indent int, key string, value string) {
+	y.line(indent, key+": "+yamlString(value))
+}
+
+// WriteManifests renders every table and enum as a declarative YAML document (apiVersion/kind/metadata/spec),
+// documents are separated by "---" so the output can be split into one manifest per object
+func (s *Schema) WriteManifests(w io.Writer) error {
+	y := yamlWriter{w: bufio.NewWriter(w)}
+	first := true
+	separate := func() {
+		if !first {
+			y.line(0, "---")
+		}
+		first = false
+	}
+
+	for _, table := range s.Tables {
+		separate()
+		y.field(0, "apiVersion", ManifestAPIVersion)
+		y.field(0, "kind", "Table")
+		y.line(0, "metadata:")
+		y.field(1, "name", table.Name)
+		y.line(0, "spec:")
+		if len(table.Comments) > 0 {
+			y.field(1, "comments", table.Comments)
+		}
+		if len(table.Owner) > 0 {
+			y.field(1, "owner", table.Owner)
+		}
+		y.line(1, "columns:")
+		for _, col := range table.Columns {
+			y.field(2, "- name", col.Name)
+			y.field(3, "type", postgresColumnType(col))
+			y.line(3, "nullable: "+strconv.FormatBool(col.IsNullable))
+			if col.IsPrimary {
+				y.line(3, "primaryKey: true")
+			}
+			if col.IsUnique {
+				y.line(3, "unique: true")
+			}
+			if col.HasDefault {
+				y.field(3, "default", col.Default)
+			}
+			if col.IsIdentity {
+				y.field(3, "identity", col.IdentityGeneration)
+			}
+			if col.IsGenerated {
+				y.field(3, "generated", col.GenerationExpression)
+			}
+			if col.IsReference {
+				y.line(3, "references:")
+				y.field(4, "table", col.ForeignTablename)
+				y.field(4, "column", col.ForeignColumnname)
+			}
+			if len(col.DerivedFrom) > 0 {
+				y.line(3, "derivedFrom:")
+				for _, source := range col.DerivedFrom {
+					y.line(4, "- "+yamlString(source))
+				}
+			}
+			if len(col.Comments) > 0 {
+				y.field(3, "comments", col.Comments)
+			}
+		}
+	}
+
+	for _, enum := range s.Enums {
+		separate()
+		y.field(0, "apiVersion", ManifestAPIVersion)
+		y.field(0, "kind", "Enum")
+		y.line(0, "metadata:")
+		y.field(1, "name", enum.Name)
+		y.line(0, "spec:")
+		y.line(1, "values:")
+		for _, value := range enum.Values {
+			y.line(2, "- "+yamlString(value.Label))
+		}
+	}
+	return y.w.Flush()
+}
@@ -0,0 +1,174 @@
+package inverseschema
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// MaterializedView is a materialized view of the schema, DependsOn lists the tables and materialized views of the
+// schema it reads, through views included. HasUniqueIndex tells whether it can be refreshed concurrently
+type MaterializedView struct {
+	Name           string   `json:"name,omitempty"`
+	Owner          string   `json:"owner,omitempty"`
+	Definition     string   `json:"definition,omitempty"`
+	IsPopulated    bool     `json:"is_populated,omitempty"`
+	HasUniqueIndex bool     `json:"has_unique_index,omitempty"`
+	DependsOn      []string `json:"depends_on,omitempty"`
+	Comments       string   `json:"comments,omitempty"`
+}
+
+type MaterializedViewAdapter interface {
+	MaterializedViews(ctx context.Context) ([]MaterializedView, error)
+}
+
+func (s *Schema) ParseMaterializedViews(ctx context.Context) error {
+	adapter, ok := s.adapter.(MaterializedViewAdapter)
+	if !ok {
+		return ErrNotSupported
+	}
+	views, err := adapter.MaterializedViews(ctx)
+	if err != nil {
+		return err
+	}
+	s.MaterializedViews = views
+	return nil
+}
+
+// MaterializedViewStaleness rates how stale a materialized view is likely to be: Writes sums the tuples inserted,
+// updated and deleted on the tables it depends on since its last refresh and WriteRatio relates them to their live
+// tuples
+type MaterializedViewStaleness struct {
+	Name       string  `json:"name,omitempty"`
+	Stale      bool    `json:"stale,omitempty"`
+	Reason     string  `json:"reason,omitempty"`
+	Writes     int64   `json:"writes,omitempty"`
+	WriteRatio float64 `json:"write_ratio,omitempty"`
+}
+
+// RefreshCheckpoint records, per materialized view, the cumulative write counters of the tables it depends on when it
+// was last refreshed. Postgres keeps no refresh time, the checkpoint is kept by the caller between plans
+type RefreshCheckpoint struct {
+	Writes map[string]map[string]int64 `json:"writes,omitempty"`
+}
+
+// RefreshPlan lists the materialized views with their staleness and the REFRESH statements of the stale ones,
+// ordered so that a materialized view is refreshed after those it reads. Checkpoint is the checkpoint to keep once
+// the statements ran
+type RefreshPlan struct {
+	Views      []MaterializedViewStaleness `json:"views,omitempty"`
+	Order      []string                    `json:"order,omitempty"`
+	SQL        []string                    `json:"sql,omitempty"`
+	Checkpoint *RefreshCheckpoint          `json:"checkpoint,omitempty"`
+}
+
+// tableWrites sums the cumulative write counters of a table
+func tableWrites(stats *TableStats) int64 {
+	return stats.TuplesInserted + stats.TuplesUpdated + stats.TuplesDeleted
+}
+
+// matviewOrder orders materialized views after the materialized views they depend on, those within a cycle (which
+// postgres does not allow) are appended
+func matviewOrder(views []MaterializedView) []MaterializedView {
+	known := map[string]bool{}
+	for _, view := range views {
+		known[view.Name] = true
+	}
+	placed := map[string]bool{}
+	ordered := make([]MaterializedView, 0, len(views))
+	for progress := true; progress; {
+		progress = false
+		for _, view := range views {
+			if placed[view.Name] {
+				continue
+			}
+			ready := true
+			for _, name := range view.DependsOn {
+				if known[name] && !placed[name] && name != view.Name {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				placed[view.Name] = true
+				ordered = append(ordered, view)
+				progress = true
+			}
+		}
+	}
+	for _, view := range views {
+		if !placed[view.Name] {
+			ordered = append(ordered, view)
+		}
+	}
+	return ordered
+}
+
+// MaterializedViewRefreshPlan rates the staleness of the parsed materialized views from the write activity of the
+// tables they depend on (requires WithStats) since the checkpoint of their last refresh, and plans the refresh of
+// those unpopulated, depending on a stale materialized view or whose tables saw writes amounting to at least
+// threshold of their live tuples. A view missing from checkpoint cannot be rated on writes, the returned checkpoint
+// starts tracking it. Counters lower than their checkpoint were reset and count from zero
+func (s *Schema) MaterializedViewRefreshPlan(threshold float64, checkpoint *RefreshCheckpoint) *RefreshPlan {
+	plan := &RefreshPlan{Views: []MaterializedViewStaleness{}, Order: []string{}, SQL: []string{}, Checkpoint: &RefreshCheckpoint{Writes: map[string]map[string]int64{}}}
+	stale := map[string]bool{}
+	for _, view := range matviewOrder(s.MaterializedViews) {
+		rating := MaterializedViewStaleness{Name: view.Name}
+		var previous map[string]int64
+		if checkpoint != nil {
+			previous = checkpoint.Writes[view.Name]
+		}
+		current := map[string]int64{}
+		var live int64
+		staleInputs := []string{}
+		for _, name := range view.DependsOn {
+			if stale[name] {
+				staleInputs = append(staleInputs, name)
+			}
+			if table, ok := s.TableByName(name); ok && table.Stats != nil {
+				writes := tableWrites(table.Stats)
+				current[name] = writes
+				// a table without checkpoint, read by a view missing from it or newly read, is tracked from now on
+				if since, ok := previous[name]; !ok {
+					writes = 0
+				} else if since <= writes {
+					writes -= since
+				}
+				rating.Writes += writes
+				live += table.Stats.LiveTuples
+			}
+		}
+		if live > 0 {
+			rating.WriteRatio = float64(rating.Writes) / float64(live)
+		}
+		switch {
+		case !view.IsPopulated:
+			rating.Stale, rating.Reason = true, "never populated"
+		case len(staleInputs) > 0:
+			sort.Strings(staleInputs)
+			rating.Stale, rating.Reason = true, fmt.Sprintf("depends on stale %v", staleInputs)
+		case previous == nil:
+			rating.Reason = "no refresh checkpoint"
+		case rating.Writes > 0 && (live == 0 || rating.WriteRatio >= threshold):
+			rating.Stale, rating.Reason = true, fmt.Sprintf("%d writes on its tables since its refresh (%.2f of their rows)", rating.Writes, rating.WriteRatio)
+		}
+		plan.Views = append(plan.Views, rating)
+		if !rating.Stale && previous != nil {
+			plan.Checkpoint.Writes[view.Name] = previous
+			continue
+		}
+		plan.Checkpoint.Writes[view.Name] = current
+		if !rating.Stale {
+			continue
+		}
+		stale[view.Name] = true
+		plan.Order = append(plan.Order, view.Name)
+		// a concurrent refresh needs a unique index and a populated view
+		concurrently := ""
+		if view.HasUniqueIndex && view.IsPopulated {
+			concurrently = "CONCURRENTLY "
+		}
+		plan.SQL = append(plan.SQL, "REFRESH MATERIALIZED VIEW "+concurrently+quoteIdent(view.Name)+";")
+	}
+	return plan
+}
@@ -0,0 +1,396 @@
+package inverseschema
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// AdapterMiddleware wraps an adapter with cross cutting behavior, the returned adapter forwards Permissions, Views,
+// MaterializedViews and ColumnDependents to the wrapped one when it supports them, Table falls back to picking the
+// table out of Tables
+type AdapterMiddleware func(next Adapter) Adapter
+
+// ChainAdapter wraps adapter with every middleware, the first middleware is the outermost one
+func ChainAdapter(adapter Adapter, middlewares ...AdapterMiddleware) Adapter {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		adapter = middlewares[i](adapter)
+	}
+	return adapter
+}
+
+type middlewareAdapter struct {
+	next Adapter
+}
+
+func (m middlewareAdapter) nextTable(ctx context.Context, name string) (*Table, error) {
+	return fetchTable(ctx, m.next, name)
+}
+
+func (m middlewareAdapter) nextPermissions(ctx context.Context) (*PermissionGraph, error) {
+	adapter, ok := m.next.(PermissionAdapter)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	return adapter.Permissions(ctx)
+}
+
+func (m middlewareAdapter) nextViews(ctx context.Context) ([]Table, error) {
+	adapter, ok := m.next.(ViewAdapter)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	return adapter.Views(ctx)
+}
+
+func (m middlewareAdapter) nextMaterializedViews(ctx context.Context) ([]MaterializedView, error) {
+	adapter, ok := m.next.(MaterializedViewAdapter)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	return adapter.MaterializedViews(ctx)
+}
+
+// ColumnDependents is forwarded untouched by every middleware, ErrNotSupported is returned when the wrapped adapter
+// does not list dependents
+func (m middlewareAdapter) ColumnDependents(ctx context.Context, tablename string, columnname string) ([]Dependent, error) {
+	adapter, ok := m.next.(DependencyAdapter)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	return adapter.ColumnDependents(ctx, tablename, columnname)
+}
+
+// LoggingMiddleware logs every adapter call with its duration, failures are logged as errors
+func LoggingMiddleware(logger *slog.Logger) AdapterMiddleware {
+	return func(next Adapter) Adapter {
+		return &loggingAdapter{middlewareAdapter: middlewareAdapter{next: next}, logger: logger}
+	}
+}
+
+type loggingAdapter struct {
+	middlewareAdapter
+	logger *slog.Logger
+}
+
+func (a *loggingAdapter) done(ctx context.Context, method string, started time.Time, err error, attrs ...interface{}) {
+	attrs = append([]interface{}{"method", method, "duration", time.Since(started)}, attrs...)
+	if err != nil {
+		a.logger.ErrorContext(ctx, "adapter call failed", append(attrs, "error", err)...)
+		return
+	}
+	a.logger.DebugContext(ctx, "adapter call", attrs...)
+}
+
+func (a *loggingAdapter) Tables(ctx context.Context) ([]Table, error) {
+	started := time.Now()
+	tables, err := a.next.Tables(ctx)
+	a.done(ctx, "tables", started, err, "tables", len(tables))
+	return tables, err
+}
+
+func (a *loggingAdapter) Table(ctx context.Context, name string) (*Table, error) {
+	started := time.Now()
+	table, err := a.nextTable(ctx, name)
+	a.done(ctx, "table", started, err, "table", name)
+	return table, err
+}
+
+func (a *loggingAdapter) Enums(ctx context.Context) ([]Enum, error) {
+	started := time.Now()
+	enums, err := a.next.Enums(ctx)
+	a.done(ctx, "enums", started, err, "enums", len(enums))
+	return enums, err
+}
+
+func (a *loggingAdapter) Permissions(ctx context.Context) (*PermissionGraph, error) {
+	started := time.Now()
+	permissions, err := a.nextPermissions(ctx)
+	a.done(ctx, "permissions", started, err)
+	return permissions, err
+}
+
+func (a *loggingAdapter) Views(ctx context.Context) ([]Table, error) {
+	started := time.Now()
+	views, err := a.nextViews(ctx)
+	a.done(ctx, "views", started, err, "views", len(views))
+	return views, err
+}
+
+func (a *loggingAdapter) MaterializedViews(ctx context.Context) ([]MaterializedView, error) {
+	started := time.Now()
+	views, err := a.nextMaterializedViews(ctx)
+	a.done(ctx, "materialized_views", started, err, "materialized_views", len(views))
+	return views, err
+}
+
+// AdapterObserver receives the outcome of every adapter call, it is meant to feed a metrics system
+type AdapterObserver func(method string, duration time.Duration, err error)
+
+// MetricsMiddleware reports the duration and error of every adapter call to observe
+func MetricsMiddleware(observe AdapterObserver) AdapterMiddleware {
+	return func(next Adapter) Adapter {
+		return &metricsAdapter{middlewareAdapter: middlewareAdapter{next: next}, observe: observe}
+	}
+}
+
+type metricsAdapter struct {
+	middlewareAdapter
+	observe AdapterObserver
+}
+
+func (a *metricsAdapter) Tables(ctx context.Context) ([]Table, error) {
+	started := time.Now()
+	tables, err := a.next.Tables(ctx)
+	a.observe("tables", time.Since(started), err)
+	return tables, err
+}
+
+func (a *metricsAdapter) Table(ctx context.Context, name string) (*Table, error) {
+	started := time.Now()
+	table, err := a.nextTable(ctx, name)
+	a.observe("table", time.Since(started), err)
+	return table, err
+}
+
+func (a *metricsAdapter) Enums(ctx context.Context) ([]Enum, error) {
+	started := time.Now()
+	enums, err := a.next.Enums(ctx)
+	a.observe("enums", time.Since(started), err)
+	return enums, err
+}
+
+func (a *metricsAdapter) Permissions(ctx context.Context) (*PermissionGraph, error) {
+	started := time.Now()
+	permissions, err := a.nextPermissions(ctx)
+	a.observe("permissions", time.Since(started), err)
+	return permissions, err
+}
+
+func (a *metricsAdapter) Views(ctx context.Context) ([]Table, error) {
+	started := time.Now()
+	views, err := a.nextViews(ctx)
+	a.observe("views", time.Since(started), err)
+	return views, err
+}
+
+func (a *metricsAdapter) MaterializedViews(ctx context.Context) ([]MaterializedView, error) {
+	started := time.Now()
+	views, err := a.nextMaterializedViews(ctx)
+	a.observe("materialized_views", time.Since(started), err)
+	return views, err
+}
+
+// CachingMiddleware keeps successful results for ttl, a zero ttl keeps them forever
+func CachingMiddleware(ttl time.Duration) AdapterMiddleware {
+	return func(next Adapter) Adapter {
+		return &cachingAdapter{middlewareAdapter: middlewareAdapter{next: next}, ttl: ttl}
+	}
+}
+
+type cachingAdapter struct {
+	middlewareAdapter
+	ttl      time.Duration
+	mu       sync.Mutex
+	tables   map[ObjectKind]cachedResult
+	enums    cachedResult
+	graph    cachedResult
+	views    cachedResult
+	matviews cachedResult
+}
+
+// cachedResult holds a result encoded so that every caller decodes its own deep copy, err is the *LimitError of a
+// truncated result which is cached along with it
+type cachedResult struct {
+	encoded []byte
+	err     error
+	at      time.Time
+}
+
+func (a *cachingAdapter) fresh(at time.Time) bool {
+	return !at.IsZero() && (a.ttl == 0 || time.Since(at) < a.ttl)
+}
+
+// cache encodes a result, failures are returned unless the result was truncated on a limit
+func cache(result interface{}, err error) (cachedResult, error) {
+	var limitErr *LimitError
+	if err != nil && !(errors.As(err, &limitErr) && limitErr.Truncated) {
+		return cachedResult{}, err
+	}
+	encoded, encodeErr := json.Marshal(result)
+	if encodeErr != nil {
+		return cachedResult{}, encodeErr
+	}
+	return cachedResult{encoded: encoded, err: err, at: time.Now()}, nil
+}
+
+func (c cachedResult) decode(result interface{}) error {
+	if err := json.Unmarshal(c.encoded, result); err != nil {
+		return err
+	}
+	return c.err
+}
+
+// Tables are cached per selection of objects
+func (a *cachingAdapter) Tables(ctx context.Context) ([]Table, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	kinds := ObjectsFromContext(ctx)
+	cached := a.tables[kinds]
+	if !a.fresh(cached.at) {
+		var err error
+		if cached, err = cache(a.next.Tables(ctx)); err != nil {
+			return nil, err
+		}
+		if a.tables == nil {
+			a.tables = map[ObjectKind]cachedResult{}
+		}
+		a.tables[kinds] = cached
+	}
+	var tables []Table
+	err := cached.decode(&tables)
+	return tables, err
+}
+
+// Table is served from the tables cached for the same selection while they are fresh, single tables are not cached on
+// their own and a table missing from truncated tables is asked to the wrapped adapter
+func (a *cachingAdapter) Table(ctx context.Context, name string) (*Table, error) {
+	a.mu.Lock()
+	cached := a.tables[ObjectsFromContext(ctx)]
+	a.mu.Unlock()
+	if !a.fresh(cached.at) {
+		return a.nextTable(ctx, name)
+	}
+	var tables []Table
+	if err := json.Unmarshal(cached.encoded, &tables); err != nil {
+		return nil, err
+	}
+	for _, table := range tables {
+		if table.Name == name {
+			return &table, nil
+		}
+	}
+	if cached.err != nil {
+		return a.nextTable(ctx, name)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrTableNotFound, name)
+}
+
+func (a *cachingAdapter) Enums(ctx context.Context) ([]Enum, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.fresh(a.enums.at) {
+		cached, err := cache(a.next.Enums(ctx))
+		if err != nil {
+			return nil, err
+		}
+		a.enums = cached
+	}
+	var enums []Enum
+	err := a.enums.decode(&enums)
+	return enums, err
+}
+
+func (a *cachingAdapter) Permissions(ctx context.Context) (*PermissionGraph, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.fresh(a.graph.at) {
+		cached, err := cache(a.nextPermissions(ctx))
+		if err != nil {
+			return nil, err
+		}
+		a.graph = cached
+	}
+	graph := &PermissionGraph{}
+	if err := a.graph.decode(graph); err != nil {
+		return nil, err
+	}
+	return graph, nil
+}
+
+func (a *cachingAdapter) Views(ctx context.Context) ([]Table, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.fresh(a.views.at) {
+		cached, err := cache(a.nextViews(ctx))
+		if err != nil {
+			return nil, err
+		}
+		a.views = cached
+	}
+	var views []Table
+	err := a.views.decode(&views)
+	return views, err
+}
+
+func (a *cachingAdapter) MaterializedViews(ctx context.Context) ([]MaterializedView, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.fresh(a.matviews.at) {
+		cached, err := cache(a.nextMaterializedViews(ctx))
+		if err != nil {
+			return nil, err
+		}
+		a.matviews = cached
+	}
+	var views []MaterializedView
+	err := a.matviews.decode(&views)
+	return views, err
+}
+
+// FilterMiddleware drops every table for which keep returns false
+func FilterMiddleware(keep func(table Table) bool) AdapterMiddleware {
+	return func(next Adapter) Adapter {
+		return &filterAdapter{middlewareAdapter: middlewareAdapter{next: next}, keep: keep}
+	}
+}
+
+type filterAdapter struct {
+	middlewareAdapter
+	keep func(table Table) bool
+}
+
+func (a *filterAdapter) Tables(ctx context.Context) ([]Table, error) {
+	tables, err := a.next.Tables(ctx)
+	if err != nil {
+		return nil, err
+	}
+	kept := make([]Table, 0, len(tables))
+	for _, table := range tables {
+		if a.keep(table) {
+			kept = append(kept, table)
+		}
+	}
+	return kept, nil
+}
+
+func (a *filterAdapter) Table(ctx context.Context, name string) (*Table, error) {
+	table, err := a.nextTable(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if !a.keep(*table) {
+		return nil, fmt.Errorf("%w: %s", ErrTableNotFound, name)
+	}
+	return table, nil
+}
+
+func (a *filterAdapter) Enums(ctx context.Context) ([]Enum, error) {
+	return a.next.Enums(ctx)
+}
+
+func (a *filterAdapter) Permissions(ctx context.Context) (*PermissionGraph, error) {
+	return a.nextPermissions(ctx)
+}
+
+func (a *filterAdapter) Views(ctx context.Context) ([]Table, error) {
+	return a.nextViews(ctx)
+}
+
+func (a *filterAdapter) MaterializedViews(ctx context.Context) ([]MaterializedView, error) {
+	return a.nextMaterializedViews(ctx)
+}
@@ -0,0 +1,473 @@
+package inverseschema
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// MigrationStatement applies a change of a change set, Down reverts it and is empty when postgres cannot.
+// NoTransaction marks statements which cannot share a transaction with the statements using their result
+type MigrationStatement struct {
+	Change        Change `json:"change,omitempty"`
+	Up            string `json:"up,omitempty"`
+	Down          string `json:"down,omitempty"`
+	NoTransaction bool   `json:"no_transaction,omitempty"`
+	// Locks annotates the statements of Up with their locks on the server version of the previous schema
+	Locks []StatementLock `json:"locks,omitempty"`
+}
+
+// the phases statements are ordered by: types first, then renames so that the other statements use the new names,
+// tables and columns, and drops last, types once no table uses them
+const (
+	phaseTypes = iota
+	phaseRenames
+	phaseTables
+	phaseColumns
+	phaseConstraints
+	phaseColumnDrops
+	phaseTableDrops
+	phaseTypeDrops
+	phaseCount
+)
+
+var typeDefinitionNameRe = regexp.MustCompile(`^CREATE (DOMAIN|TYPE) ((?:"(?:[^"]|"")*"|[^\s"])+) AS`)
+
+// migrationSchemas resolves the objects a change refers to in the previous and the current schema, following the
+// renames of the change set
+type migrationSchemas struct {
+	previous        *Schema
+	current         *Schema
+	previousTables  map[string]string
+	previousColumns map[string]string
+	// created holds the sequences, types and constraints the previous schema has or a statement created
+	created map[string]bool
+}
+
+func newMigrationSchemas(changes []Change, previous *Schema, current *Schema) *migrationSchemas {
+	m := &migrationSchemas{
+		previous:        previous,
+		current:         current,
+		previousTables:  map[string]string{},
+		previousColumns: map[string]string{},
+		created:         map[string]bool{},
+	}
+	for _, change := range changes {
+		switch change.Kind {
+		case ChangeTableRenamed:
+			m.previousTables[change.Tablename] = change.From
+		case ChangeColumnRenamed:
+			m.previousColumns[change.Tablename+"."+change.Columnname] = change.From
+		}
+	}
+	_, sequences, _ := sequencesSQL(previous.Tables, false)
+	for _, statement := range append(sequences, typeDefinitionsSQL(previous.Tables)...) {
+		m.created[statement] = true
+	}
+	return m
+}
+
+// before returns the previous version of a current table
+func (m *migrationSchemas) before(tablename string) (*Table, bool) {
+	if name, ok := m.previousTables[tablename]; ok {
+		tablename = name
+	}
+	return m.previous.TableByName(tablename)
+}
+
+// columns returns the previous and the current version of the column of a change
+func (m *migrationSchemas) columns(change Change) (Column, Column) {
+	var from, to Column
+	if table, ok := m.before(change.Tablename); ok {
+		name := change.Columnname
+		if previousName, ok := m.previousColumns[change.Tablename+"."+change.Columnname]; ok {
+			name = previousName
+		}
+		from = table.ColumnsByName[name]
+	}
+	if table, ok := m.current.TableByName(change.Tablename); ok {
+		to = table.ColumnsByName[change.Columnname]
+	}
+	return from, to
+}
+
+// prerequisites renders the statements creating the sequences, domains and composite types the tables use which
+// neither the previous schema nor an earlier statement created, and the statements dropping them
+func (m *migrationSchemas) prerequisites(tables []Table) ([]string, []string) {
+	up, down := []string{}, []string{}
+	_, sequences, names := sequencesSQL(tables, false)
+	for i, statement := range sequences {
+		if !m.created[statement] {
+			m.created[statement] = true
+			up = append(up, statement)
+			down = append([]string{"DROP SEQUENCE IF EXISTS " + quoteIdent(names[i]) + ";"}, down...)
+		}
+	}
+	for _, statement := range typeDefinitionsSQL(tables) {
+		if m.created[statement] {
+			continue
+		}
+		m.created[statement] = true
+		up = append(up, statement)
+		if match := typeDefinitionNameRe.FindStringSubmatch(statement); match != nil {
+			down = append([]string{"DROP " + match[1] + " " + match[2] + ";"}, down...)
+		}
+	}
+	return up, down
+}
+
+// foreignKeys lists the foreign keys of a table involving a column
+func foreignKeys(table *Table, columnname string) map[string]tableConstraint {
+	constraints := map[string]tableConstraint{}
+	if table == nil {
+		return constraints
+	}
+	for _, c := range tableConstraints(*table) {
+		if c.typ != ConstraintTypeForeignKey {
+			continue
+		}
+		for _, name := range c.columns {
+			if name == columnname {
+				constraints[c.name] = c
+			}
+		}
+	}
+	return constraints
+}
+
+func alterColumnSQL(tablename string, columnname string, action string) string {
+	return "ALTER TABLE " + quoteIdent(tablename) + " ALTER COLUMN " + quoteIdent(columnname) + " " + action + ";"
+}
+
+func columnTypeChangeSQL(tablename string, col Column) string {
+	typ := columnTypeSQL(col)
+	return alterColumnSQL(tablename, col.Name, "TYPE "+typ+" USING "+quoteIdent(col.Name)+"::"+typ)
+}
+
+func columnDefaultSQL(tablename string, col Column) string {
+	if col.HasDefault {
+		return alterColumnSQL(tablename, col.Name, "SET DEFAULT "+col.Default)
+	}
+	return alterColumnSQL(tablename, col.Name, "DROP DEFAULT")
+}
+
+// migrationServerVersion is the version of the server the migration runs on, the previous schema is the live one
+func migrationServerVersion(previous *Schema, current *Schema) int {
+	if previous.ServerVersion > 0 {
+		return previous.ServerVersion
+	}
+	return current.ServerVersion
+}
+
+func joinStatements(statements []string) string {
+	return strings.Join(statements, "\n\n")
+}
+
+// MigrationSQL renders the statements applying the changes, turning the previous schema into the current one, and
+// reverting them. Types and new enum values come first, then renames, new tables (referenced ones first, foreign keys
+// within a cycle added once the tables exist) and column changes, drops come last. Removed and reordered enum values
+// cannot be migrated in place, they are rendered as comments holding the guidance of the change
+func (c *ChangeSet) MigrationSQL(previous *Schema, current *Schema) []MigrationStatement {
+	m := newMigrationSchemas(c.Changes, previous, current)
+	enumSQL := map[string]string{}
+	for _, evolution := range CheckEnumEvolution(previous, current) {
+		enumSQL[evolution.Enumname+"."+evolution.Label] = evolution.SQL
+	}
+
+	phases := make([][]MigrationStatement, phaseCount)
+	add := func(phase int, change Change, up []string, down []string) {
+		phases[phase] = append(phases[phase], MigrationStatement{Change: change, Up: joinStatements(up), Down: joinStatements(down)})
+	}
+
+	added := []Table{}
+	addedChanges := map[string]Change{}
+	removed := []Table{}
+	removedChanges := map[string]Change{}
+	for _, change := range c.Changes {
+		table := quoteIdent(change.Tablename)
+		switch change.Kind {
+		case ChangeTableAdded:
+			if after, ok := current.TableByName(change.Tablename); ok {
+				added = append(added, *after)
+				addedChanges[after.Name] = change
+			}
+		case ChangeTableRemoved:
+			if before, ok := previous.TableByName(change.Tablename); ok {
+				removed = append(removed, *before)
+				removedChanges[before.Name] = change
+			}
+		case ChangeTableRenamed:
+			add(phaseRenames, change,
+				[]string{"ALTER TABLE " + quoteIdent(change.From) + " RENAME TO " + table + ";"},
+				[]string{"ALTER TABLE " + table + " RENAME TO " + quoteIdent(change.From) + ";"})
+		case ChangeColumnRenamed:
+			add(phaseRenames, change,
+				[]string{"ALTER TABLE " + table + " RENAME COLUMN " + quoteIdent(change.From) + " TO " + quoteIdent(change.Columnname) + ";"},
+				[]string{"ALTER TABLE " + table + " RENAME COLUMN " + quoteIdent(change.Columnname) + " TO " + quoteIdent(change.From) + ";"})
+		case ChangeColumnAdded:
+			_, col := m.columns(change)
+			up, down := m.prerequisites([]Table{{Columns: []Column{col}}})
+			up = append(up, "ALTER TABLE "+table+" ADD COLUMN "+columnDefinitionSQL(col)+";")
+			down = append([]string{"ALTER TABLE " + table + " DROP COLUMN " + quoteIdent(col.Name) + ";"}, down...)
+			add(phaseColumns, change, up, down)
+			after, _ := current.TableByName(change.Tablename)
+			before, _ := m.before(change.Tablename)
+			for _, constraint := range tableConstraints(*before) {
+				m.created["constraint "+after.Name+"."+constraint.name] = true
+			}
+			constraints := []string{}
+			for _, constraint := range tableConstraints(*after) {
+				key := "constraint " + after.Name + "." + constraint.name
+				for _, name := range constraint.columns {
+					if name == col.Name && !m.created[key] && len(constraintDefinitionSQL(constraint)) > 0 {
+						m.created[key] = true
+						constraints = append(constraints, addConstraintSQL(after.Name, constraint))
+					}
+				}
+			}
+			if len(constraints) > 0 {
+				// dropping the column drops its constraints
+				add(phaseConstraints, change, constraints, nil)
+			}
+		case ChangeColumnRemoved:
+			before, _ := m.before(change.Tablename)
+			col := before.ColumnsByName[change.Columnname]
+			add(phaseColumnDrops, change,
+				[]string{"ALTER TABLE " + table + " DROP COLUMN " + quoteIdent(col.Name) + ";"},
+				[]string{"ALTER TABLE " + table + " ADD COLUMN " + columnDefinitionSQL(col) + ";"})
+		case ChangeColumnWidened, ChangeColumnNarrowed, ChangeColumnTypeChanged:
+			from, to := m.columns(change)
+			up, down := m.prerequisites([]Table{{Columns: []Column{to}}})
+			add(phaseColumns, change, append(up, columnTypeChangeSQL(change.Tablename, to)), append([]string{columnTypeChangeSQL(change.Tablename, from)}, down...))
+		case ChangeColumnNullable:
+			add(phaseColumns, change,
+				[]string{alterColumnSQL(change.Tablename, change.Columnname, "DROP NOT NULL")},
+				[]string{alterColumnSQL(change.Tablename, change.Columnname, "SET NOT NULL")})
+		case ChangeColumnNotNull:
+			add(phaseColumns, change,
+				[]string{alterColumnSQL(change.Tablename, change.Columnname, "SET NOT NULL")},
+				[]string{alterColumnSQL(change.Tablename, change.Columnname, "DROP NOT NULL")})
+		case ChangeColumnDefault:
+			from, to := m.columns(change)
+			up, down := m.prerequisites([]Table{{Columns: []Column{to}}})
+			add(phaseColumns, change, append(up, columnDefaultSQL(change.Tablename, to)), append([]string{columnDefaultSQL(change.Tablename, from)}, down...))
+		case ChangeColumnReference:
+			before, _ := m.before(change.Tablename)
+			after, _ := current.TableByName(change.Tablename)
+			from, to := foreignKeys(before, change.Columnname), foreignKeys(after, change.Columnname)
+			up, down := []string{}, []string{}
+			for _, constraint := range tableConstraints(*after) {
+				if _, ok := to[constraint.name]; ok {
+					if _, existing := from[constraint.name]; !existing {
+						up = append(up, addConstraintSQL(change.Tablename, constraint))
+						down = append(down, "ALTER TABLE "+table+" DROP CONSTRAINT "+quoteIdent(constraint.name)+";")
+					}
+				}
+			}
+			for _, constraint := range tableConstraints(*before) {
+				if _, ok := from[constraint.name]; ok {
+					if _, kept := to[constraint.name]; !kept {
+						up = append([]string{"ALTER TABLE " + table + " DROP CONSTRAINT " + quoteIdent(constraint.name) + ";"}, up...)
+						down = append(down, addConstraintSQL(change.Tablename, constraint))
+					}
+				}
+			}
+			add(phaseConstraints, change, up, down)
+		case ChangeEnumAdded:
+			if enum, ok := current.EnumByName(change.Enumname); ok {
+				add(phaseTypes, change, []string{CreateEnumSQL(*enum)}, []string{"DROP TYPE " + quoteIdent(enum.Name) + ";"})
+			}
+		case ChangeEnumRemoved:
+			if enum, ok := previous.EnumByName(change.Enumname); ok {
+				add(phaseTypeDrops, change, []string{"DROP TYPE " + quoteIdent(enum.Name) + ";"}, []string{CreateEnumSQL(*enum)})
+			}
+		case ChangeEnumValueAdded, ChangeEnumValueInserted:
+			// postgres cannot drop enum values, and a value cannot be used within the transaction adding it
+			phases[phaseTypes] = append(phases[phaseTypes], MigrationStatement{Change: change, Up: enumSQL[change.Enumname+"."+change.Label], NoTransaction: true})
+		case ChangeEnumValueRenamed:
+			add(phaseTypes, change,
+				[]string{enumSQL[change.Enumname+"."+change.Label]},
+				[]string{fmt.Sprintf("ALTER TYPE %s RENAME VALUE %s TO %s;", quoteIdent(change.Enumname), quoteLiteral(change.To), quoteLiteral(change.Label))})
+		case ChangeEnumValueRemoved, ChangeEnumValueReordered:
+			add(phaseTypeDrops, change, []string{"-- " + singleLine(change.Reason)}, nil)
+		}
+	}
+
+	ordered, cyclic := dependencyOrder(added)
+	deferred := map[string]bool{}
+	for _, name := range cyclic {
+		deferred[name] = true
+	}
+	for _, table := range ordered {
+		up, down := m.prerequisites([]Table{table})
+		create := CreateTableSQL(table)
+		if deferred[table.Name] {
+			// the table is created without its foreign keys, they are added once all tables exist
+			create = CreateTableSQL(withoutForeignKeys(table))
+			constraints, drops := []string{}, []string{}
+			for _, c := range tableConstraints(table) {
+				if c.typ == ConstraintTypeForeignKey {
+					constraints = append(constraints, addConstraintSQL(table.Name, c))
+					drops = append(drops, "ALTER TABLE "+quoteIdent(table.Name)+" DROP CONSTRAINT "+quoteIdent(c.name)+";")
+				}
+			}
+			add(phaseConstraints, addedChanges[table.Name], constraints, drops)
+		}
+		add(phaseTables, addedChanges[table.Name], append(up, create), append([]string{"DROP TABLE " + quoteIdent(table.Name) + ";"}, down...))
+	}
+	// tables are dropped before the tables they reference
+	ordered, _ = dependencyOrder(removed)
+	for i := len(ordered) - 1; i >= 0; i-- {
+		table := ordered[i]
+		add(phaseTableDrops, removedChanges[table.Name], []string{"DROP TABLE " + quoteIdent(table.Name) + ";"}, []string{CreateTableSQL(table)})
+	}
+
+	version := migrationServerVersion(previous, current)
+	statements := []MigrationStatement{}
+	for _, phase := range phases {
+		for _, statement := range phase {
+			if len(statement.Up) > 0 {
+				if locks := AnnotateLocks(statement.Up, version); len(locks) > 0 {
+					statement.Locks = locks
+				}
+				statements = append(statements, statement)
+			}
+		}
+	}
+	return statements
+}
+
+type MigrationFormat string
+
+const (
+	MigrationFormatGolangMigrate MigrationFormat = "golang-migrate"
+	MigrationFormatGoose         MigrationFormat = "goose"
+)
+
+type MigrationOption func(o *migrationOptions)
+
+type migrationOptions struct {
+	version       uint64
+	name          string
+	maxStatements int
+}
+
+// WithMigrationVersion sets the version of the first migration, 1 by default, the next ones follow
+func WithMigrationVersion(version uint64) MigrationOption {
+	return func(o *migrationOptions) {
+		o.version = version
+	}
+}
+
+// WithMigrationName sets the name of the migrations, numbered when the changes are split
+func WithMigrationName(name string) MigrationOption {
+	return func(o *migrationOptions) {
+		o.name = name
+	}
+}
+
+// WithMaxStatements splits the changes into migrations of at most n statements
+func WithMaxStatements(n int) MigrationOption {
+	return func(o *migrationOptions) {
+		o.maxStatements = n
+	}
+}
+
+// Migrations groups the statements of MigrationSQL into numbered migrations, the down part reverting the statements
+// in reverse order. A statement which cannot share a transaction, such as adding an enum value, gets a migration of
+// its own run without one
+func (c *ChangeSet) Migrations(previous *Schema, current *Schema, opts ...MigrationOption) []Migration {
+	o := &migrationOptions{version: 1, name: "schema_changes"}
+	for _, opt := range opts {
+		opt(o)
+	}
+	groups := [][]MigrationStatement{}
+	for _, statement := range c.MigrationSQL(previous, current) {
+		last := len(groups) - 1
+		if last < 0 || statement.NoTransaction || groups[last][0].NoTransaction || (o.maxStatements > 0 && len(groups[last]) >= o.maxStatements) {
+			groups = append(groups, []MigrationStatement{})
+			last++
+		}
+		groups[last] = append(groups[last], statement)
+	}
+	migrations := make([]Migration, len(groups))
+	for i, group := range groups {
+		up, down := []string{}, []string{}
+		for _, statement := range group {
+			up = append(up, statement.Up)
+			if len(statement.Down) > 0 {
+				down = append([]string{statement.Down}, down...)
+			}
+		}
+		name := o.name
+		if len(groups) > 1 {
+			name = fmt.Sprintf("%s_%d", o.name, i+1)
+		}
+		migrations[i] = Migration{
+			Version:       o.version + uint64(i),
+			Name:          name,
+			Up:            joinStatements(up),
+			Down:          joinStatements(down),
+			NoTransaction: group[0].NoTransaction,
+		}
+	}
+	return migrations
+}
+
+// WriteMigrations writes migrations into dir as golang-migrate <version>_<name>.up.sql and .down.sql files wrapped
+// in a transaction, or as goose <version>_<name>.sql files which goose runs in a transaction itself. Migrations
+// flagged NoTransaction are written without one, existing files are never overwritten
+func WriteMigrations(dir string, migrations []Migration, format MigrationFormat) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	files := map[string]string{}
+	names := []string{}
+	file := func(name string, content string) {
+		names = append(names, name)
+		files[name] = content
+	}
+	for _, migration := range migrations {
+		prefix := fmt.Sprintf("%06d_%s", migration.Version, migration.Name)
+		switch format {
+		case MigrationFormatGolangMigrate:
+			wrap := func(statements string) string {
+				if len(statements) == 0 {
+					return "-- postgres cannot revert this migration\n"
+				}
+				if migration.NoTransaction {
+					return statements + "\n"
+				}
+				return "BEGIN;\n\n" + statements + "\n\nCOMMIT;\n"
+			}
+			file(prefix+".up.sql", wrap(migration.Up))
+			file(prefix+".down.sql", wrap(migration.Down))
+		case MigrationFormatGoose:
+			var b strings.Builder
+			if migration.NoTransaction {
+				b.WriteString("-- +goose NO TRANSACTION\n")
+			}
+			b.WriteString("-- +goose Up\n-- +goose StatementBegin\n" + migration.Up + "\n-- +goose StatementEnd\n")
+			if len(migration.Down) > 0 {
+				b.WriteString("\n-- +goose Down\n-- +goose StatementBegin\n" + migration.Down + "\n-- +goose StatementEnd\n")
+			}
+			file(prefix+".sql", b.String())
+		default:
+			return fmt.Errorf("unknown migration format %s", format)
+		}
+	}
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return fmt.Errorf("migration %s exists", path)
+		}
+	}
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(files[name]), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
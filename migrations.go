@@ -0,0 +1,351 @@
+package inverseschema
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Migration is a golang-migrate or goose migration, loaded migrations only hold their up part. NoTransaction marks
+// migrations which have to run outside of a transaction
+type Migration struct {
+	Version       uint64 `json:"version,omitempty"`
+	Name          string `json:"name,omitempty"`
+	Up            string `json:"up,omitempty"`
+	Down          string `json:"down,omitempty"`
+	NoTransaction bool   `json:"no_transaction,omitempty"`
+}
+
+var (
+	migrateFileRe = regexp.MustCompile(`^(\d+)_(.*)\.up\.sql$`)
+	gooseFileRe   = regexp.MustCompile(`^(\d+)_(.*)\.sql$`)
+)
+
+// gooseUp extracts the statements between the "-- +goose Up" and "-- +goose Down" annotations
+func gooseUp(content string) string {
+	var b strings.Builder
+	up := false
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	scanner.Buffer(make([]byte, 64*1024), len(content)+1)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if annotation := strings.TrimSpace(line); strings.HasPrefix(annotation, "-- +goose") {
+			switch strings.TrimSpace(strings.TrimPrefix(annotation, "-- +goose")) {
+			case "Up":
+				up = true
+			case "Down":
+				up = false
+			}
+			continue
+		}
+		if up {
+			b.WriteString(line + "\n")
+		}
+	}
+	return b.String()
+}
+
+// LoadMigrations reads a golang-migrate (<version>_<name>.up.sql) or goose (<version>_<name>.sql with
+// "-- +goose Up" sections) migration directory ordered by version, down migrations and go migrations are ignored
+func LoadMigrations(dir string) ([]Migration, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	migrations := []Migration{}
+	seen := map[uint64]string{}
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasSuffix(entry.Name(), ".down.sql") {
+			continue
+		}
+		goose := false
+		match := migrateFileRe.FindStringSubmatch(entry.Name())
+		if match == nil {
+			if match = gooseFileRe.FindStringSubmatch(entry.Name()); match == nil {
+				continue
+			}
+			goose = true
+		}
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version %s: %w", entry.Name(), err)
+		}
+		if other, ok := seen[version]; ok {
+			return nil, fmt.Errorf("migrations %s and %s share version %d", other, entry.Name(), version)
+		}
+		seen[version] = entry.Name()
+		content, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		migration := Migration{Version: version, Name: match[2], Up: string(content)}
+		if goose {
+			migration.Up = gooseUp(migration.Up)
+		}
+		migrations = append(migrations, migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// ApplyMigrations runs the up part of every migration in order, it is meant for throwaway databases
+func ApplyMigrations(ctx context.Context, db *sql.DB, migrations []Migration) error {
+	for _, migration := range migrations {
+		if strings.TrimSpace(migration.Up) == "" {
+			continue
+		}
+		if _, err := db.ExecContext(ctx, migration.Up); err != nil {
+			return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+	}
+	return nil
+}
+
+// SchemaFromMigrations applies a migration directory to an empty throwaway database and introspects the result, views
+// and materialized views included
+func SchemaFromMigrations(ctx context.Context, scratch *sql.DB, schemaname string, dir string, opts ...PostgresOption) (*Schema, error) {
+	migrations, err := LoadMigrations(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := ApplyMigrations(ctx, scratch, migrations); err != nil {
+		return nil, err
+	}
+	schema := NewSchema(NewPostgresAdapter(scratch, schemaname, opts...), WithObjects(ObjectDefault|ObjectViews|ObjectMaterializedViews))
+	if err := schema.ParseContext(ctx); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// BaselineSQL renders the schema as a single script creating every sequence of a nextval default, enum, domain and
+// composite type used by a column, table, index, view and materialized view, tables are ordered so that referenced
+// tables are created first, foreign keys between tables of a cycle are added once all tables exist
+func (s *Schema) BaselineSQL() string {
+	return s.baselineSQL(func(index Index) string { return index.Definition })
+}
+
+// baselineSQL renders the baseline script, index renders the statement creating a secondary index, an empty
+// statement leaves the index out
+func (s *Schema) baselineSQL(index func(index Index) string) string {
+	_, statements, _ := sequencesSQL(s.Tables, false)
+	for _, enum := range s.Enums {
+		statements = append(statements, CreateEnumSQL(enum))
+	}
+	statements = append(statements, typeDefinitionsSQL(s.Tables)...)
+	tables, cyclic := dependencyOrder(s.Tables)
+	deferred := map[string]bool{}
+	for _, name := range cyclic {
+		deferred[name] = true
+	}
+	alters := []string{}
+	for _, table := range tables {
+		constraintNames := map[string]bool{}
+		for _, c := range tableConstraints(table) {
+			constraintNames[c.name] = true
+		}
+		for _, idx := range table.Indexes {
+			if constraintNames[idx.Name] || idx.IsPrimary {
+				continue
+			}
+			if def := index(idx); len(def) > 0 {
+				alters = append(alters, def+";")
+			}
+		}
+		if !deferred[table.Name] {
+			statements = append(statements, CreateTableSQL(table))
+			continue
+		}
+		// the table is created without its foreign keys, they are added at the end
+		statements = append(statements, CreateTableSQL(withoutForeignKeys(table)))
+		for _, c := range tableConstraints(table) {
+			if c.typ == ConstraintTypeForeignKey {
+				alters = append(alters, addConstraintSQL(table.Name, c))
+			}
+		}
+	}
+	statements = append(statements, alters...)
+	return strings.Join(append(statements, viewsSQL(s.Views, s.MaterializedViews)...), "\n\n") + "\n"
+}
+
+// viewsSQL renders the statements creating the parsed views and materialized views, a view is created after the
+// views its definition mentions
+func viewsSQL(views []Table, matviews []MaterializedView) []string {
+	type view struct {
+		name       string
+		definition string
+		statement  string
+	}
+	pending := []view{}
+	for _, v := range views {
+		definition := strings.TrimSuffix(strings.TrimSpace(v.ViewDefinition), ";")
+		pending = append(pending, view{v.Name, definition, "CREATE VIEW " + quoteIdent(v.Name) + " AS\n" + definition + ";"})
+	}
+	for _, v := range matviews {
+		definition := strings.TrimSuffix(strings.TrimSpace(v.Definition), ";")
+		pending = append(pending, view{v.Name, definition, "CREATE MATERIALIZED VIEW " + quoteIdent(v.Name) + " AS\n" + definition + ";"})
+	}
+	mentionRes := map[string]*regexp.Regexp{}
+	for _, v := range pending {
+		mentionRes[v.name] = regexp.MustCompile(`(?:^|[^\w$])"?` + regexp.QuoteMeta(v.name) + `"?(?:[^\w$]|$)`)
+	}
+	placed := map[string]bool{}
+	statements := []string{}
+	for progress := true; progress; {
+		progress = false
+		for _, v := range pending {
+			if placed[v.name] {
+				continue
+			}
+			ready := true
+			for _, other := range pending {
+				if other.name != v.name && !placed[other.name] && mentionRes[other.name].MatchString(v.definition) {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				placed[v.name] = true
+				statements = append(statements, v.statement)
+				progress = true
+			}
+		}
+	}
+	// a mention may be a column or alias named after another view, such views keep the parsed order
+	for _, v := range pending {
+		if !placed[v.name] {
+			statements = append(statements, v.statement)
+		}
+	}
+	return statements
+}
+
+// unrenderedObjects lists the functions, triggers and sequences of a schema the baseline does not render, sequences
+// are rendered only when a nextval default of the schema uses them and identity sequences come with their column
+func unrenderedObjects(ctx context.Context, db *sql.DB, schemaname string, schema *Schema) ([]string, error) {
+	_, _, names := sequencesSQL(schema.Tables, false)
+	rendered := map[string]bool{}
+	for _, name := range names {
+		rendered[name] = true
+	}
+	rows, err := db.QueryContext(ctx, `SELECT 'function ' || p.oid::regprocedure::text, NULL::text
+		FROM pg_catalog.pg_proc p
+			JOIN pg_catalog.pg_namespace n ON n.oid = p.pronamespace
+		WHERE n.nspname = $1
+			AND NOT EXISTS (SELECT 1 FROM pg_catalog.pg_depend d
+				WHERE d.classid = 'pg_catalog.pg_proc'::regclass AND d.objid = p.oid AND d.deptype = 'e')
+		UNION ALL
+		SELECT 'trigger ' || quote_ident(t.tgname) || ' on ' || quote_ident(c.relname), NULL::text
+		FROM pg_catalog.pg_trigger t
+			JOIN pg_catalog.pg_class c ON c.oid = t.tgrelid
+			JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1 AND NOT t.tgisinternal
+		UNION ALL
+		SELECT 'sequence ' || quote_ident(c.relname), c.relname::text
+		FROM pg_catalog.pg_class c
+			JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1 AND c.relkind = 'S'
+			AND NOT EXISTS (SELECT 1 FROM pg_catalog.pg_depend d
+				WHERE d.classid = 'pg_catalog.pg_class'::regclass AND d.objid = c.oid AND d.deptype = 'i')
+		ORDER BY 1`, schemaname)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	objects := []string{}
+	for rows.Next() {
+		var object string
+		var sequence *string
+		if err := rows.Scan(&object, &sequence); err != nil {
+			return nil, err
+		}
+		if sequence == nil || !rendered[*sequence] {
+			objects = append(objects, object)
+		}
+	}
+	return objects, rows.Err()
+}
+
+// Baseline is a migration directory squashed into a single script, Verification lists the differences between the
+// schema the migrations produce and production
+type Baseline struct {
+	Schema       *Schema            `json:"schema,omitempty"`
+	SQL          string             `json:"sql,omitempty"`
+	Verification *EnvironmentMatrix `json:"verification,omitempty"`
+	// Locks annotates the statements of SQL with their locks on the server version of production
+	Locks []StatementLock `json:"locks,omitempty"`
+}
+
+// SquashMigrations applies a migration directory to a throwaway database, renders the resulting schema as a baseline
+// script and compares it against production. Functions, triggers and sequences no column default uses cannot be
+// rendered, the squash fails when the migrations create any rather than return an incomplete baseline
+func SquashMigrations(ctx context.Context, scratch *sql.DB, schemaname string, dir string, production Adapter) (*Baseline, error) {
+	schema, err := SchemaFromMigrations(ctx, scratch, schemaname, dir)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := unrenderedObjects(ctx, scratch, schemaname, schema)
+	if err != nil {
+		return nil, err
+	}
+	if len(objects) > 0 {
+		return nil, fmt.Errorf("the baseline cannot render %s", strings.Join(objects, ", "))
+	}
+	verification, err := compareMigrations(ctx, schema, "production", production)
+	if err != nil {
+		return nil, err
+	}
+	baseline := &Baseline{Schema: schema, SQL: schema.BaselineSQL(), Verification: verification}
+	version := schema.ServerVersion
+	if adapter, ok := production.(VersionAdapter); ok {
+		if version, err = adapter.ServerVersion(ctx); errors.Is(err, ErrNotSupported) {
+			version = schema.ServerVersion
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	baseline.Locks = AnnotateLocks(baseline.SQL, version)
+	return baseline, nil
+}
+
+// VerifyMigrations builds the schema a migration directory produces on a throwaway database and compares it with
+// the live database, every row of the returned matrix is drift, typically caused by out of band DDL
+func VerifyMigrations(ctx context.Context, scratch *sql.DB, schemaname string, dir string, live Adapter) (*EnvironmentMatrix, error) {
+	schema, err := SchemaFromMigrations(ctx, scratch, schemaname, dir)
+	if err != nil {
+		return nil, err
+	}
+	return compareMigrations(ctx, schema, "live", live)
+}
+
+// withoutInternalTables drops the bookkeeping tables of migration tools, matched by DefaultInternalPatterns, which
+// the migrated databases hold and the scratch database does not
+func withoutInternalTables(tables []Table) []Table {
+	kept := make([]Table, 0, len(tables))
+	for _, table := range tables {
+		if !internalObject(DefaultInternalPatterns, table.Name, false) {
+			kept = append(kept, table)
+		}
+	}
+	return kept
+}
+
+func compareMigrations(ctx context.Context, expected *Schema, name string, adapter Adapter) (*EnvironmentMatrix, error) {
+	actual := NewSchema(adapter)
+	if err := actual.ParseContext(ctx); err != nil {
+		return nil, err
+	}
+	actual.Tables = withoutInternalTables(actual.Tables)
+	expected.Tables = withoutInternalTables(expected.Tables)
+	return CompareEnvironments([]string{"migrations", name}, map[string]*Schema{"migrations": expected, name: actual})
+}
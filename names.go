@@ -0,0 +1,71 @@
+package inverseschema
+
+import (
+	"strings"
+	"unicode"
+)
+
+func nameWords(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func pascalCase(s string) string {
+	var b strings.Builder
+	for _, word := range nameWords(s) {
+		runes := []rune(word)
+		b.WriteRune(unicode.ToUpper(runes[0]))
+		b.WriteString(string(runes[1:]))
+	}
+	return b.String()
+}
+
+func camelCase(s string) string {
+	p := []rune(pascalCase(s))
+	if len(p) == 0 {
+		return ""
+	}
+	p[0] = unicode.ToLower(p[0])
+	return string(p)
+}
+
+// tableTypeName is the name generators use for the type of a table, the overlay app name takes precedence
+func tableTypeName(table Table) string {
+	if len(table.AppName) > 0 {
+		return table.AppName
+	}
+	return pascalCase(table.Name)
+}
+
+// columnFieldName is the name generators use for the field of a column, the overlay app name takes precedence
+func columnFieldName(col Column) string {
+	if len(col.AppName) > 0 {
+		return col.AppName
+	}
+	return camelCase(col.Name)
+}
+
+// columnAttributeName is the columnFieldName of generators keeping the database naming (CUE labels, XSD elements and
+// python attributes), the overlay app name still takes precedence
+func columnAttributeName(col Column) string {
+	if len(col.AppName) > 0 {
+		return col.AppName
+	}
+	return col.Name
+}
+
+// singular is a naive english singular of a lower case name, used to name types derived from tables
+func singular(name string) string {
+	switch {
+	case strings.HasSuffix(name, "ies") && len(name) > 3:
+		return strings.TrimSuffix(name, "ies") + "y"
+	case strings.HasSuffix(name, "sses"), strings.HasSuffix(name, "uses"), strings.HasSuffix(name, "xes"), strings.HasSuffix(name, "ches"), strings.HasSuffix(name, "shes"):
+		return strings.TrimSuffix(name, "es")
+	case strings.HasSuffix(name, "ss"), strings.HasSuffix(name, "us"):
+		return name
+	case strings.HasSuffix(name, "s") && len(name) > 1:
+		return strings.TrimSuffix(name, "s")
+	}
+	return name
+}
@@ -0,0 +1,160 @@
+package inverseschema
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// NamingConvention holds a name template per kind of object, templates may use {table}, {columns} (joined by an underscore),
+// {column} (the first column) and {foreign_table}, an empty template disables the check for that kind
+type NamingConvention struct {
+	PrimaryKey string `json:"primary_key,omitempty"`
+	ForeignKey string `json:"foreign_key,omitempty"`
+	Unique     string `json:"unique,omitempty"`
+	Index      string `json:"index,omitempty"`
+}
+
+// DefaultNamingConvention mirrors the names postgres generates itself
+var DefaultNamingConvention = NamingConvention{
+	PrimaryKey: "{table}_pkey",
+	ForeignKey: "{table}_{columns}_fkey",
+	Unique:     "{table}_{columns}_key",
+	Index:      "{table}_{columns}_idx",
+}
+
+type NamingViolation struct {
+	Tablename    string `json:"tablename,omitempty"`
+	Name         string `json:"name,omitempty"`
+	ExpectedName string `json:"expected_name,omitempty"`
+	RenameSQL    string `json:"rename_sql,omitempty"`
+	// Lock is the lock the rename takes on the server version of the schema
+	Lock *LockImpact `json:"lock,omitempty"`
+}
+
+// postgres truncates identifiers to 63 bytes
+const maxIdentifierLength = 63
+
+func expandNamingTemplate(template string, tablename string, columns []string, foreignTablename string) string {
+	column := ""
+	if len(columns) > 0 {
+		column = columns[0]
+	}
+	return strings.NewReplacer(
+		"{table}", tablename,
+		"{columns}", strings.Join(columns, "_"),
+		"{column}", column,
+		"{foreign_table}", foreignTablename,
+	).Replace(template)
+}
+
+var namingFunctionRe = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*)\(`)
+
+// namingColumns names the keys of an index like postgres does: columns by their name, function calls by the function
+// and any other expression as expr
+func namingColumns(table Table, keys []string) []string {
+	names := make([]string, len(keys))
+	for i, key := range keys {
+		unquoted := strings.ReplaceAll(strings.Trim(key, `"`), `""`, `"`)
+		if _, ok := table.ColumnsByName[unquoted]; ok {
+			names[i] = unquoted
+		} else if match := namingFunctionRe.FindStringSubmatch(key); match != nil {
+			names[i] = strings.ToLower(match[1])
+		} else {
+			names[i] = "expr"
+		}
+	}
+	return names
+}
+
+// fitIdentifier truncates name on a rune boundary so that, with suffix appended, it fits the 63 bytes postgres keeps
+func fitIdentifier(name string, suffix string) string {
+	limit := maxIdentifierLength - len(suffix)
+	if len(name) > limit {
+		name = name[:limit]
+		for len(name) > 0 && !utf8.ValidString(name) {
+			name = name[:len(name)-1]
+		}
+	}
+	return name + suffix
+}
+
+// uniqueName fits name and, when it is taken, numbers it as postgres does (name1, name2, ...), the result is reserved
+func uniqueName(name string, taken map[string]bool) string {
+	candidate := fitIdentifier(name, "")
+	for n := 1; taken[candidate]; n++ {
+		candidate = fitIdentifier(name, strconv.Itoa(n))
+	}
+	taken[candidate] = true
+	return candidate
+}
+
+// CheckNaming compares constraint and index names against a convention, every violation carries the statement renaming it,
+// indexes backing a constraint are renamed through their constraint. Expected names are truncated to 63 bytes and
+// numbered when another index or constraint already holds them
+func (s *Schema) CheckNaming(convention NamingConvention) []NamingViolation {
+	violations := []NamingViolation{}
+	taken := map[string]bool{}
+	for _, table := range s.Tables {
+		for _, c := range tableConstraints(table) {
+			taken[c.name] = true
+		}
+		for _, index := range table.Indexes {
+			taken[index.Name] = true
+		}
+	}
+	for _, table := range s.Tables {
+		constraintNames := map[string]bool{}
+		for _, c := range tableConstraints(table) {
+			constraintNames[c.name] = true
+			template := ""
+			switch c.typ {
+			case ConstraintTypePrimaryKey:
+				template = convention.PrimaryKey
+			case ConstraintTypeForeignKey:
+				template = convention.ForeignKey
+			case ConstraintTypeUnique:
+				template = convention.Unique
+			}
+			if len(template) == 0 {
+				continue
+			}
+			expected := expandNamingTemplate(template, table.Name, c.columns, c.foreignTablename)
+			if fitIdentifier(expected, "") == c.name {
+				continue
+			}
+			expected = uniqueName(expected, taken)
+			violations = append(violations, NamingViolation{
+				Tablename:    table.Name,
+				Name:         c.name,
+				ExpectedName: expected,
+				RenameSQL:    "ALTER TABLE " + quoteIdent(table.Name) + " RENAME CONSTRAINT " + quoteIdent(c.name) + " TO " + quoteIdent(expected) + ";",
+			})
+			violation := &violations[len(violations)-1]
+			violation.Lock = statementLock(violation.RenameSQL, s.ServerVersion)
+		}
+		if len(convention.Index) == 0 {
+			continue
+		}
+		for _, index := range table.Indexes {
+			if constraintNames[index.Name] || index.IsPrimary {
+				continue
+			}
+			expected := expandNamingTemplate(convention.Index, table.Name, namingColumns(table, index.Columns), "")
+			if fitIdentifier(expected, "") == index.Name {
+				continue
+			}
+			expected = uniqueName(expected, taken)
+			violations = append(violations, NamingViolation{
+				Tablename:    table.Name,
+				Name:         index.Name,
+				ExpectedName: expected,
+				RenameSQL:    "ALTER INDEX " + quoteIdent(index.Name) + " RENAME TO " + quoteIdent(expected) + ";",
+			})
+			violation := &violations[len(violations)-1]
+			violation.Lock = statementLock(violation.RenameSQL, s.ServerVersion)
+		}
+	}
+	return violations
+}
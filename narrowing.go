@@ -0,0 +1,122 @@
+package inverseschema
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// TypeNarrowing is a column change which existing data may not survive, Probe counts the rows violating the new
+// definition, Checked, Fits and Violations are filled by ProbeNarrowing
+type TypeNarrowing struct {
+	Tablename  string `json:"tablename,omitempty"`
+	Columnname string `json:"columnname,omitempty"`
+	From       string `json:"from,omitempty"`
+	To         string `json:"to,omitempty"`
+	Probe      string `json:"probe,omitempty"`
+	Checked    bool   `json:"checked,omitempty"`
+	Fits       bool   `json:"fits,omitempty"`
+	Violations int64  `json:"violations,omitempty"`
+}
+
+var integerRanges = map[Datatype][2]int64{
+	DatatypeSmallint: {-32768, 32767},
+	DatatypeInt:      {-2147483648, 2147483647},
+	DatatypeBigint:   {-9223372036854775808, 9223372036854775807},
+}
+
+// textLimit is the maximum length of a character column, 0 when unbounded or not a character type
+func textLimit(col Column) (int, bool) {
+	switch col.Datatype {
+	case DatatypeText:
+		return 0, true
+	case DatatypeVarchar:
+		return col.CharacterMaxLength, true
+	}
+	return 0, false
+}
+
+// narrowingCondition returns the condition matching rows which do not fit the new column definition
+func narrowingCondition(from Column, to Column) (string, bool) {
+	column := quoteIdent(to.Name)
+	if from.IsArray || to.IsArray {
+		return "", false
+	}
+	if fromLimit, ok := textLimit(from); ok {
+		if toLimit, ok := textLimit(to); ok && toLimit > 0 && (fromLimit == 0 || toLimit < fromLimit) {
+			return fmt.Sprintf("char_length(%s) > %d", column, toLimit), true
+		}
+		return "", false
+	}
+	toRange, ok := integerRanges[to.Datatype]
+	if !ok {
+		return "", false
+	}
+	if fromRange, ok := integerRanges[from.Datatype]; ok {
+		if toRange[1] < fromRange[1] {
+			return fmt.Sprintf("%s NOT BETWEEN %d AND %d", column, toRange[0], toRange[1]), true
+		}
+		return "", false
+	}
+	switch from.Datatype {
+	case DatatypeNumeric, DatatypeDecimal, DatatypeVariableNumeric:
+		return fmt.Sprintf("(%s <> trunc(%s) OR %s NOT BETWEEN %d AND %d)", column, column, column, toRange[0], toRange[1]), true
+	}
+	return "", false
+}
+
+// DetectNarrowing finds the columns of current which narrow their type compared to previous (text to varchar(n),
+// shorter varchar, smaller integers, numeric to integers) or became NOT NULL
+func DetectNarrowing(previous *Schema, current *Schema, schemaname string) []TypeNarrowing {
+	narrowings := []TypeNarrowing{}
+	for _, table := range current.Tables {
+		before, ok := previous.TableByName(table.Name)
+		if !ok {
+			continue
+		}
+		for _, col := range table.Columns {
+			from, ok := before.ColumnsByName[col.Name]
+			if !ok || col.IsVirtual || from.IsVirtual {
+				continue
+			}
+			conditions := []string{}
+			if condition, ok := narrowingCondition(from, col); ok {
+				conditions = append(conditions, condition)
+			}
+			if from.IsNullable && !col.IsNullable {
+				conditions = append(conditions, quoteIdent(col.Name)+" IS NULL")
+			}
+			if len(conditions) == 0 {
+				continue
+			}
+			narrowings = append(narrowings, TypeNarrowing{
+				Tablename:  table.Name,
+				Columnname: col.Name,
+				From:       columnSignature(from),
+				To:         columnSignature(col),
+				Probe:      fmt.Sprintf("SELECT count(*) FROM %s.%s WHERE %s", quoteIdent(schemaname), quoteIdent(table.Name), strings.Join(conditions, " OR ")),
+			})
+		}
+	}
+	return narrowings
+}
+
+// ProbeNarrowing runs the probe of every narrowing against the source database within a read only transaction
+func ProbeNarrowing(ctx context.Context, db *sql.DB, narrowings []TypeNarrowing) ([]TypeNarrowing, error) {
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	probed := make([]TypeNarrowing, len(narrowings))
+	for i, narrowing := range narrowings {
+		if err := tx.QueryRowContext(ctx, narrowing.Probe).Scan(&narrowing.Violations); err != nil {
+			return nil, err
+		}
+		narrowing.Checked = true
+		narrowing.Fits = narrowing.Violations == 0
+		probed[i] = narrowing
+	}
+	return probed, nil
+}
@@ -0,0 +1,125 @@
+package inverseschema
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	naturalKeyNameRe   = regexp.MustCompile(`^(email|email_address|username|user_name|login|handle|slug|code|sku|isbn|ean|upc|iban|external_id|external_ref)$|_(code|number|no|slug|key|external_id)$`)
+	surrogateDefaultRe = regexp.MustCompile(`(?i)nextval\(|gen_random_uuid\(|uuid_generate_v|newid\(|uuidv7\(`)
+)
+
+// surrogateColumn tells whether a column holds generated values with no meaning outside the database
+func surrogateColumn(col Column) bool {
+	if col.IsIdentity || (col.HasDefault && surrogateDefaultRe.MatchString(col.Default)) {
+		return true
+	}
+	return col.IsPrimary && col.Name == "id"
+}
+
+// uniqueColumnSets lists the column sets a table enforces uniqueness on: primary key and unique constraints followed
+// by unique indexes without predicate over plain columns
+func uniqueColumnSets(table Table) [][]string {
+	sets := [][]string{}
+	seen := map[string]bool{}
+	add := func(cols []string) {
+		key := strings.Join(cols, ",")
+		if len(cols) == 0 || seen[key] {
+			return
+		}
+		seen[key] = true
+		sets = append(sets, cols)
+	}
+
+	constraints := map[string][]string{}
+	order := []string{}
+	for _, col := range table.Columns {
+		for _, c := range col.Constraints {
+			if c.Type != ConstraintTypePrimaryKey && c.Type != ConstraintTypeUnique {
+				continue
+			}
+			if _, ok := constraints[c.Name]; !ok {
+				order = append(order, c.Name)
+			}
+			constraints[c.Name] = append(constraints[c.Name], col.Name)
+		}
+	}
+	for _, name := range order {
+		add(constraints[name])
+	}
+	for _, index := range table.Indexes {
+		if !index.IsUnique || len(index.Predicate) > 0 {
+			continue
+		}
+		cols := []string{}
+		for _, key := range index.Columns {
+			if _, ok := table.ColumnsByName[strings.Trim(key, `"`)]; !ok {
+				cols = nil
+				break
+			}
+			cols = append(cols, strings.Trim(key, `"`))
+		}
+		add(cols)
+	}
+	if len(sets) == 0 {
+		// sources without constraints nor indexes only report the flags
+		if pk := primaryKeyColumns(table); len(pk) > 0 {
+			add(pk)
+		}
+		for _, col := range table.Columns {
+			if col.IsUnique && !col.IsPrimary {
+				add([]string{col.Name})
+			}
+		}
+	}
+	return sets
+}
+
+// naturalKeys lists the candidate natural keys of a table: column sets enforced unique, not nullable and holding no
+// surrogate column, those named like natural identifiers (email, slug, external_id, ...) first
+func naturalKeys(table Table) [][]string {
+	byName := make(map[string]Column, len(table.Columns))
+	for _, col := range table.Columns {
+		byName[col.Name] = col
+	}
+	keys := [][]string{}
+	sets := uniqueColumnSets(table)
+	for _, set := range sets {
+		candidate := true
+		for _, name := range set {
+			col, ok := byName[name]
+			if !ok || col.IsNullable || surrogateColumn(col) {
+				candidate = false
+				break
+			}
+		}
+		if candidate {
+			keys = append(keys, set)
+		}
+	}
+	named := func(key []string) bool {
+		for _, name := range key {
+			if naturalKeyNameRe.MatchString(name) {
+				return true
+			}
+		}
+		return false
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		return named(keys[i]) && !named(keys[j])
+	})
+	return keys
+}
+
+// DetectNaturalKeys sets Table.NaturalKeys, ParseContext runs it after parsing
+func (s *Schema) DetectNaturalKeys() {
+	for i := range s.Tables {
+		keys := naturalKeys(s.Tables[i])
+		if len(keys) == 0 {
+			keys = nil
+		}
+		s.Tables[i].NaturalKeys = keys
+	}
+}
@@ -0,0 +1,55 @@
+package inverseschema
+
+import "context"
+
+// ObjectKind selects the kinds of objects a parse introspects, kinds combine with |
+type ObjectKind uint
+
+const (
+	ObjectTables ObjectKind = 1 << iota
+	ObjectViews
+	ObjectMaterializedViews
+	ObjectEnums
+	// ObjectIndexes and ObjectConstraints complete the tables, they are only introspected along ObjectTables
+	ObjectIndexes
+	ObjectConstraints
+	// ObjectComments covers table and column comments along with security labels
+	ObjectComments
+)
+
+// ObjectDefault is what a parse introspects without WithObjects
+const ObjectDefault = ObjectTables | ObjectEnums | ObjectIndexes | ObjectConstraints | ObjectComments
+
+// WithTypesOnly restricts Parse to the tables with their column types and to the enums, skipping indexes, constraints
+// and comments, for callers such as form builders which need nothing else
+func WithTypesOnly() SchemaOption {
+	return WithObjects(ObjectTables | ObjectEnums)
+}
+
+// WithObjects restricts Parse to the given kinds of objects, views and materialized views are parsed along when
+// selected and the adapter supports them
+func WithObjects(kinds ObjectKind) SchemaOption {
+	return func(s *Schema) {
+		s.objects = kinds
+	}
+}
+
+type objectsKey struct{}
+
+// ContextWithObjects restricts the introspection made with ctx to the given kinds, Parse sets it from WithObjects so
+// concurrent parses sharing an adapter each keep their own selection
+func ContextWithObjects(ctx context.Context, kinds ObjectKind) context.Context {
+	return context.WithValue(ctx, objectsKey{}, kinds)
+}
+
+// ObjectsFromContext returns the kinds selected on ctx, 0 when nothing was selected and everything is introspected
+func ObjectsFromContext(ctx context.Context) ObjectKind {
+	kinds, _ := ctx.Value(objectsKey{}).(ObjectKind)
+	return kinds
+}
+
+// selects tells whether the introspection made with ctx covers kind
+func selects(ctx context.Context, kind ObjectKind) bool {
+	kinds := ObjectsFromContext(ctx)
+	return kinds == 0 || kinds&kind != 0
+}
@@ -0,0 +1,60 @@
+package inverseschema
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Overlay maps database names to preferred application names, column keys are either "table.column" or a bare
+// column name applying to every table, the qualified form takes precedence
+type Overlay struct {
+	Tables  map[string]string          `json:"tables,omitempty"`
+	Columns map[string]string          `json:"columns,omitempty"`
+	Enums   map[string]EnumAnnotations `json:"enums,omitempty"`
+}
+
+func LoadOverlay(r io.Reader) (*Overlay, error) {
+	overlay := &Overlay{}
+	if err := json.NewDecoder(r).Decode(overlay); err != nil {
+		return nil, err
+	}
+	return overlay, nil
+}
+
+func (o *Overlay) TableName(tablename string) (string, bool) {
+	name, ok := o.Tables[tablename]
+	return name, ok
+}
+
+func (o *Overlay) ColumnName(tablename string, columnname string) (string, bool) {
+	if name, ok := o.Columns[tablename+"."+columnname]; ok {
+		return name, true
+	}
+	name, ok := o.Columns[columnname]
+	return name, ok
+}
+
+// ApplyOverlay sets AppName on every mapped table and column, the overlay is kept on the schema so it is stored with snapshots
+func (s *Schema) ApplyOverlay(overlay *Overlay) {
+	s.Overlay = overlay
+	for i := range s.Tables {
+		table := &s.Tables[i]
+		if name, ok := overlay.TableName(table.Name); ok {
+			table.AppName = name
+		}
+		for j := range table.Columns {
+			col := &table.Columns[j]
+			if name, ok := overlay.ColumnName(table.Name, col.Name); ok {
+				col.AppName = name
+				if table.ColumnsByName != nil {
+					table.ColumnsByName[col.Name] = *col
+				}
+			}
+		}
+	}
+	for i := range s.Enums {
+		if annotations, ok := overlay.Enums[s.Enums[i].Name]; ok {
+			applyEnumAnnotations(&s.Enums[i], annotations)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package inverseschema
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type PartitionIssue struct {
+	Tablename string `json:"tablename,omitempty"`
+	Partition string `json:"partition,omitempty"`
+	Detail    string `json:"detail,omitempty"`
+}
+
+func indexSignature(index Index) string {
+	return fmt.Sprintf("%s %t (%s) %s", index.Method, index.IsUnique, strings.Join(index.Columns, ", "), index.Predicate)
+}
+
+func describeIndexSignature(index Index) string {
+	unique := ""
+	if index.IsUnique {
+		unique = "unique "
+	}
+	predicate := ""
+	if len(index.Predicate) > 0 {
+		predicate = " WHERE " + index.Predicate
+	}
+	return fmt.Sprintf("%s%s index on (%s)%s", unique, index.Method, strings.Join(index.Columns, ", "), predicate)
+}
+
+var constraintTypeNames = map[ConstraintType]string{
+	ConstraintTypeCheck:      "check",
+	ConstraintTypeForeignKey: "foreign key",
+	ConstraintTypePrimaryKey: "primary key",
+	ConstraintTypeUnique:     "unique",
+	ConstraintTypeTrigger:    "trigger",
+	ConstraintTypeExclusion:  "exclusion",
+}
+
+func constraintSignature(c tableConstraint) string {
+	signature := fmt.Sprintf("%s constraint on (%s)", constraintTypeNames[c.typ], strings.Join(c.columns, ", "))
+	if c.typ == ConstraintTypeForeignKey {
+		signature += fmt.Sprintf(" referencing %s (%s)", c.foreignTablename, strings.Join(c.foreignColumns, ", "))
+	}
+	return signature
+}
+
+// PartitionCoverage checks every partitioned table: foreign key and leading index columns which are not partition keys
+// (lookups by them cannot be pruned), and partitions missing indexes or constraints present on their siblings
+func (s *Schema) PartitionCoverage() []PartitionIssue {
+	issues := []PartitionIssue{}
+	partitions := map[string][]Table{}
+	for _, table := range s.Tables {
+		if len(table.PartitionOf) > 0 {
+			partitions[table.PartitionOf] = append(partitions[table.PartitionOf], table)
+		}
+	}
+
+	for _, table := range s.Tables {
+		if table.Partitioning == nil {
+			continue
+		}
+		keys := map[string]bool{}
+		for _, col := range table.Partitioning.Columns {
+			keys[col] = true
+		}
+		lookups := map[string]string{}
+		for _, col := range table.Columns {
+			if col.IsReference {
+				lookups[col.Name] = "foreign key"
+			}
+		}
+		for _, index := range table.Indexes {
+			if len(index.Columns) > 0 {
+				if _, ok := lookups[index.Columns[0]]; !ok {
+					lookups[index.Columns[0]] = "indexed lookup"
+				}
+			}
+		}
+		names := make([]string, 0, len(lookups))
+		for name := range lookups {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if !keys[name] {
+				issues = append(issues, PartitionIssue{
+					Tablename: table.Name,
+					Detail:    fmt.Sprintf("%s column %s is not a partition key, lookups by it scan every partition", lookups[name], name),
+				})
+			}
+		}
+
+		children := partitions[table.Name]
+		indexCount := map[string]int{}
+		indexDescription := map[string]string{}
+		constraintCount := map[string]int{}
+		for _, child := range children {
+			seen := map[string]bool{}
+			for _, index := range child.Indexes {
+				signature := indexSignature(index)
+				if !seen[signature] {
+					seen[signature] = true
+					indexCount[signature]++
+					indexDescription[signature] = describeIndexSignature(index)
+				}
+			}
+			for _, c := range tableConstraints(child) {
+				signature := constraintSignature(c)
+				if !seen[signature] {
+					seen[signature] = true
+					constraintCount[signature]++
+				}
+			}
+		}
+		for _, child := range children {
+			present := map[string]bool{}
+			for _, index := range child.Indexes {
+				present[indexSignature(index)] = true
+			}
+			for _, c := range tableConstraints(child) {
+				present[constraintSignature(c)] = true
+			}
+			missing := []string{}
+			for signature := range indexCount {
+				if !present[signature] {
+					missing = append(missing, "missing "+indexDescription[signature])
+				}
+			}
+			for signature := range constraintCount {
+				if !present[signature] {
+					missing = append(missing, "missing "+signature)
+				}
+			}
+			sort.Strings(missing)
+			for _, detail := range missing {
+				issues = append(issues, PartitionIssue{Tablename: table.Name, Partition: child.Name, Detail: detail + " present on sibling partitions"})
+			}
+		}
+	}
+	return issues
+}
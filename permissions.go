@@ -0,0 +1,113 @@
+package inverseschema
+
+import (
+	"context"
+	"errors"
+	"sort"
+)
+
+var ErrNotSupported = errors.New("not supported by adapter")
+
+const RolePublic = "PUBLIC"
+
+type Role struct {
+	Name        string   `json:"name,omitempty"`
+	IsSuperuser bool     `json:"is_superuser,omitempty"`
+	Inherit     bool     `json:"inherit,omitempty"`
+	CanLogin    bool     `json:"can_login,omitempty"`
+	MemberOf    []string `json:"member_of,omitempty"`
+}
+
+type Grant struct {
+	Grantee     string `json:"grantee,omitempty"`
+	Tablename   string `json:"tablename,omitempty"`
+	Privilege   string `json:"privilege,omitempty"`
+	IsGrantable bool   `json:"is_grantable,omitempty"`
+}
+
+type DefaultPrivilege struct {
+	Role       string `json:"role,omitempty"`
+	Grantee    string `json:"grantee,omitempty"`
+	ObjectType string `json:"object_type,omitempty"`
+	Privilege  string `json:"privilege,omitempty"`
+}
+
+type PermissionGraph struct {
+	Roles             []Role             `json:"roles,omitempty"`
+	Grants            []Grant            `json:"grants,omitempty"`
+	DefaultPrivileges []DefaultPrivilege `json:"default_privileges,omitempty"`
+}
+
+type PermissionAdapter interface {
+	Permissions(ctx context.Context) (*PermissionGraph, error)
+}
+
+func (s *Schema) ParsePermissions(ctx context.Context) error {
+	adapter, ok := s.adapter.(PermissionAdapter)
+	if !ok {
+		return ErrNotSupported
+	}
+	permissions, err := adapter.Permissions(ctx)
+	if err != nil {
+		return err
+	}
+	s.Permissions = permissions
+	return nil
+}
+
+// RolesWithPrivilege returns every role holding any of the given privileges on a table, either directly,
+// through PUBLIC, through inherited role memberships or by being a superuser
+func (g *PermissionGraph) RolesWithPrivilege(tablename string, privileges ...string) []string {
+	wanted := make(map[string]bool, len(privileges))
+	for _, privilege := range privileges {
+		wanted[privilege] = true
+	}
+	granted := map[string]bool{}
+	for _, grant := range g.Grants {
+		if grant.Tablename == tablename && wanted[grant.Privilege] {
+			granted[grant.Grantee] = true
+		}
+	}
+
+	rolesByName := make(map[string]Role, len(g.Roles))
+	for _, role := range g.Roles {
+		rolesByName[role.Name] = role
+	}
+	var holds func(name string, visited map[string]bool) bool
+	holds = func(name string, visited map[string]bool) bool {
+		if granted[name] {
+			return true
+		}
+		if visited[name] {
+			return false
+		}
+		visited[name] = true
+		role := rolesByName[name]
+		if !role.Inherit {
+			return false
+		}
+		for _, parent := range role.MemberOf {
+			if holds(parent, visited) {
+				return true
+			}
+		}
+		return false
+	}
+
+	roles := []string{}
+	for _, role := range g.Roles {
+		if role.IsSuperuser || granted[RolePublic] || holds(role.Name, map[string]bool{}) {
+			roles = append(roles, role.Name)
+		}
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+func (g *PermissionGraph) RolesCanRead(tablename string) []string {
+	return g.RolesWithPrivilege(tablename, "SELECT")
+}
+
+func (g *PermissionGraph) RolesCanWrite(tablename string) []string {
+	return g.RolesWithPrivilege(tablename, "INSERT", "UPDATE", "DELETE", "TRUNCATE")
+}
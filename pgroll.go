@@ -0,0 +1,250 @@
+package inverseschema
+
+import (
+	"encoding/json"
+	"io"
+)
+
+type pgrollMigration struct {
+	Name       string            `json:"name,omitempty"`
+	Operations []pgrollOperation `json:"operations"`
+}
+
+// pgrollOperation holds a single pgroll operation, the field set names it
+type pgrollOperation struct {
+	CreateTable  *pgrollCreateTable  `json:"create_table,omitempty"`
+	DropTable    *pgrollDropTable    `json:"drop_table,omitempty"`
+	RenameTable  *pgrollRenameTable  `json:"rename_table,omitempty"`
+	AddColumn    *pgrollAddColumn    `json:"add_column,omitempty"`
+	DropColumn   *pgrollDropColumn   `json:"drop_column,omitempty"`
+	RenameColumn *pgrollRenameColumn `json:"rename_column,omitempty"`
+	AlterColumn  *pgrollAlterColumn  `json:"alter_column,omitempty"`
+	SQL          *pgrollSQL          `json:"sql,omitempty"`
+}
+
+type pgrollReferences struct {
+	Name   string `json:"name"`
+	Table  string `json:"table"`
+	Column string `json:"column"`
+}
+
+type pgrollIdentity struct {
+	UserSpecifiedValues string `json:"user_specified_values,omitempty"`
+}
+
+type pgrollGenerated struct {
+	Expression string          `json:"expression,omitempty"`
+	Identity   *pgrollIdentity `json:"identity,omitempty"`
+}
+
+type pgrollColumn struct {
+	Name       string            `json:"name"`
+	Type       string            `json:"type"`
+	Nullable   bool              `json:"nullable"`
+	Pk         bool              `json:"pk,omitempty"`
+	Unique     bool              `json:"unique,omitempty"`
+	Default    *string           `json:"default,omitempty"`
+	References *pgrollReferences `json:"references,omitempty"`
+	Generated  *pgrollGenerated  `json:"generated,omitempty"`
+	Comment    *string           `json:"comment,omitempty"`
+}
+
+type pgrollCreateTable struct {
+	Name    string         `json:"name"`
+	Columns []pgrollColumn `json:"columns"`
+}
+
+type pgrollDropTable struct {
+	Name string `json:"name"`
+}
+
+type pgrollRenameTable struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type pgrollAddColumn struct {
+	Table  string       `json:"table"`
+	Column pgrollColumn `json:"column"`
+}
+
+type pgrollDropColumn struct {
+	Table  string `json:"table"`
+	Column string `json:"column"`
+}
+
+type pgrollRenameColumn struct {
+	Table string `json:"table"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+type pgrollAlterColumn struct {
+	Table    string  `json:"table"`
+	Column   string  `json:"column"`
+	Type     string  `json:"type,omitempty"`
+	Nullable *bool   `json:"nullable,omitempty"`
+	Default  *string `json:"default,omitempty"`
+	Up       string  `json:"up,omitempty"`
+	Down     string  `json:"down,omitempty"`
+}
+
+type pgrollSQL struct {
+	Up   string `json:"up"`
+	Down string `json:"down,omitempty"`
+}
+
+// pgrollColumnOf translates a column, the single column primary key, unique and foreign key constraints listed in
+// inline are declared on it
+func pgrollColumnOf(col Column, inline map[string]tableConstraint) pgrollColumn {
+	column := pgrollColumn{Name: col.Name, Type: columnTypeSQL(col), Nullable: col.IsNullable}
+	switch {
+	case col.IsIdentity:
+		generation := col.IdentityGeneration
+		if len(generation) == 0 {
+			generation = "BY DEFAULT"
+		}
+		column.Generated = &pgrollGenerated{Identity: &pgrollIdentity{UserSpecifiedValues: generation}}
+	case col.IsGenerated:
+		column.Generated = &pgrollGenerated{Expression: col.GenerationExpression}
+	case col.HasDefault:
+		value := col.Default
+		column.Default = &value
+	}
+	if len(col.Comments) > 0 {
+		comment := col.Comments
+		column.Comment = &comment
+	}
+	for _, c := range col.Constraints {
+		constraint, ok := inline[c.Name]
+		if !ok {
+			continue
+		}
+		switch constraint.typ {
+		case ConstraintTypePrimaryKey:
+			column.Pk = true
+		case ConstraintTypeUnique:
+			column.Unique = true
+		case ConstraintTypeForeignKey:
+			column.References = &pgrollReferences{Name: constraint.name, Table: constraint.foreignTablename, Column: constraint.foreignColumns[0]}
+		}
+	}
+	return column
+}
+
+// pgrollInline splits the constraints of a new table between the ones pgroll declares on a column (primary keys,
+// single column unique and foreign keys) and the statements adding the others. Foreign keys are left out when they
+// are added once the tables of a cycle exist
+func pgrollInline(table Table, withoutForeignKeys bool) (map[string]tableConstraint, []string) {
+	inline := map[string]tableConstraint{}
+	statements := []string{}
+	for _, c := range tableConstraints(table) {
+		switch {
+		case c.typ == ConstraintTypeForeignKey && withoutForeignKeys:
+		case c.typ == ConstraintTypePrimaryKey, len(c.columns) == 1 && (c.typ == ConstraintTypeUnique || c.typ == ConstraintTypeForeignKey):
+			inline[c.name] = c
+		case len(constraintDefinitionSQL(c)) > 0:
+			statements = append(statements, addConstraintSQL(table.Name, c))
+		}
+	}
+	return inline, statements
+}
+
+// WritePgroll renders the changes as a pgroll migration named name, in the order of MigrationSQL. Tables and columns
+// are created, renamed, altered and dropped with pgroll operations, which keep the previous version of the schema
+// available during the rollout, the changes pgroll has no operation for (enums, sequences and types, references,
+// dropped defaults and multi column constraints) are carried by sql operations. Type changes cast the column both
+// ways and new NOT NULL constraints fill nulls with the default of the column, the up and down expressions may have
+// to be edited when that does not fit the data
+func (c *ChangeSet) WritePgroll(w io.Writer, name string, previous *Schema, current *Schema) error {
+	migration := pgrollMigration{Name: name, Operations: []pgrollOperation{}}
+	m := newMigrationSchemas(c.Changes, previous, current)
+	statements := c.MigrationSQL(previous, current)
+	followed := map[Change]bool{}
+	for i := 1; i < len(statements); i++ {
+		if statements[i].Change == statements[i-1].Change {
+			followed[statements[i].Change] = true
+		}
+	}
+	seen := map[Change]bool{}
+	for _, statement := range statements {
+		change := statement.Change
+		op := pgrollOperation{}
+		if seen[change] {
+			// a follow-up statement, adding the constraints of new tables and columns
+			op.SQL = &pgrollSQL{Up: statement.Up, Down: statement.Down}
+			migration.Operations = append(migration.Operations, op)
+			continue
+		}
+		seen[change] = true
+		from, to := m.columns(change)
+		expression := quoteIdent(change.Columnname)
+		switch change.Kind {
+		case ChangeColumnAdded, ChangeColumnWidened, ChangeColumnNarrowed, ChangeColumnTypeChanged, ChangeColumnDefault:
+			// the sequences and types the column uses
+			if prerequisites, _ := m.prerequisites([]Table{{Columns: []Column{to}}}); len(prerequisites) > 0 {
+				migration.Operations = append(migration.Operations, pgrollOperation{SQL: &pgrollSQL{Up: joinStatements(prerequisites)}})
+			}
+		}
+		switch change.Kind {
+		case ChangeTableAdded:
+			table, _ := current.TableByName(change.Tablename)
+			inline, constraints := pgrollInline(*table, followed[change])
+			create := &pgrollCreateTable{Name: table.Name, Columns: []pgrollColumn{}}
+			for _, col := range table.Columns {
+				if !col.IsVirtual {
+					create.Columns = append(create.Columns, pgrollColumnOf(col, inline))
+				}
+			}
+			if prerequisites, _ := m.prerequisites([]Table{*table}); len(prerequisites) > 0 {
+				migration.Operations = append(migration.Operations, pgrollOperation{SQL: &pgrollSQL{Up: joinStatements(prerequisites)}})
+			}
+			op.CreateTable = create
+			migration.Operations = append(migration.Operations, op)
+			for _, constraint := range constraints {
+				migration.Operations = append(migration.Operations, pgrollOperation{SQL: &pgrollSQL{Up: constraint}})
+			}
+			continue
+		case ChangeTableRemoved:
+			op.DropTable = &pgrollDropTable{Name: change.Tablename}
+		case ChangeTableRenamed:
+			op.RenameTable = &pgrollRenameTable{From: change.From, To: change.Tablename}
+		case ChangeColumnRenamed:
+			op.RenameColumn = &pgrollRenameColumn{Table: change.Tablename, From: change.From, To: change.Columnname}
+		case ChangeColumnAdded:
+			// the constraints of the column are added by the follow-up statement
+			op.AddColumn = &pgrollAddColumn{Table: change.Tablename, Column: pgrollColumnOf(to, nil)}
+		case ChangeColumnRemoved:
+			op.DropColumn = &pgrollDropColumn{Table: change.Tablename, Column: change.Columnname}
+		case ChangeColumnWidened, ChangeColumnNarrowed, ChangeColumnTypeChanged:
+			op.AlterColumn = &pgrollAlterColumn{
+				Table:  change.Tablename,
+				Column: change.Columnname,
+				Type:   columnTypeSQL(to),
+				Up:     "CAST(" + expression + " AS " + columnTypeSQL(to) + ")",
+				Down:   "CAST(" + expression + " AS " + columnTypeSQL(from) + ")",
+			}
+		case ChangeColumnNullable, ChangeColumnNotNull:
+			nullable := change.Kind == ChangeColumnNullable
+			op.AlterColumn = &pgrollAlterColumn{Table: change.Tablename, Column: change.Columnname, Nullable: &nullable, Up: expression, Down: expression}
+			if !nullable && to.HasDefault {
+				op.AlterColumn.Up = "COALESCE(" + expression + ", " + to.Default + ")"
+			} else if nullable && from.HasDefault {
+				op.AlterColumn.Down = "COALESCE(" + expression + ", " + from.Default + ")"
+			}
+		case ChangeColumnDefault:
+			if !to.HasDefault {
+				op.SQL = &pgrollSQL{Up: statement.Up, Down: statement.Down}
+				break
+			}
+			value := to.Default
+			op.AlterColumn = &pgrollAlterColumn{Table: change.Tablename, Column: change.Columnname, Default: &value}
+		default:
+			op.SQL = &pgrollSQL{Up: statement.Up, Down: statement.Down}
+		}
+		migration.Operations = append(migration.Operations, op)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(migration)
+}
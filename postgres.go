@@ -4,16 +4,61 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"log/slog"
 	"sort"
+	"strings"
+	"time"
 )
 
-func NewPostgresAdapter(db *sql.DB, schemaname string) *PostgresAdapter {
-	return &PostgresAdapter{db: db, schemaname: schemaname}
+func NewPostgresAdapter(db *sql.DB, schemaname string, opts ...PostgresOption) *PostgresAdapter {
+	a := &PostgresAdapter{db: db, schemaname: schemaname}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
 }
 
 type PostgresAdapter struct {
-	db         *sql.DB
-	schemaname string
+	db               *sql.DB
+	schemaname       string
+	columnHooks      []ColumnHook
+	tableHooks       []TableHook
+	virtualColumns   map[string][]Column
+	withStats        bool
+	jsonProfileRows  int
+	jsonProfileDepth int
+	maxTables        int
+	maxDuration      time.Duration
+	truncate         bool
+	logger           *slog.Logger
+	limiter          *tokenBucket
+	systemColumns    bool
+	// withoutExtensionObjects leaves out the objects owned by extensions
+	withoutExtensionObjects bool
+}
+
+type PostgresOption func(a *PostgresAdapter)
+
+func WithColumnHook(hook ColumnHook) PostgresOption {
+	return func(a *PostgresAdapter) {
+		a.columnHooks = append(a.columnHooks, hook)
+	}
+}
+
+func WithTableHook(hook TableHook) PostgresOption {
+	return func(a *PostgresAdapter) {
+		a.tableHooks = append(a.tableHooks, hook)
+	}
+}
+
+// WithVirtualColumns declares columns computed in application code which are merged into the introspected table
+func WithVirtualColumns(tablename string, cols ...Column) PostgresOption {
+	return func(a *PostgresAdapter) {
+		if a.virtualColumns == nil {
+			a.virtualColumns = map[string][]Column{}
+		}
+		a.virtualColumns[tablename] = append(a.virtualColumns[tablename], cols...)
+	}
 }
 
 var postgresDatatypemap = map[string]Datatype{
@@ -27,80 +72,302 @@ var postgresDatatypemap = map[string]Datatype{
 	"character varying":           DatatypeVarchar,
 	"jsonb":                       DatatypeJsonb,
 	"uuid":                        DatatypeUuid,
+	"bytea":                       DatatypeBytea,
 	"date":                        DatatypeDate,
 	"timestamp without time zone": DatatypeTimestamp,
 	"timestamp with time zone":    DatatypeTimestampz,
 }
 
+var postgresPartitionStrategies = map[string]string{
+	"r": "range",
+	"l": "list",
+	"h": "hash",
+}
+
+var postgresDatatypeNames = func() map[Datatype]string {
+	names := make(map[Datatype]string, len(postgresDatatypemap))
+	for name, datatype := range postgresDatatypemap {
+		if datatype == DatatypeUserdefined || datatype == DatatypeArray {
+			continue
+		}
+		names[datatype] = name
+	}
+	return names
+}()
+
+// postgresColumnType renders the type of a column the way it would be declared in DDL
+func postgresColumnType(col Column) string {
+	typename := col.DatatypeRaw
+	if col.IsUserDefined && col.UserDefinedType != nil {
+		typename = col.UserDefinedType.Name
+	} else if name, ok := postgresDatatypeNames[col.Datatype]; ok {
+		typename = name
+		if col.Datatype == DatatypeVarchar && col.CharacterMaxLength > 0 {
+			typename = fmt.Sprintf("%s(%d)", name, col.CharacterMaxLength)
+		}
+	}
+	if col.IsArray {
+		typename += "[]"
+	}
+	return typename
+}
+
 func (a *PostgresAdapter) Enums(ctx context.Context) ([]Enum, error) {
 	sql := `SELECT 
 			t.typname,
 			e.enumsortorder as enum_order,
-			e.enumlabel as enum_value
+			e.enumlabel as enum_value,
+			obj_description(t.oid, 'pg_type') as enum_comment,
+			` + extensionSQL("pg_catalog.pg_type", "t.oid") + ` AS extension
 		FROM pg_type t 
 			JOIN pg_enum e on t.oid = e.enumtypid  
 			JOIN pg_catalog.pg_namespace n ON n.oid = t.typnamespace
-		WHERE n.nspname = $1`
+		WHERE n.nspname = $1
+		ORDER BY t.typname, e.enumsortorder`
 
-	rows, err := a.db.QueryContext(ctx, sql, a.schemaname)
+	rows, err := a.query(ctx, sql, a.schemaname)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 	enumsByName := map[string]Enum{}
 	var name string
-	var order int
+	// values added with BEFORE or AFTER get a fractional sort order, Order holds the position instead
+	var sortorder float64
 	var label string
+	var comments *string
+	var extension *string
 	for rows.Next() {
-		if err := rows.Scan(&name, &order, &label); err != nil {
+		if err := ctx.Err(); err != nil {
 			return nil, err
 		}
+		if err := rows.Scan(&name, &sortorder, &label, &comments, &extension); err != nil {
+			return nil, err
+		}
+		if a.skipsExtension(extension) {
+			continue
+		}
 		if enum, ok := enumsByName[name]; ok {
 			enum.Values = append(enum.Values, EnumValue{
 				Label: label,
-				Order: order,
+				Order: len(enum.Values) + 1,
 			})
 			enumsByName[name] = enum
 			continue
 		}
-		enumsByName[name] = Enum{
+		enum := Enum{
 			Name: name,
 			Values: []EnumValue{{
 				Label: label,
-				Order: order,
+				Order: 1,
 			}},
 		}
+		if comments != nil {
+			enum.Comments = *comments
+		}
+		if extension != nil {
+			enum.Extension = *extension
+		}
+		enumsByName[name] = enum
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
 	enums := make([]Enum, len(enumsByName))
 	idx := 0
 	for _, enum := range enumsByName {
+		applyEnumAnnotations(&enum, enumAnnotationsFromComment(enum.Comments))
 		enums[idx] = enum
 		idx++
 	}
+	a.log().DebugContext(ctx, "parsed enums", "enums", len(enums))
 	return enums, nil
 }
 
+var postgresTablesSQL = `SELECT
+		t.tablename,
+		t.tableowner,
+		obj_description(c.oid, 'pg_class') AS table_comment,
+		pt.partstrat,
+		pg_get_partkeydef(c.oid) AS partition_key,
+		(SELECT string_agg(COALESCE(a.attname, ''), chr(31) ORDER BY k.n)
+			FROM unnest(pt.partattrs::int2[]) WITH ORDINALITY AS k(attnum, n)
+			LEFT JOIN pg_catalog.pg_attribute a ON a.attrelid = c.oid AND a.attnum = k.attnum) AS partition_columns,
+		parent.relname AS partition_of,
+		pg_get_expr(c.relpartbound, c.oid) AS partition_bound,
+		` + extensionSQL("pg_catalog.pg_class", "c.oid") + ` AS extension
+	FROM pg_catalog.pg_tables t
+		JOIN pg_catalog.pg_namespace n ON n.nspname = t.schemaname
+		JOIN pg_catalog.pg_class c ON c.relnamespace = n.oid AND c.relname = t.tablename
+		LEFT JOIN pg_catalog.pg_partitioned_table pt ON pt.partrelid = c.oid
+		LEFT JOIN pg_catalog.pg_inherits inh ON inh.inhrelid = c.oid AND c.relispartition
+		LEFT JOIN pg_catalog.pg_class parent ON parent.oid = inh.inhparent
+	WHERE t.schemaname=$1`
+
 func (a *PostgresAdapter) Tables(ctx context.Context) ([]Table, error) {
-	rows, err := a.db.QueryContext(ctx, "SELECT tablename FROM pg_catalog.pg_tables WHERE schemaname=$1", a.schemaname)
+	started := time.Now()
+	statsByTable, err := a.tableStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// ordered so that a truncated parse keeps the same tables across runs
+	rows, err := a.query(ctx, postgresTablesSQL+" ORDER BY t.tablename", a.schemaname)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 	tables := []Table{}
+	seen := 0
 	for rows.Next() {
-		var tablename *string
-		if err := rows.Scan(&tablename); err != nil {
+		if err := ctx.Err(); err != nil {
 			return nil, err
 		}
-		table, err := a.parseTable(ctx, *tablename)
+		if seen++; a.maxTables > 0 && seen > a.maxTables {
+			return a.limitExceeded(ctx, tables, &LimitError{MaxTables: a.maxTables})
+		}
+		if a.maxDuration > 0 && time.Since(started) > a.maxDuration {
+			return a.limitExceeded(ctx, tables, &LimitError{MaxDuration: a.maxDuration})
+		}
+		table, err := a.scanTable(ctx, rows, statsByTable)
 		if err != nil {
 			return nil, err
 		}
-
-		tables = append(tables, *table)
+		if table != nil {
+			tables = append(tables, *table)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 	return tables, nil
 }
 
+// Table introspects a single table, ErrTableNotFound is returned when it does not exist or a hook skips it
+func (a *PostgresAdapter) Table(ctx context.Context, name string) (*Table, error) {
+	statsByTable, err := a.tableStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := a.query(ctx, postgresTablesSQL+" AND t.tablename=$2", a.schemaname, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var table *Table
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if table, err = a.scanTable(ctx, rows, statsByTable); err != nil {
+			return nil, err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if table == nil {
+		return nil, fmt.Errorf("%w: %s", ErrTableNotFound, name)
+	}
+	return table, nil
+}
+
+func (a *PostgresAdapter) tableStats(ctx context.Context) (map[string]*TableStats, error) {
+	if !a.withStats {
+		return nil, nil
+	}
+	return a.parseTableStats(ctx)
+}
+
+// scanTable completes a row of postgresTablesSQL into a table, nil is returned when a hook skips it
+func (a *PostgresAdapter) scanTable(ctx context.Context, rows *sql.Rows, statsByTable map[string]*TableStats) (*Table, error) {
+	tableStarted := time.Now()
+	var tablename *string
+	var owner *string
+	var comments *string
+	var partitionStrategy *string
+	var partitionKey *string
+	var partitionColumns *string
+	var partitionOf *string
+	var partitionBound *string
+	var extension *string
+	if err := rows.Scan(
+		&tablename,
+		&owner,
+		&comments,
+		&partitionStrategy,
+		&partitionKey,
+		&partitionColumns,
+		&partitionOf,
+		&partitionBound,
+		&extension,
+	); err != nil {
+		return nil, err
+	}
+	if a.skipsExtension(extension) {
+		a.log().DebugContext(ctx, "skipped extension table", "table", *tablename, "extension", *extension)
+		return nil, nil
+	}
+	table, err := a.parseTable(ctx, *tablename)
+	if err != nil {
+		return nil, err
+	}
+	if comments != nil && selects(ctx, ObjectComments) {
+		table.Comments = *comments
+	}
+	// an explicit owner or steward annotation takes precedence over the database role owning the table
+	if tagged, ok := commentTag(table.Comments, "owner"); ok && len(tagged) > 0 {
+		table.Owner = tagged
+	} else if tagged, ok := commentTag(table.Comments, "steward"); ok && len(tagged) > 0 {
+		table.Owner = tagged
+	} else if owner != nil {
+		table.Owner = *owner
+	}
+
+	if partitionStrategy != nil {
+		table.Partitioning = &Partitioning{
+			Strategy:   postgresPartitionStrategies[*partitionStrategy],
+			Definition: *partitionKey,
+		}
+		// expression keys have no column and are left empty
+		if partitionColumns != nil {
+			table.Partitioning.Columns = strings.Split(*partitionColumns, indexColumnSeparator)
+		}
+	}
+	if partitionOf != nil {
+		table.PartitionOf = *partitionOf
+	}
+	if partitionBound != nil {
+		table.PartitionBound = *partitionBound
+	}
+	if extension != nil {
+		table.Extension = *extension
+	}
+
+	table.Stats = statsByTable[table.Name]
+	if a.jsonProfileRows > 0 {
+		if err := a.profileJSONColumns(ctx, table); err != nil {
+			return nil, err
+		}
+	}
+	mergeVirtualColumns(table, a.virtualColumns[table.Name])
+	columns := len(table.Columns)
+	keep, err := applyHooks(table, a.columnHooks, a.tableHooks)
+	if err != nil {
+		return nil, err
+	}
+	if !keep {
+		a.log().DebugContext(ctx, "skipped table", "table", table.Name)
+		return nil, nil
+	}
+	if skipped := columns - len(table.Columns); skipped > 0 {
+		a.log().DebugContext(ctx, "skipped columns", "table", table.Name, "columns", skipped)
+	}
+	a.log().DebugContext(ctx, "parsed table", "table", table.Name, "columns", len(table.Columns), "duration", time.Since(tableStarted))
+	return table, nil
+}
+
 func (a *PostgresAdapter) parseTable(ctx context.Context, tablename string) (*Table, error) {
 	table := &Table{
 		Name:    tablename,
@@ -116,14 +383,47 @@ func (a *PostgresAdapter) parseTable(ctx context.Context, tablename string) (*Ta
 		table.ColumnsByName[col.Name] = col
 	}
 
-	constraints, err := a.parseTableConstraints(ctx, tablename)
-	if err != nil {
-		return table, err
+	if selects(ctx, ObjectConstraints) {
+		constraints, err := a.parseTableConstraints(ctx, tablename)
+		if err != nil {
+			return table, err
+		}
+		checks, err := a.parseTableChecks(ctx, tablename)
+		if err != nil {
+			return table, err
+		}
+		for _, check := range checks {
+			if len(check.Columnname) == 0 {
+				table.Checks = append(table.Checks, check)
+			} else {
+				constraints = append(constraints, check)
+			}
+		}
+
+		if err := a.refrenceConstraints(ctx, table, constraints); err != nil {
+			return nil, err
+		}
+	}
+
+	if selects(ctx, ObjectIndexes) {
+		if table.Indexes, err = a.parseTableIndexes(ctx, tablename); err != nil {
+			return nil, err
+		}
+		triggers, err := a.parseTextSearchTriggers(ctx, tablename)
+		if err != nil {
+			return nil, err
+		}
+		applyTextSearch(table, triggers)
 	}
 
-	if err := a.refrenceConstraints(ctx, table, constraints); err != nil {
+	if table.DroppedColumns, err = a.parseDroppedColumns(ctx, tablename); err != nil {
 		return nil, err
 	}
+	if a.systemColumns {
+		if table.SystemColumns, err = a.parseSystemColumns(ctx, tablename); err != nil {
+			return nil, err
+		}
+	}
 
 	for _, col := range table.ColumnsByName {
 		table.Columns = append(table.Columns, col)
@@ -131,6 +431,11 @@ func (a *PostgresAdapter) parseTable(ctx context.Context, tablename string) (*Ta
 	sort.Slice(table.Columns, func(i, j int) bool {
 		return table.Columns[i].OrdinalPosition < table.Columns[j].OrdinalPosition
 	})
+	// information_schema reports the attribute number, positions are made contiguous over the dropped columns
+	for i := range table.Columns {
+		table.Columns[i].OrdinalPosition = i + 1
+		table.ColumnsByName[table.Columns[i].Name] = table.Columns[i]
+	}
 	return table, nil
 }
 
@@ -156,6 +461,8 @@ func (a *PostgresAdapter) refrenceConstraints(ctx context.Context, table *Table,
 		case ConstraintTypeUnique:
 			// should we mark as unique if there is more than one column for this index?
 			col.IsUnique = true
+		case ConstraintTypeCheck:
+			col.Restriction = mergeRestriction(col.Restriction, parseCheckRestriction(c.Definition, col.Name))
 		}
 
 		table.ColumnsByName[c.Columnname] = col
@@ -166,6 +473,15 @@ func (a *PostgresAdapter) refrenceConstraints(ctx context.Context, table *Table,
 }
 
 func (a *PostgresAdapter) parseTableColumns(ctx context.Context, tablename string) ([]Column, error) {
+	commentColumns := `NULL::text AS column_comment, NULL::text AS security_label`
+	if selects(ctx, ObjectComments) {
+		commentColumns = `(SELECT pg_catalog.col_description(oid,c.ordinal_position::int) from pg_catalog.pg_class pc where pc.relname=c.table_name) as column_comment,
+		(SELECT sl.label FROM pg_catalog.pg_seclabel sl
+			WHERE sl.classoid = 'pg_catalog.pg_class'::regclass
+			AND sl.objoid = (quote_ident(c.table_schema) || '.' || quote_ident(c.table_name))::regclass
+			AND sl.objsubid = c.ordinal_position
+			ORDER BY sl.provider LIMIT 1) AS security_label`
+	}
 	sql := `SELECT 
 		c.ordinal_position,
 		c.column_name,
@@ -181,18 +497,50 @@ func (a *PostgresAdapter) parseTableColumns(ctx context.Context, tablename strin
 		c.udt_catalog,
 		c.udt_schema,
 		c.udt_name,
-		(SELECT pg_catalog.col_description(oid,c.ordinal_position::int) from pg_catalog.pg_class pc where pc.relname=c.table_name) as column_comment
+		c.is_identity,
+		c.identity_generation,
+		c.is_generated,
+		c.generation_expression,
+		att.attinhcount > 0 AND NOT att.attislocal AS is_inherited,
+		EXISTS (SELECT 1 FROM pg_catalog.pg_inherits i
+			JOIN pg_catalog.pg_attribute pa ON pa.attrelid = i.inhparent AND pa.attname = att.attname
+			JOIN pg_catalog.pg_attrdef pd ON pd.adrelid = pa.attrelid AND pd.adnum = pa.attnum
+			JOIN pg_catalog.pg_attrdef cd ON cd.adrelid = att.attrelid AND cd.adnum = att.attnum
+			WHERE i.inhrelid = att.attrelid AND pg_get_expr(pd.adbin, pd.adrelid) = pg_get_expr(cd.adbin, cd.adrelid)) AS default_inherited,
+		ty.typtype,
+		ty.typcategory,
+		CASE WHEN ty.typtype = 'd' THEN format_type(ty.typbasetype, NULL) END AS base_type,
+		format_type(att.atttypid, att.atttypmod) AS formatted_type,
+		CASE WHEN ty.typnamespace <> (SELECT relnamespace FROM pg_catalog.pg_class WHERE oid = att.attrelid) THEN NULL
+		WHEN ty.typtype = 'd' THEN 'CREATE DOMAIN ' || quote_ident(ty.typname) || ' AS ' || format_type(ty.typbasetype, ty.typtypmod)
+			|| CASE WHEN ty.typnotnull THEN ' NOT NULL' ELSE '' END
+			|| COALESCE(' DEFAULT ' || ty.typdefault, '')
+			|| COALESCE((SELECT string_agg(' CONSTRAINT ' || quote_ident(dc.conname) || ' ' || pg_get_constraintdef(dc.oid), '' ORDER BY dc.conname)
+				FROM pg_catalog.pg_constraint dc WHERE dc.contypid = ty.oid), '') || ';'
+		WHEN ty.typtype = 'c' AND (SELECT relkind FROM pg_catalog.pg_class WHERE oid = ty.typrelid) = 'c' THEN 'CREATE TYPE ' || quote_ident(ty.typname) || ' AS ('
+			|| (SELECT string_agg(quote_ident(ca.attname) || ' ' || format_type(ca.atttypid, ca.atttypmod), ', ' ORDER BY ca.attnum)
+				FROM pg_catalog.pg_attribute ca WHERE ca.attrelid = ty.typrelid AND ca.attnum > 0 AND NOT ca.attisdropped) || ');'
+		END AS type_definition,
+		` + commentColumns + `
 		FROM information_schema.columns c
+		JOIN pg_catalog.pg_attribute att ON att.attrelid = (quote_ident(c.table_schema) || '.' || quote_ident(c.table_name))::regclass
+			AND att.attnum = c.ordinal_position
+		JOIN pg_catalog.pg_type aty ON aty.oid = att.atttypid
+		JOIN pg_catalog.pg_type ty ON ty.oid = CASE WHEN aty.typcategory = 'A' AND aty.typelem <> 0 THEN aty.typelem ELSE aty.oid END
 		LEFT JOIN information_schema.element_types e ON ((c.table_catalog, c.table_schema, c.table_name, 'TABLE', c.dtd_identifier)
 		= (e.object_catalog, e.object_schema, e.object_name, e.object_type, e.collection_type_identifier))
 		WHERE c.table_schema=$1 AND c.table_name=$2`
 
-	rows, err := a.db.QueryContext(ctx, sql, a.schemaname, tablename)
+	rows, err := a.query(ctx, sql, a.schemaname, tablename)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 	cols := []Column{}
 	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		var ordinalPosition int
 		var columnName string
 		var columnDefault *string
@@ -207,7 +555,19 @@ func (a *PostgresAdapter) parseTableColumns(ctx context.Context, tablename strin
 		var udtCatalog *string
 		var udtSchema *string
 		var udtName *string
+		var isIdentity *string
+		var identityGeneration *string
+		var isGenerated *string
+		var generationExpression *string
+		var isInherited bool
+		var defaultInherited bool
+		var typtype *string
+		var typcategory *string
+		var baseType *string
+		var formattedType string
+		var typeDefinition *string
 		var comments *string
+		var securityLabel *string
 
 		if err := rows.Scan(
 			&ordinalPosition,
@@ -224,20 +584,44 @@ func (a *PostgresAdapter) parseTableColumns(ctx context.Context, tablename strin
 			&udtCatalog,
 			&udtSchema,
 			&udtName,
+			&isIdentity,
+			&identityGeneration,
+			&isGenerated,
+			&generationExpression,
+			&isInherited,
+			&defaultInherited,
+			&typtype,
+			&typcategory,
+			&baseType,
+			&formattedType,
+			&typeDefinition,
 			&comments,
+			&securityLabel,
 		); err != nil {
 			return nil, err
 		}
 
 		col := Column{
 			OrdinalPosition: ordinalPosition,
+			AttNum:          ordinalPosition,
 			Name:            columnName,
 			DatatypeRaw:     datatypeRaw,
+			IsInherited:     isInherited,
+			// types of the parsed schema are qualified when it is not on the search_path
+			FormattedType: strings.TrimPrefix(strings.TrimPrefix(formattedType, quoteIdent(a.schemaname)+"."), a.schemaname+"."),
+		}
+		if typeDefinition != nil {
+			col.TypeDefinition = *typeDefinition
 		}
 
 		if comments != nil {
 			col.Comments = *comments
 		}
+		if tagged, ok := commentTag(col.Comments, "security"); ok && len(tagged) > 0 {
+			col.SecurityLabel = tagged
+		} else if securityLabel != nil {
+			col.SecurityLabel = *securityLabel
+		}
 		datatype, ok := postgresDatatypemap[datatypeRaw]
 		if ok {
 			col.Datatype = datatype
@@ -254,6 +638,19 @@ func (a *PostgresAdapter) parseTableColumns(ctx context.Context, tablename strin
 		if columnDefault != nil && len(*columnDefault) > 0 {
 			col.HasDefault = true
 			col.Default = *columnDefault
+			col.DefaultInherited = defaultInherited
+		}
+		if isIdentity != nil && *isIdentity == "YES" {
+			col.IsIdentity = true
+			if identityGeneration != nil {
+				col.IdentityGeneration = *identityGeneration
+			}
+		}
+		if isGenerated != nil && *isGenerated == "ALWAYS" {
+			col.IsGenerated = true
+			if generationExpression != nil {
+				col.GenerationExpression = *generationExpression
+			}
 		}
 		if col.Datatype == DatatypeUserdefined {
 			col.IsUserDefined = true
@@ -279,56 +676,72 @@ func (a *PostgresAdapter) parseTableColumns(ctx context.Context, tablename strin
 				}
 			}
 		}
+		classifyType(&col, typtype, typcategory, baseType)
+		detectEncryption(&col)
 		cols = append(cols, col)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 	return cols, nil
 }
 
 func (a *PostgresAdapter) parseTableConstraints(ctx context.Context, tablename string) ([]Constraint, error) {
+	// conkey and confkey are unnested together so that the columns of composite foreign keys stay paired
 	sql := `SELECT
-		tc.constraint_name, tc.constraint_type, kcu.column_name, 
-		ccu.table_name AS foreign_table_name,
-		ccu.column_name AS foreign_column_name 
-	FROM information_schema.table_constraints AS tc 
-		LEFT JOIN information_schema.key_column_usage AS kcu ON tc.constraint_name = kcu.constraint_name
-		LEFT JOIN information_schema.constraint_column_usage AS ccu ON ccu.constraint_name = tc.constraint_name
-	WHERE tc.table_schema=$1 AND tc.table_name=$2 AND tc.constraint_type IN ('PRIMARY KEY', 'FOREIGN KEY', 'UNIQUE')`
-
-	rows, err := a.db.QueryContext(ctx, sql, a.schemaname, tablename)
+		co.conname, co.contype, att.attname,
+		fc.relname AS foreign_table_name,
+		fatt.attname AS foreign_column_name,
+		k.n AS position,
+		co.condeferrable,
+		co.conparentid <> 0 OR NOT co.conislocal AS is_inherited
+	FROM pg_catalog.pg_constraint co
+		JOIN pg_catalog.pg_class c ON c.oid = co.conrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		CROSS JOIN LATERAL unnest(co.conkey, co.confkey) WITH ORDINALITY AS k(attnum, fattnum, n)
+		JOIN pg_catalog.pg_attribute att ON att.attrelid = c.oid AND att.attnum = k.attnum
+		LEFT JOIN pg_catalog.pg_class fc ON fc.oid = co.confrelid
+		LEFT JOIN pg_catalog.pg_attribute fatt ON fatt.attrelid = co.confrelid AND fatt.attnum = k.fattnum
+	WHERE n.nspname=$1 AND c.relname=$2 AND co.contype IN ('p', 'f', 'u')
+	ORDER BY co.conname, k.n`
+
+	rows, err := a.query(ctx, sql, a.schemaname, tablename)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 	constraints := []Constraint{}
 	for rows.Next() {
-		var constraintname string
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		var constrainttype string
-		var columnname *string
 		var foreignTablename *string
 		var foreignColumnname *string
+		c := Constraint{Tablename: tablename}
 
 		if err := rows.Scan(
-			&constraintname,
+			&c.Name,
 			&constrainttype,
-			&columnname,
+			&c.Columnname,
 			&foreignTablename,
 			&foreignColumnname,
+			&c.Position,
+			&c.IsDeferrable,
+			&c.IsInherited,
 		); err != nil {
 			return nil, err
 		}
 
-		c := Constraint{
-			Name:              constraintname,
-			Tablename:         tablename,
-			Columnname:        *columnname,
-			ForeignTablename:  *foreignTablename,
-			ForeignColumnname: *foreignColumnname,
-		}
 		switch constrainttype {
-		case "PRIMARY KEY":
+		case "p":
 			c.Type = ConstraintTypePrimaryKey
-		case "FOREIGN KEY":
+		case "f":
 			c.Type = ConstraintTypeForeignKey
-		case "UNIQUE":
+			if foreignTablename != nil && foreignColumnname != nil {
+				c.ForeignTablename, c.ForeignColumnname = *foreignTablename, *foreignColumnname
+			}
+		case "u":
 			c.Type = ConstraintTypeUnique
 		default:
 			return nil, fmt.Errorf("unsupported constraint type: %s", constrainttype)
@@ -336,5 +749,38 @@ func (a *PostgresAdapter) parseTableConstraints(ctx context.Context, tablename s
 		constraints = append(constraints, c)
 
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 	return constraints, nil
 }
+
+func (a *PostgresAdapter) parseDroppedColumns(ctx context.Context, tablename string) ([]int, error) {
+	sql := `SELECT att.attnum
+		FROM pg_catalog.pg_attribute att
+			JOIN pg_catalog.pg_class c ON c.oid = att.attrelid
+			JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname=$1 AND c.relname=$2 AND att.attnum > 0 AND att.attisdropped
+		ORDER BY att.attnum`
+
+	rows, err := a.query(ctx, sql, a.schemaname, tablename)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var dropped []int
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var attnum int
+		if err := rows.Scan(&attnum); err != nil {
+			return nil, err
+		}
+		dropped = append(dropped, attnum)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return dropped, nil
+}
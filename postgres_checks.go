@@ -0,0 +1,42 @@
+package inverseschema
+
+import (
+	"context"
+)
+
+// parseTableChecks reads the CHECK constraints of a table, a constraint covering several columns yields one
+// Constraint per column and a constraint referencing no column one Constraint without Columnname
+func (a *PostgresAdapter) parseTableChecks(ctx context.Context, tablename string) ([]Constraint, error) {
+	sql := `SELECT co.conname, pg_get_constraintdef(co.oid), att.attname, NOT co.conislocal
+		FROM pg_catalog.pg_constraint co
+			JOIN pg_catalog.pg_class c ON c.oid = co.conrelid
+			JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+			LEFT JOIN pg_catalog.pg_attribute att ON att.attrelid = c.oid AND att.attnum = ANY (co.conkey)
+		WHERE co.contype = 'c' AND n.nspname=$1 AND c.relname=$2
+		ORDER BY co.conname, att.attnum`
+
+	rows, err := a.query(ctx, sql, a.schemaname, tablename)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	checks := []Constraint{}
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		c := Constraint{Type: ConstraintTypeCheck, Tablename: tablename}
+		var columnname *string
+		if err := rows.Scan(&c.Name, &c.Definition, &columnname, &c.IsInherited); err != nil {
+			return nil, err
+		}
+		if columnname != nil {
+			c.Columnname = *columnname
+		}
+		checks = append(checks, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return checks, nil
+}
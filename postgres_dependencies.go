@@ -0,0 +1,63 @@
+package inverseschema
+
+import (
+	"context"
+)
+
+// ColumnDependents lists the objects pg_depend records as depending on a column, functions are only listed when
+// postgres tracks their dependencies (SQL functions with a BEGIN ATOMIC body), indexes are left to Schema.Impact
+func (a *PostgresAdapter) ColumnDependents(ctx context.Context, tablename string, columnname string) ([]Dependent, error) {
+	sql := `SELECT DISTINCT kind, name FROM (
+			SELECT
+				CASE d.classid
+					WHEN 'pg_catalog.pg_rewrite'::regclass THEN 'view'
+					WHEN 'pg_catalog.pg_policy'::regclass THEN 'policy'
+					WHEN 'pg_catalog.pg_trigger'::regclass THEN 'trigger'
+					WHEN 'pg_catalog.pg_attrdef'::regclass THEN 'generated'
+					WHEN 'pg_catalog.pg_proc'::regclass THEN 'function'
+					WHEN 'pg_catalog.pg_constraint'::regclass THEN 'constraint'
+				END AS kind,
+				CASE d.classid
+					WHEN 'pg_catalog.pg_rewrite'::regclass THEN (SELECT r.ev_class::regclass::text FROM pg_catalog.pg_rewrite r WHERE r.oid = d.objid)
+					WHEN 'pg_catalog.pg_policy'::regclass THEN (SELECT p.polname::text FROM pg_catalog.pg_policy p WHERE p.oid = d.objid)
+					WHEN 'pg_catalog.pg_trigger'::regclass THEN (SELECT t.tgname::text FROM pg_catalog.pg_trigger t WHERE t.oid = d.objid)
+					WHEN 'pg_catalog.pg_attrdef'::regclass THEN (
+						SELECT da.attname::text FROM pg_catalog.pg_attrdef ad
+							JOIN pg_catalog.pg_attribute da ON da.attrelid = ad.adrelid AND da.attnum = ad.adnum
+						WHERE ad.oid = d.objid AND da.attnum <> a.attnum)
+					WHEN 'pg_catalog.pg_proc'::regclass THEN (SELECT p.oid::regprocedure::text FROM pg_catalog.pg_proc p WHERE p.oid = d.objid)
+					WHEN 'pg_catalog.pg_constraint'::regclass THEN (SELECT co.conname::text FROM pg_catalog.pg_constraint co WHERE co.oid = d.objid)
+				END AS name
+			FROM pg_catalog.pg_depend d
+				JOIN pg_catalog.pg_class c ON c.oid = d.refobjid
+				JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+				JOIN pg_catalog.pg_attribute a ON a.attrelid = c.oid AND a.attnum = d.refobjsubid
+			WHERE d.refclassid = 'pg_catalog.pg_class'::regclass
+				AND n.nspname = $1
+				AND c.relname = $2
+				AND a.attname = $3
+		) dependents
+		WHERE kind IS NOT NULL AND name IS NOT NULL AND NOT (kind = 'view' AND name = $2)
+		ORDER BY kind, name`
+
+	rows, err := a.query(ctx, sql, a.schemaname, tablename, columnname)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	dependents := []Dependent{}
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		dependent := Dependent{}
+		if err := rows.Scan(&dependent.Kind, &dependent.Name); err != nil {
+			return nil, err
+		}
+		dependents = append(dependents, dependent)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return dependents, nil
+}
@@ -0,0 +1,22 @@
+package inverseschema
+
+// WithoutExtensionObjects leaves out the tables, views and enums created by extensions (PostGIS spatial_ref_sys,
+// pg_stat_statements views, ...), they are otherwise kept and tagged with their extension
+func WithoutExtensionObjects() PostgresOption {
+	return func(a *PostgresAdapter) {
+		a.withoutExtensionObjects = true
+	}
+}
+
+// extensionSQL renders a subquery returning the extension owning an object of the given catalog
+func extensionSQL(catalog string, oid string) string {
+	return `(SELECT x.extname FROM pg_catalog.pg_depend xd
+			JOIN pg_catalog.pg_extension x ON x.oid = xd.refobjid
+			WHERE xd.classid = '` + catalog + `'::regclass AND xd.objid = ` + oid + `
+			AND xd.refclassid = 'pg_catalog.pg_extension'::regclass AND xd.deptype = 'e' LIMIT 1)`
+}
+
+// skipsExtension tells whether an object owned by extension is left out
+func (a *PostgresAdapter) skipsExtension(extension *string) bool {
+	return a.withoutExtensionObjects && extension != nil
+}
@@ -0,0 +1,63 @@
+package inverseschema
+
+import (
+	"context"
+	"strings"
+)
+
+// indexColumnSeparator joins key columns within the query, it cannot appear in an identifier
+const indexColumnSeparator = "\x1f"
+
+func (a *PostgresAdapter) parseTableIndexes(ctx context.Context, tablename string) ([]Index, error) {
+	sql := `SELECT
+			i.relname,
+			ix.indisunique,
+			ix.indisprimary,
+			am.amname,
+			pg_get_indexdef(ix.indexrelid),
+			COALESCE(pg_get_expr(ix.indpred, ix.indrelid), ''),
+			pg_relation_size(ix.indexrelid),
+			(SELECT string_agg(pg_get_indexdef(ix.indexrelid, k.n, true), chr(31) ORDER BY k.n)
+				FROM generate_series(1, ix.indnkeyatts) AS k(n)) AS key_columns
+		FROM pg_catalog.pg_index ix
+			JOIN pg_catalog.pg_class i ON i.oid = ix.indexrelid
+			JOIN pg_catalog.pg_class t ON t.oid = ix.indrelid
+			JOIN pg_catalog.pg_namespace n ON n.oid = t.relnamespace
+			JOIN pg_catalog.pg_am am ON am.oid = i.relam
+		WHERE n.nspname=$1 AND t.relname=$2
+		ORDER BY i.relname`
+
+	rows, err := a.query(ctx, sql, a.schemaname, tablename)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	indexes := []Index{}
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var keyColumns *string
+		index := Index{}
+		if err := rows.Scan(
+			&index.Name,
+			&index.IsUnique,
+			&index.IsPrimary,
+			&index.Method,
+			&index.Definition,
+			&index.Predicate,
+			&index.SizeBytes,
+			&keyColumns,
+		); err != nil {
+			return nil, err
+		}
+		if keyColumns != nil {
+			index.Columns = strings.Split(*keyColumns, indexColumnSeparator)
+		}
+		indexes = append(indexes, index)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return indexes, nil
+}
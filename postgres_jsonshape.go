@@ -0,0 +1,70 @@
+package inverseschema
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// WithJSONProfile samples up to rows values of every json and jsonb column and stores the structure inferred from
+// them, up to maxDepth levels of nesting, on Column.InferredShape. Sampling reads table data and is off by default
+func WithJSONProfile(rows int, maxDepth int) PostgresOption {
+	return func(a *PostgresAdapter) {
+		a.jsonProfileRows = rows
+		a.jsonProfileDepth = maxDepth
+	}
+}
+
+func (a *PostgresAdapter) profileJSONColumns(ctx context.Context, table *Table) error {
+	names := []string{}
+	selects := []string{}
+	for _, col := range table.Columns {
+		if col.IsArray || (col.Datatype != DatatypeJsonb && col.Datatype != DatatypeJson) {
+			continue
+		}
+		names = append(names, col.Name)
+		selects = append(selects, quoteIdent(col.Name)+"::text")
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	sql := fmt.Sprintf("SELECT %s FROM %s.%s LIMIT $1", strings.Join(selects, ", "), quoteIdent(a.schemaname), quoteIdent(table.Name))
+	rows, err := a.query(ctx, sql, a.jsonProfileRows)
+	if err != nil {
+		return fmt.Errorf("profiling json columns of %s: %w", table.Name, err)
+	}
+	defer rows.Close()
+	documents := make([][][]byte, len(names))
+	values := make([]*string, len(names))
+	dest := make([]interface{}, len(names))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		for i, value := range values {
+			if value != nil {
+				documents[i] = append(documents[i], []byte(*value))
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i, name := range names {
+		for j := range table.Columns {
+			if table.Columns[j].Name == name {
+				table.Columns[j].InferredShape = InferJSONShape(documents[i], a.jsonProfileDepth)
+				table.ColumnsByName[name] = table.Columns[j]
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package inverseschema
+
+import (
+	"context"
+	"strings"
+)
+
+func (a *PostgresAdapter) MaterializedViews(ctx context.Context) ([]MaterializedView, error) {
+	// dependencies are followed through views down to tables and materialized views
+	sql := `WITH RECURSIVE deps(root, oid) AS (
+			SELECT r.ev_class, d.refobjid
+			FROM pg_catalog.pg_rewrite r
+				JOIN pg_catalog.pg_depend d ON d.classid = 'pg_catalog.pg_rewrite'::regclass AND d.objid = r.oid
+					AND d.refclassid = 'pg_catalog.pg_class'::regclass AND d.refobjid <> r.ev_class
+			UNION
+			SELECT deps.root, d.refobjid
+			FROM deps
+				JOIN pg_catalog.pg_class v ON v.oid = deps.oid AND v.relkind = 'v'
+				JOIN pg_catalog.pg_rewrite r ON r.ev_class = v.oid
+				JOIN pg_catalog.pg_depend d ON d.classid = 'pg_catalog.pg_rewrite'::regclass AND d.objid = r.oid
+					AND d.refclassid = 'pg_catalog.pg_class'::regclass AND d.refobjid <> r.ev_class
+		)
+		SELECT
+			c.relname,
+			pg_get_userbyid(c.relowner),
+			pg_get_viewdef(c.oid),
+			c.relispopulated,
+			EXISTS (SELECT 1 FROM pg_catalog.pg_index i WHERE i.indrelid = c.oid AND i.indisunique AND i.indpred IS NULL),
+			(SELECT string_agg(DISTINCT t.relname, chr(31))
+				FROM deps
+					JOIN pg_catalog.pg_class t ON t.oid = deps.oid
+				WHERE deps.root = c.oid AND t.relkind IN ('r', 'p', 'm') AND t.relnamespace = c.relnamespace),
+			obj_description(c.oid, 'pg_class')
+		FROM pg_catalog.pg_class c
+			JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname=$1 AND c.relkind = 'm'
+		ORDER BY c.relname`
+
+	rows, err := a.query(ctx, sql, a.schemaname)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	views := []MaterializedView{}
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		view := MaterializedView{}
+		var dependsOn *string
+		var comments *string
+		if err := rows.Scan(
+			&view.Name,
+			&view.Owner,
+			&view.Definition,
+			&view.IsPopulated,
+			&view.HasUniqueIndex,
+			&dependsOn,
+			&comments,
+		); err != nil {
+			return nil, err
+		}
+		if dependsOn != nil {
+			view.DependsOn = strings.Split(*dependsOn, indexColumnSeparator)
+		}
+		if comments != nil {
+			view.Comments = *comments
+		}
+		views = append(views, view)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return views, nil
+}
@@ -0,0 +1,162 @@
+package inverseschema
+
+import (
+	"context"
+)
+
+var postgresDefaultACLObjectTypes = map[string]string{
+	"r": "table",
+	"S": "sequence",
+	"f": "function",
+	"T": "type",
+	"n": "schema",
+}
+
+func (a *PostgresAdapter) Permissions(ctx context.Context) (*PermissionGraph, error) {
+	g := &PermissionGraph{}
+	var err error
+	if g.Roles, err = a.parseRoles(ctx); err != nil {
+		return nil, err
+	}
+	if g.Grants, err = a.parseGrants(ctx); err != nil {
+		return nil, err
+	}
+	if g.DefaultPrivileges, err = a.parseDefaultPrivileges(ctx); err != nil {
+		return nil, err
+	}
+	a.log().DebugContext(ctx, "parsed permissions", "roles", len(g.Roles), "grants", len(g.Grants), "default_privileges", len(g.DefaultPrivileges))
+	return g, nil
+}
+
+func (a *PostgresAdapter) parseRoles(ctx context.Context) ([]Role, error) {
+	sql := `SELECT r.rolname, r.rolsuper, r.rolinherit, r.rolcanlogin
+		FROM pg_catalog.pg_roles r
+		WHERE r.rolname !~ '^pg_'
+		ORDER BY r.rolname`
+
+	rows, err := a.query(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	roles := []Role{}
+	idxByName := map[string]int{}
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		role := Role{}
+		if err := rows.Scan(&role.Name, &role.IsSuperuser, &role.Inherit, &role.CanLogin); err != nil {
+			return nil, err
+		}
+		idxByName[role.Name] = len(roles)
+		roles = append(roles, role)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sql = `SELECT m.rolname AS member, r.rolname AS role
+		FROM pg_catalog.pg_auth_members am
+			JOIN pg_catalog.pg_roles r ON r.oid = am.roleid
+			JOIN pg_catalog.pg_roles m ON m.oid = am.member
+		ORDER BY r.rolname`
+
+	rows, err = a.query(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var member string
+		var role string
+		if err := rows.Scan(&member, &role); err != nil {
+			return nil, err
+		}
+		idx, ok := idxByName[member]
+		if !ok {
+			continue
+		}
+		roles[idx].MemberOf = append(roles[idx].MemberOf, role)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+func (a *PostgresAdapter) parseGrants(ctx context.Context) ([]Grant, error) {
+	// tables without an explicit acl fall back to the default owner privileges
+	sql := `SELECT
+			c.relname,
+			COALESCE(g.rolname, 'PUBLIC') AS grantee,
+			acl.privilege_type,
+			acl.is_grantable
+		FROM pg_catalog.pg_class c
+			JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+			CROSS JOIN LATERAL aclexplode(COALESCE(c.relacl, acldefault('r', c.relowner))) acl
+			LEFT JOIN pg_catalog.pg_roles g ON g.oid = acl.grantee
+		WHERE n.nspname = $1 AND c.relkind IN ('r', 'p')
+		ORDER BY c.relname`
+
+	rows, err := a.query(ctx, sql, a.schemaname)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	grants := []Grant{}
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		grant := Grant{}
+		if err := rows.Scan(&grant.Tablename, &grant.Grantee, &grant.Privilege, &grant.IsGrantable); err != nil {
+			return nil, err
+		}
+		grants = append(grants, grant)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return grants, nil
+}
+
+func (a *PostgresAdapter) parseDefaultPrivileges(ctx context.Context) ([]DefaultPrivilege, error) {
+	sql := `SELECT
+			r.rolname,
+			COALESCE(g.rolname, 'PUBLIC') AS grantee,
+			d.defaclobjtype,
+			acl.privilege_type
+		FROM pg_catalog.pg_default_acl d
+			JOIN pg_catalog.pg_roles r ON r.oid = d.defaclrole
+			LEFT JOIN pg_catalog.pg_namespace n ON n.oid = d.defaclnamespace
+			CROSS JOIN LATERAL aclexplode(d.defaclacl) acl
+			LEFT JOIN pg_catalog.pg_roles g ON g.oid = acl.grantee
+		WHERE d.defaclnamespace = 0 OR n.nspname = $1`
+
+	rows, err := a.query(ctx, sql, a.schemaname)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	privileges := []DefaultPrivilege{}
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var objtype string
+		p := DefaultPrivilege{}
+		if err := rows.Scan(&p.Role, &p.Grantee, &objtype, &p.Privilege); err != nil {
+			return nil, err
+		}
+		p.ObjectType = postgresDefaultACLObjectTypes[objtype]
+		privileges = append(privileges, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return privileges, nil
+}
@@ -0,0 +1,60 @@
+package inverseschema
+
+import (
+	"context"
+)
+
+func WithStats() PostgresOption {
+	return func(a *PostgresAdapter) {
+		a.withStats = true
+	}
+}
+
+func (a *PostgresAdapter) parseTableStats(ctx context.Context) (map[string]*TableStats, error) {
+	sql := `SELECT
+			s.relname,
+			s.seq_scan,
+			s.seq_tup_read,
+			COALESCE(s.idx_scan, 0),
+			COALESCE(s.idx_tup_fetch, 0),
+			s.n_tup_ins,
+			s.n_tup_upd,
+			s.n_tup_del,
+			s.n_live_tup,
+			s.n_dead_tup
+		FROM pg_catalog.pg_stat_user_tables s
+		WHERE s.schemaname=$1`
+
+	rows, err := a.query(ctx, sql, a.schemaname)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	statsByTable := map[string]*TableStats{}
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var tablename string
+		stats := &TableStats{}
+		if err := rows.Scan(
+			&tablename,
+			&stats.SeqScans,
+			&stats.SeqTuplesRead,
+			&stats.IndexScans,
+			&stats.IndexTuplesFetched,
+			&stats.TuplesInserted,
+			&stats.TuplesUpdated,
+			&stats.TuplesDeleted,
+			&stats.LiveTuples,
+			&stats.DeadTuples,
+		); err != nil {
+			return nil, err
+		}
+		statsByTable[tablename] = stats
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return statsByTable, nil
+}
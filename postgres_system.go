@@ -0,0 +1,44 @@
+package inverseschema
+
+import (
+	"context"
+)
+
+// WithSystemColumns makes the postgres adapter list the hidden system columns (ctid, xmin, ...) of every table in
+// Table.SystemColumns, they are left out of Columns so generators and DDL are unaffected
+func WithSystemColumns() PostgresOption {
+	return func(a *PostgresAdapter) {
+		a.systemColumns = true
+	}
+}
+
+func (a *PostgresAdapter) parseSystemColumns(ctx context.Context, tablename string) ([]Column, error) {
+	sql := `SELECT att.attnum, att.attname, format_type(att.atttypid, att.atttypmod)
+		FROM pg_catalog.pg_attribute att
+			JOIN pg_catalog.pg_class c ON c.oid = att.attrelid
+			JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname=$1 AND c.relname=$2 AND att.attnum < 0
+		ORDER BY att.attnum DESC`
+
+	rows, err := a.query(ctx, sql, a.schemaname, tablename)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	cols := []Column{}
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		col := Column{}
+		if err := rows.Scan(&col.AttNum, &col.Name, &col.DatatypeRaw); err != nil {
+			return nil, err
+		}
+		col.Datatype = postgresDatatypemap[col.DatatypeRaw]
+		cols = append(cols, col)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return cols, nil
+}
@@ -0,0 +1,57 @@
+package inverseschema
+
+import (
+	"bytes"
+	"context"
+)
+
+// parseTextSearchTriggers maps the tsvector columns of a table maintained by tsvector_update_trigger or
+// tsvector_update_trigger_column to the configuration and source columns given to the trigger
+func (a *PostgresAdapter) parseTextSearchTriggers(ctx context.Context, tablename string) (map[string][]string, error) {
+	sql := `SELECT
+			p.proname,
+			t.tgargs
+		FROM pg_catalog.pg_trigger t
+			JOIN pg_catalog.pg_proc p ON p.oid = t.tgfoid
+			JOIN pg_catalog.pg_class c ON c.oid = t.tgrelid
+			JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname=$1 AND c.relname=$2 AND NOT t.tgisinternal
+			AND p.proname IN ('tsvector_update_trigger', 'tsvector_update_trigger_column')
+		ORDER BY t.tgname`
+
+	rows, err := a.query(ctx, sql, a.schemaname, tablename)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	triggers := map[string][]string{}
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var proname string
+		var tgargs []byte
+		if err := rows.Scan(&proname, &tgargs); err != nil {
+			return nil, err
+		}
+		// arguments are NUL terminated: the tsvector column, the configuration (or the column holding it) and the
+		// source columns
+		args := []string{}
+		for _, arg := range bytes.Split(bytes.TrimSuffix(tgargs, []byte{0}), []byte{0}) {
+			args = append(args, string(arg))
+		}
+		if len(args) < 3 {
+			continue
+		}
+		if proname == "tsvector_update_trigger_column" {
+			args[1] = "@" + args[1]
+		}
+		if _, ok := triggers[args[0]]; !ok {
+			triggers[args[0]] = args[1:]
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return triggers, nil
+}
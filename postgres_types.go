@@ -0,0 +1,47 @@
+package inverseschema
+
+var postgresTypeKinds = map[string]string{
+	"b": "base",
+	"c": "composite",
+	"d": "domain",
+	"e": "enum",
+	"p": "pseudo",
+	"r": "range",
+	"m": "multirange",
+}
+
+var postgresTypeCategories = map[string]string{
+	"A": "array",
+	"B": "boolean",
+	"C": "composite",
+	"D": "datetime",
+	"E": "enum",
+	"G": "geometric",
+	"I": "network",
+	"N": "numeric",
+	"P": "pseudo",
+	"R": "range",
+	"S": "string",
+	"T": "timespan",
+	"U": "user",
+	"V": "bitstring",
+	"X": "unknown",
+	"Z": "internal",
+}
+
+// classifyType falls back on pg_type for the types the datatype map does not resolve, recording the kind and category
+// of the type (of its elements for arrays) and the base type of domains
+func classifyType(col *Column, typtype *string, typcategory *string, baseType *string) {
+	if col.Datatype != DatatypeUnknown && col.Datatype != DatatypeUserdefined {
+		return
+	}
+	if typtype != nil {
+		col.TypeKind = postgresTypeKinds[*typtype]
+	}
+	if typcategory != nil {
+		col.TypeCategory = postgresTypeCategories[*typcategory]
+	}
+	if baseType != nil {
+		col.BaseType = *baseType
+	}
+}
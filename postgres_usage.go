@@ -0,0 +1,78 @@
+package inverseschema
+
+import (
+	"context"
+)
+
+// Usage reads the column dependencies pg_depend records (views, policies, triggers, indexes, generated columns and
+// functions with a BEGIN ATOMIC body), the columns pg_stats found always null and the sequences with their owner and
+// callers. Calls from plpgsql bodies are not recorded by postgres
+func (a *PostgresAdapter) Usage(ctx context.Context) (*ObjectUsage, error) {
+	usage := &ObjectUsage{}
+	depended := `SELECT DISTINCT t.relname, att.attname
+		FROM pg_catalog.pg_depend d
+			JOIN pg_catalog.pg_class t ON t.oid = d.refobjid
+			JOIN pg_catalog.pg_namespace n ON n.oid = t.relnamespace
+			JOIN pg_catalog.pg_attribute att ON att.attrelid = t.oid AND att.attnum = d.refobjsubid
+		WHERE d.refclassid = 'pg_catalog.pg_class'::regclass AND d.refobjsubid > 0 AND n.nspname=$1
+			AND t.relkind IN ('r', 'p') AND NOT (d.classid = 'pg_catalog.pg_class'::regclass AND d.objid = t.oid)
+		ORDER BY 1, 2`
+	if err := a.queryColumnRefs(ctx, depended, &usage.DependedColumns); err != nil {
+		return nil, err
+	}
+	null := `SELECT tablename, attname FROM pg_catalog.pg_stats WHERE schemaname=$1 AND null_frac = 1 AND NOT inherited
+		ORDER BY 1, 2`
+	if err := a.queryColumnRefs(ctx, null, &usage.NullColumns); err != nil {
+		return nil, err
+	}
+
+	sequences := `SELECT s.relname,
+			EXISTS (SELECT 1 FROM pg_catalog.pg_depend d
+				WHERE d.classid = 'pg_catalog.pg_class'::regclass AND d.objid = s.oid
+				AND d.refclassid = 'pg_catalog.pg_class'::regclass AND d.deptype IN ('a', 'i')) AS owned,
+			EXISTS (SELECT 1 FROM pg_catalog.pg_depend d
+				WHERE d.refclassid = 'pg_catalog.pg_class'::regclass AND d.refobjid = s.oid
+				AND d.classid IN ('pg_catalog.pg_attrdef'::regclass, 'pg_catalog.pg_rewrite'::regclass, 'pg_catalog.pg_proc'::regclass)) AS used
+		FROM pg_catalog.pg_class s
+			JOIN pg_catalog.pg_namespace n ON n.oid = s.relnamespace
+		WHERE s.relkind = 'S' AND n.nspname=$1
+		ORDER BY s.relname`
+	rows, err := a.query(ctx, sequences, a.schemaname)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		sequence := SequenceUsage{}
+		if err := rows.Scan(&sequence.Name, &sequence.Owned, &sequence.Used); err != nil {
+			return nil, err
+		}
+		usage.Sequences = append(usage.Sequences, sequence)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
+
+func (a *PostgresAdapter) queryColumnRefs(ctx context.Context, sql string, refs *[]ColumnRef) error {
+	rows, err := a.query(ctx, sql, a.schemaname)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		ref := ColumnRef{}
+		if err := rows.Scan(&ref.Tablename, &ref.Columnname); err != nil {
+			return err
+		}
+		*refs = append(*refs, ref)
+	}
+	return rows.Err()
+}
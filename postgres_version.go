@@ -0,0 +1,30 @@
+package inverseschema
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// ServerVersion reads server_version_num, e.g. 160002 for 16.2
+func (a *PostgresAdapter) ServerVersion(ctx context.Context) (int, error) {
+	rows, err := a.query(ctx, "SHOW server_version_num")
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	raw := ""
+	if rows.Next() {
+		if err := rows.Scan(&raw); err != nil {
+			return 0, err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	version, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("server_version_num %q: %w", raw, err)
+	}
+	return version, nil
+}
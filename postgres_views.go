@@ -0,0 +1,150 @@
+package inverseschema
+
+import (
+	"context"
+	"regexp"
+)
+
+// viewNullableRe matches view queries which may produce nulls out of not null columns
+var viewNullableRe = regexp.MustCompile(`(?i)\b(LEFT|RIGHT|FULL)(\s+OUTER)?\s+JOIN\b|\b(UNION|EXCEPT|INTERSECT|ROLLUP|CUBE|GROUPING\s+SETS)\b`)
+
+type viewBaseColumn struct {
+	tablename   string
+	notNull     bool
+	primary     bool
+	primaryKeys int
+}
+
+func (a *PostgresAdapter) Views(ctx context.Context) ([]Table, error) {
+	sql := `SELECT
+			c.relname,
+			pg_get_userbyid(c.relowner),
+			pg_get_viewdef(c.oid),
+			obj_description(c.oid, 'pg_class'),
+			` + extensionSQL("pg_catalog.pg_class", "c.oid") + `
+		FROM pg_catalog.pg_class c
+			JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname=$1 AND c.relkind = 'v'
+		ORDER BY c.relname`
+
+	rows, err := a.query(ctx, sql, a.schemaname)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	views := []Table{}
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		view := Table{IsView: true, Columns: []Column{}}
+		var comments *string
+		var extension *string
+		if err := rows.Scan(&view.Name, &view.Owner, &view.ViewDefinition, &comments, &extension); err != nil {
+			return nil, err
+		}
+		if a.skipsExtension(extension) {
+			continue
+		}
+		if comments != nil {
+			view.Comments = *comments
+		}
+		if extension != nil {
+			view.Extension = *extension
+		}
+		views = append(views, view)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range views {
+		view := &views[i]
+		if view.Columns, err = a.parseTableColumns(ctx, view.Name); err != nil {
+			return nil, err
+		}
+		if !viewNullableRe.MatchString(view.ViewDefinition) {
+			base, err := a.parseViewBaseColumns(ctx, view.Name)
+			if err != nil {
+				return nil, err
+			}
+			resolveViewColumns(view, base)
+		}
+		view.ColumnsByName = make(map[string]Column, len(view.Columns))
+		for _, col := range view.Columns {
+			view.ColumnsByName[col.Name] = col
+		}
+	}
+	return views, nil
+}
+
+// parseViewBaseColumns lists the table columns a view reads by name, from the dependencies of its rewrite rule
+func (a *PostgresAdapter) parseViewBaseColumns(ctx context.Context, viewname string) (map[string][]viewBaseColumn, error) {
+	sql := `SELECT DISTINCT
+			att.attname,
+			t.relname,
+			att.attnotnull,
+			COALESCE(att.attnum = ANY(i.indkey::int2[]), false),
+			COALESCE(i.indnatts, 0)
+		FROM pg_catalog.pg_class v
+			JOIN pg_catalog.pg_namespace n ON n.oid = v.relnamespace
+			JOIN pg_catalog.pg_rewrite r ON r.ev_class = v.oid
+			JOIN pg_catalog.pg_depend d ON d.classid = 'pg_catalog.pg_rewrite'::regclass AND d.objid = r.oid
+				AND d.refclassid = 'pg_catalog.pg_class'::regclass AND d.refobjid <> v.oid AND d.refobjsubid > 0
+			JOIN pg_catalog.pg_class t ON t.oid = d.refobjid
+			JOIN pg_catalog.pg_attribute att ON att.attrelid = t.oid AND att.attnum = d.refobjsubid
+			LEFT JOIN pg_catalog.pg_index i ON i.indrelid = t.oid AND i.indisprimary
+		WHERE n.nspname=$1 AND v.relname=$2`
+
+	rows, err := a.query(ctx, sql, a.schemaname, viewname)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	base := map[string][]viewBaseColumn{}
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var name string
+		col := viewBaseColumn{}
+		if err := rows.Scan(&name, &col.tablename, &col.notNull, &col.primary, &col.primaryKeys); err != nil {
+			return nil, err
+		}
+		base[name] = append(base[name], col)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return base, nil
+}
+
+// resolveViewColumns takes the nullability of a view column from the single table column of the same name it reads,
+// views over a single table also get its primary key when every key column is selected
+func resolveViewColumns(view *Table, base map[string][]viewBaseColumn) {
+	tables := map[string]bool{}
+	for _, cols := range base {
+		for _, col := range cols {
+			tables[col.tablename] = true
+		}
+	}
+	keys := []int{}
+	primaryKeys := 0
+	for i := range view.Columns {
+		col := &view.Columns[i]
+		if len(base[col.Name]) != 1 {
+			continue
+		}
+		resolved := base[col.Name][0]
+		col.IsNullable = !resolved.notNull
+		if resolved.primary {
+			keys = append(keys, i)
+			primaryKeys = resolved.primaryKeys
+		}
+	}
+	if len(tables) == 1 && len(keys) > 0 && len(keys) == primaryKeys {
+		for _, i := range keys {
+			view.Columns[i].IsPrimary = true
+		}
+	}
+}
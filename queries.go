@@ -0,0 +1,460 @@
+package inverseschema
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// QueryIssue is a reference of a query which the schema cannot satisfy, Query is the sqlc query name when present
+type QueryIssue struct {
+	File   string `json:"file,omitempty"`
+	Query  string `json:"query,omitempty"`
+	Line   int    `json:"line,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+type sqlTokenKind int
+
+const (
+	sqlTokenIdent sqlTokenKind = iota + 1
+	sqlTokenQuotedIdent
+	sqlTokenString
+	sqlTokenNumber
+	sqlTokenSymbol
+)
+
+type sqlToken struct {
+	text string
+	kind sqlTokenKind
+	line int
+	// pos is the offset of the token in the runes of the query
+	pos int
+}
+
+// sqlKeywords are never table names, aliases or function calls
+var sqlKeywords = wordSet(`select from where join inner left right full outer cross natural on using group by order having
+	limit offset fetch for union intersect except as and or not in exists any all some array values set returning into
+	update delete insert with recursive lateral only when then else end case between like ilike is null distinct window
+	over partition do conflict nothing default asc desc nulls first last true false merge matched`)
+
+var sqlBuiltinTypes = wordSet(`bigint int8 bigserial serial8 bit varbit boolean bool box bytea character char varchar cidr
+	circle date double float float8 float4 real inet integer int int4 interval json jsonb line lseg macaddr macaddr8 money
+	numeric decimal path pg_lsn point polygon smallint int2 smallserial serial2 serial serial4 text time timetz timestamp
+	timestamptz tsquery tsvector txid_snapshot uuid xml regclass regtype regproc oid name citext hstore ltree geometry
+	geography record anyelement anyarray void`)
+
+func (t sqlToken) is(keyword string) bool {
+	return t.kind == sqlTokenIdent && t.text == keyword
+}
+
+func (t sqlToken) name() bool {
+	return t.kind == sqlTokenQuotedIdent || (t.kind == sqlTokenIdent && !sqlKeywords[t.text])
+}
+
+// tokenizeSQL splits a query into tokens, unquoted identifiers are folded to lower case like postgres does
+func tokenizeSQL(query string, line int) []sqlToken {
+	tokens := []sqlToken{}
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		start, pos := line, i
+		switch {
+		case r == '\n':
+			line++
+			i++
+		case unicode.IsSpace(r):
+			i++
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			for i += 2; i < len(runes) && !(runes[i] == '*' && i+1 < len(runes) && runes[i+1] == '/'); i++ {
+				if runes[i] == '\n' {
+					line++
+				}
+			}
+			i += 2
+		case r == '\'' || r == '"':
+			var b strings.Builder
+			for i++; i < len(runes); i++ {
+				if runes[i] == r {
+					if i+1 < len(runes) && runes[i+1] == r {
+						b.WriteRune(r)
+						i++
+						continue
+					}
+					break
+				}
+				if runes[i] == '\n' {
+					line++
+				}
+				b.WriteRune(runes[i])
+			}
+			i++
+			kind := sqlTokenString
+			if r == '"' {
+				kind = sqlTokenQuotedIdent
+			}
+			tokens = append(tokens, sqlToken{text: b.String(), kind: kind, line: start, pos: pos})
+		case r == '$' && i+1 < len(runes) && (runes[i+1] == '$' || unicode.IsLetter(runes[i+1])):
+			// dollar quoted strings, $1 style parameters are numbers
+			end := i + 1
+			for end < len(runes) && (unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end]) || runes[end] == '_') {
+				end++
+			}
+			if end >= len(runes) || runes[end] != '$' {
+				tokens = append(tokens, sqlToken{text: string(runes[i:end]), kind: sqlTokenSymbol, line: start, pos: pos})
+				i = end
+				continue
+			}
+			tag := string(runes[i : end+1])
+			body := string(runes[end+1:])
+			idx := strings.Index(body, tag)
+			if idx < 0 {
+				idx = len(body)
+			}
+			content := body[:idx]
+			line += strings.Count(content, "\n")
+			tokens = append(tokens, sqlToken{text: content, kind: sqlTokenString, line: start, pos: pos})
+			i = end + 1 + len([]rune(content)) + len([]rune(tag))
+		case unicode.IsLetter(r) || r == '_':
+			end := i
+			for end < len(runes) && (unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end]) || runes[end] == '_' || runes[end] == '$') {
+				end++
+			}
+			tokens = append(tokens, sqlToken{text: strings.ToLower(string(runes[i:end])), kind: sqlTokenIdent, line: start, pos: pos})
+			i = end
+		case unicode.IsDigit(r) || (r == '$' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			end := i + 1
+			for end < len(runes) && (unicode.IsDigit(runes[end]) || runes[end] == '.') {
+				end++
+			}
+			tokens = append(tokens, sqlToken{text: string(runes[i:end]), kind: sqlTokenNumber, line: start, pos: pos})
+			i = end
+		case r == ':' && i+1 < len(runes) && runes[i+1] == ':':
+			tokens = append(tokens, sqlToken{text: "::", kind: sqlTokenSymbol, line: start, pos: pos})
+			i += 2
+		default:
+			tokens = append(tokens, sqlToken{text: string(r), kind: sqlTokenSymbol, line: start, pos: pos})
+			i++
+		}
+	}
+	return tokens
+}
+
+type queryChecker struct {
+	schema  *Schema
+	tokens  []sqlToken
+	issues  []QueryIssue
+	ctes    map[string]bool
+	aliases map[string]string
+	target  string
+}
+
+func (c *queryChecker) token(i int) sqlToken {
+	if i < 0 || i >= len(c.tokens) {
+		return sqlToken{}
+	}
+	return c.tokens[i]
+}
+
+func (c *queryChecker) issue(line int, format string, args ...interface{}) {
+	c.issues = append(c.issues, QueryIssue{Line: line, Reason: fmt.Sprintf(format, args...)})
+}
+
+// table resolves a relation of the query to a table or a view of the schema, CTEs shadow both
+func (c *queryChecker) table(name string) (*Table, bool) {
+	if c.ctes[name] {
+		return nil, false
+	}
+	if table, ok := c.schema.TableByName(name); ok {
+		return table, true
+	}
+	for i := range c.schema.Views {
+		if c.schema.Views[i].Name == name {
+			return &c.schema.Views[i], true
+		}
+	}
+	return nil, false
+}
+
+func (c *queryChecker) checkColumn(tablename string, column sqlToken) {
+	table, ok := c.table(tablename)
+	if !ok || column.text == "*" {
+		return
+	}
+	if _, ok := table.ColumnsByName[column.text]; !ok {
+		c.issue(column.line, "column %s.%s does not exist", tablename, column.text)
+	}
+}
+
+// dottedName reads name or schema.name starting at i, returning the last part and the index after it
+func (c *queryChecker) dottedName(i int) (sqlToken, int) {
+	name := c.token(i)
+	i++
+	for c.token(i).text == "." && c.token(i+1).name() {
+		name = c.token(i + 1)
+		i += 2
+	}
+	return name, i
+}
+
+// columnList checks a parenthesized list of plain column names starting at i, returning the index after it
+func (c *queryChecker) columnList(tablename string, i int) int {
+	depth := 0
+	for ; i < len(c.tokens); i++ {
+		t := c.token(i)
+		switch t.text {
+		case "(":
+			depth++
+			continue
+		case ")":
+			if depth--; depth == 0 {
+				return i + 1
+			}
+			continue
+		}
+		if depth == 1 && t.name() && (c.token(i-1).text == "(" || c.token(i-1).text == ",") && (c.token(i+1).text == "," || c.token(i+1).text == ")") {
+			c.checkColumn(tablename, t)
+		}
+	}
+	return i
+}
+
+// tableRefs reads the table references following FROM, JOIN, UPDATE or INTO at i
+func (c *queryChecker) tableRefs(i int, list bool, into bool) {
+	for {
+		for c.token(i).is("only") || c.token(i).is("lateral") {
+			i++
+		}
+		if !c.token(i).name() {
+			return
+		}
+		name, next := c.dottedName(i)
+		if c.token(next).text == "(" && !into {
+			// a set returning function
+			return
+		}
+		if _, ok := c.table(name.text); !ok && !c.ctes[name.text] {
+			c.issue(name.line, "table %s does not exist", name.text)
+		}
+		c.aliases[name.text] = name.text
+		i = next
+		if c.token(i).is("as") {
+			i++
+		}
+		if c.token(i).name() {
+			c.aliases[c.token(i).text] = name.text
+			i++
+		}
+		if into {
+			c.target = name.text
+			c.aliases["excluded"] = name.text
+			if c.token(i).text == "(" {
+				c.columnList(name.text, i)
+			}
+			return
+		}
+		if !list || c.token(i).text != "," {
+			return
+		}
+		i++
+	}
+}
+
+// assignments checks the columns assigned after SET at i against the target table
+func (c *queryChecker) assignments(i int) {
+	depth := 0
+	expectColumn := true
+	for ; i < len(c.tokens); i++ {
+		t := c.token(i)
+		switch {
+		case t.text == "(":
+			if expectColumn && depth == 0 {
+				i = c.columnList(c.target, i) - 1
+				expectColumn = false
+				continue
+			}
+			depth++
+		case t.text == ")":
+			if depth--; depth < 0 {
+				return
+			}
+		case depth == 0 && t.text == ",":
+			expectColumn = true
+		case depth == 0 && (t.is("from") || t.is("where") || t.is("returning") || t.text == ";"):
+			return
+		case expectColumn && depth == 0 && t.name():
+			c.checkColumn(c.target, t)
+			expectColumn = false
+		}
+	}
+}
+
+func (c *queryChecker) check() {
+	// common table expressions are not tables of the schema
+	for i, t := range c.tokens {
+		if !t.name() {
+			continue
+		}
+		if c.token(i+1).is("as") && (c.token(i+2).text == "(" || (c.token(i+2).is("not") || c.token(i+2).is("materialized"))) {
+			c.ctes[t.text] = true
+		}
+		if c.token(i+1).text == "(" && (c.token(i-1).is("with") || c.token(i-1).is("recursive") || c.token(i-1).text == ",") {
+			depth := 0
+			for j := i + 1; j < len(c.tokens); j++ {
+				if c.tokens[j].text == "(" {
+					depth++
+				} else if c.tokens[j].text == ")" {
+					if depth--; depth == 0 {
+						if c.token(j + 1).is("as") {
+							c.ctes[t.text] = true
+						}
+						break
+					}
+				}
+			}
+		}
+	}
+
+	functions := []bool{}
+	for i, t := range c.tokens {
+		switch {
+		case t.text == "(":
+			prev := c.token(i - 1)
+			functions = append(functions, prev.kind == sqlTokenIdent && !sqlKeywords[prev.text])
+		case t.text == ")":
+			if len(functions) > 0 {
+				functions = functions[:len(functions)-1]
+			}
+		case len(functions) > 0 && functions[len(functions)-1]:
+			// FROM within EXTRACT(), SUBSTRING() and friends is not a table reference
+		case t.is("from"):
+			c.tableRefs(i+1, true, false)
+		case t.is("join"):
+			c.tableRefs(i+1, false, false)
+		case t.is("update") && !c.token(i-1).is("for") && !c.token(i-1).is("key") && !c.token(i-1).is("do"):
+			c.tableRefs(i+1, false, true)
+		case t.is("into"):
+			c.tableRefs(i+1, false, true)
+		case t.is("set") && len(c.target) > 0:
+			c.assignments(i + 1)
+		case t.is("conflict") && c.token(i+1).text == "(" && len(c.target) > 0:
+			c.columnList(c.target, i+1)
+		}
+	}
+
+	enums := map[string]bool{}
+	for _, enum := range c.schema.Enums {
+		enums[enum.Name] = true
+	}
+	for _, table := range c.schema.Tables {
+		for _, col := range table.Columns {
+			if col.UserDefinedType != nil {
+				enums[col.UserDefinedType.Name] = true
+			}
+		}
+	}
+	for i, t := range c.tokens {
+		if t.text == "::" {
+			typ, _ := c.dottedName(i + 1)
+			if typ.name() && !sqlBuiltinTypes[typ.text] && !enums[typ.text] {
+				c.issue(typ.line, "type %s does not exist", typ.text)
+			}
+			continue
+		}
+		if !t.name() || c.token(i-1).text == "::" || c.token(i+1).text != "." || c.token(i+3).text == "(" {
+			continue
+		}
+		if tablename, ok := c.aliases[t.text]; ok {
+			column := c.token(i + 2)
+			if column.name() || column.text == "*" {
+				c.checkColumn(tablename, column)
+			}
+		}
+	}
+}
+
+// CheckQuery validates the tables, qualified columns, inserted and updated columns and casts of a query, or of
+// several semicolon separated ones, against the schema. It is a heuristic: unqualified columns outside of INSERT,
+// UPDATE and ON CONFLICT column lists are not checked
+func (s *Schema) CheckQuery(query string) []QueryIssue {
+	return s.checkQuery(query, 1)
+}
+
+func (s *Schema) checkQuery(query string, line int) []QueryIssue {
+	issues := []QueryIssue{}
+	tokens := tokenizeSQL(query, line)
+	start := 0
+	for i := 0; i <= len(tokens); i++ {
+		if i < len(tokens) && tokens[i].text != ";" {
+			continue
+		}
+		if i > start {
+			c := &queryChecker{schema: s, tokens: tokens[start:i], ctes: map[string]bool{}, aliases: map[string]string{}}
+			c.check()
+			issues = append(issues, c.issues...)
+		}
+		start = i + 1
+	}
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].Line < issues[j].Line
+	})
+	return issues
+}
+
+var sqlcNameRe = regexp.MustCompile(`^--\s*name:\s*(\S+)`)
+
+// CheckQueries checks every .sql file below dir, sqlc query files are split on their "-- name:" annotations. Checking
+// the queries against the schema a pending change produces reports the queries it would break
+func (s *Schema) CheckQueries(dir string) ([]QueryIssue, error) {
+	files := []string{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && strings.HasSuffix(path, ".sql") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	issues := []QueryIssue{}
+	for _, path := range files {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			rel = path
+		}
+		lines := strings.Split(string(content), "\n")
+		name, start := "", 0
+		flush := func(end int) {
+			for _, issue := range s.checkQuery(strings.Join(lines[start:end], "\n"), start+1) {
+				issue.File = rel
+				issue.Query = name
+				issues = append(issues, issue)
+			}
+		}
+		for i, line := range lines {
+			if match := sqlcNameRe.FindStringSubmatch(strings.TrimSpace(line)); match != nil {
+				flush(i)
+				name, start = match[1], i
+			}
+		}
+		flush(len(lines))
+	}
+	return issues, nil
+}
@@ -0,0 +1,75 @@
+package inverseschema
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+)
+
+// tokenBucket refills rate tokens per second up to burst, every query takes one token
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// reserve takes a token and returns how long the caller has to wait before using it
+func (b *tokenBucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+func (b *tokenBucket) wait(ctx context.Context) error {
+	delay := b.reserve()
+	if delay == 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// WithRateLimit throttles catalog queries to perSecond with bursts of up to burst queries, the limit is shared by
+// every call made on the adapter, including concurrent ones
+func WithRateLimit(perSecond float64, burst int) PostgresOption {
+	return func(a *PostgresAdapter) {
+		if perSecond > 0 {
+			a.limiter = newTokenBucket(perSecond, burst)
+		}
+	}
+}
+
+func (a *PostgresAdapter) query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if a.limiter != nil {
+		if err := a.limiter.wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return a.db.QueryContext(ctx, query, args...)
+}
@@ -0,0 +1,164 @@
+package inverseschema
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var redisPlaceholderRe = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// redisTypeAliases complete the postgres type names accepted for declared fields
+var redisTypeAliases = map[string]Datatype{
+	"string":      DatatypeText,
+	"int":         DatatypeInt,
+	"int4":        DatatypeInt,
+	"int8":        DatatypeBigint,
+	"smallint":    DatatypeSmallint,
+	"bool":        DatatypeBoolean,
+	"json":        DatatypeJson,
+	"varchar":     DatatypeVarchar,
+	"timestamp":   DatatypeTimestamp,
+	"timestamptz": DatatypeTimestampz,
+}
+
+// RedisField declares a field of a hash, stream entry or JSON document, Type is a postgres type name (text, bigint,
+// timestamptz, ...) with a [] suffix for arrays
+type RedisField struct {
+	Name     string `json:"name,omitempty"`
+	Type     string `json:"type,omitempty"`
+	Nullable bool   `json:"nullable,omitempty"`
+	Comments string `json:"comments,omitempty"`
+}
+
+// RedisKeyspace declares the keys matching a pattern, placeholders such as {id} in the pattern become key columns.
+// Structure is string, hash, json, list, set, zset or stream, hashes, JSON documents and streams declare their Fields
+// while the other structures declare the Type of their members
+type RedisKeyspace struct {
+	Name      string       `json:"name,omitempty"`
+	Pattern   string       `json:"pattern,omitempty"`
+	Structure string       `json:"structure,omitempty"`
+	Type      string       `json:"type,omitempty"`
+	Fields    []RedisField `json:"fields,omitempty"`
+	TTL       string       `json:"ttl,omitempty"`
+	Comments  string       `json:"comments,omitempty"`
+}
+
+// LoadRedisKeyspaces reads a JSON list of keyspaces
+func LoadRedisKeyspaces(r io.Reader) ([]RedisKeyspace, error) {
+	keyspaces := []RedisKeyspace{}
+	if err := json.NewDecoder(r).Decode(&keyspaces); err != nil {
+		return nil, err
+	}
+	return keyspaces, nil
+}
+
+// NewRedisAdapter builds a pseudo schema from declared keyspaces, it never connects to redis: every keyspace becomes
+// a table holding its key columns followed by its fields or members
+func NewRedisAdapter(keyspaces ...RedisKeyspace) *RedisAdapter {
+	return &RedisAdapter{keyspaces: keyspaces}
+}
+
+type RedisAdapter struct {
+	keyspaces []RedisKeyspace
+}
+
+func redisColumn(name string, typ string) Column {
+	col := Column{Name: name}
+	typ = strings.ToLower(strings.TrimSpace(typ))
+	if strings.HasSuffix(typ, "[]") {
+		col.IsArray = true
+		typ = strings.TrimSuffix(typ, "[]")
+	}
+	if len(typ) == 0 {
+		typ = "text"
+	}
+	col.DatatypeRaw = typ
+	if datatype, ok := postgresDatatypemap[typ]; ok && datatype != DatatypeUserdefined && datatype != DatatypeArray {
+		col.Datatype = datatype
+	} else {
+		col.Datatype = redisTypeAliases[typ]
+	}
+	return col
+}
+
+func redisTable(keyspace RedisKeyspace) (Table, error) {
+	table := Table{Name: keyspace.Name, Columns: []Column{}}
+	if len(table.Name) == 0 {
+		return table, fmt.Errorf("redis keyspace %s: missing name", keyspace.Pattern)
+	}
+	comments := []string{"redis " + keyspace.Structure + " " + keyspace.Pattern}
+	if len(keyspace.TTL) > 0 {
+		comments = append(comments, "ttl "+keyspace.TTL)
+	}
+	if len(keyspace.Comments) > 0 {
+		comments = append(comments, keyspace.Comments)
+	}
+	table.Comments = strings.Join(comments, ", ")
+
+	add := func(col Column) {
+		col.OrdinalPosition = len(table.Columns) + 1
+		table.Columns = append(table.Columns, col)
+	}
+	for _, match := range redisPlaceholderRe.FindAllStringSubmatch(keyspace.Pattern, -1) {
+		col := redisColumn(match[1], "text")
+		col.IsPrimary = true
+		add(col)
+	}
+
+	switch keyspace.Structure {
+	case "hash", "json", "stream":
+		if keyspace.Structure == "stream" {
+			entry := redisColumn("entry_id", "text")
+			entry.IsPrimary = true
+			add(entry)
+		}
+		for _, field := range keyspace.Fields {
+			col := redisColumn(field.Name, field.Type)
+			col.IsNullable = field.Nullable
+			col.Comments = field.Comments
+			add(col)
+		}
+	case "string":
+		add(redisColumn("value", keyspace.Type))
+	case "list", "set":
+		member := redisColumn("members", keyspace.Type)
+		member.IsArray = true
+		add(member)
+	case "zset":
+		member := redisColumn("member", keyspace.Type)
+		member.IsPrimary = true
+		add(member)
+		add(redisColumn("score", "numeric"))
+	default:
+		return table, fmt.Errorf("redis keyspace %s: unsupported structure %q", keyspace.Name, keyspace.Structure)
+	}
+
+	table.ColumnsByName = make(map[string]Column, len(table.Columns))
+	for _, col := range table.Columns {
+		if _, ok := table.ColumnsByName[col.Name]; ok {
+			return table, fmt.Errorf("redis keyspace %s: duplicate column %s", keyspace.Name, col.Name)
+		}
+		table.ColumnsByName[col.Name] = col
+	}
+	return table, nil
+}
+
+func (a *RedisAdapter) Tables(ctx context.Context) ([]Table, error) {
+	tables := []Table{}
+	for _, keyspace := range a.keyspaces {
+		table, err := redisTable(keyspace)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+func (a *RedisAdapter) Enums(ctx context.Context) ([]Enum, error) {
+	return []Enum{}, nil
+}
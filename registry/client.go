@@ -0,0 +1,76 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/oiime/inverseschema"
+)
+
+func NewClient(baseURL string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{baseURL: strings.TrimRight(baseURL, "/"), httpClient: httpClient}
+}
+
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// Publish stores the schema of a service in the registry, usually called on service startup
+func (c *Client) Publish(ctx context.Context, name string, schema *inverseschema.Schema) (*Entry, error) {
+	body, err := json.Marshal(Entry{Schema: schema})
+	if err != nil {
+		return nil, err
+	}
+	entry := &Entry{}
+	if err := c.do(ctx, http.MethodPut, pathPrefix+url.PathEscape(name), bytes.NewReader(body), entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (c *Client) Fetch(ctx context.Context, name string) (*Entry, error) {
+	entry := &Entry{}
+	if err := c.do(ctx, http.MethodGet, pathPrefix+url.PathEscape(name), nil, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (c *Client) List(ctx context.Context) ([]Entry, error) {
+	entries := []Entry{}
+	if err := c.do(ctx, http.MethodGet, "/schemas", nil, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (c *Client) do(ctx context.Context, method string, path string, body io.Reader, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("registry responded with %d: %s", res.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return json.NewDecoder(res.Body).Decode(v)
+}
@@ -0,0 +1,106 @@
+package registry
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// selectFields keeps the given dotted paths of a decoded JSON document, arrays are traversed so that columns.name
+// keeps the name of every column. A path ending on an object keeps it whole
+func selectFields(v interface{}, paths [][]string) interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		selected := make([]interface{}, len(v))
+		for i, item := range v {
+			selected[i] = selectFields(item, paths)
+		}
+		return selected
+	case map[string]interface{}:
+		nested := map[string][][]string{}
+		whole := map[string]bool{}
+		for _, path := range paths {
+			if len(path) == 1 {
+				whole[path[0]] = true
+			} else {
+				nested[path[0]] = append(nested[path[0]], path[1:])
+			}
+		}
+		selected := map[string]interface{}{}
+		for key, value := range v {
+			if whole[key] {
+				selected[key] = value
+			} else if paths, ok := nested[key]; ok {
+				selected[key] = selectFields(value, paths)
+			}
+		}
+		return selected
+	}
+	return v
+}
+
+// limitDepth drops the objects nested deeper than depth, arrays do not count as a level
+func limitDepth(v interface{}, depth int) interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		limited := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			if item = limitDepth(item, depth); item != nil {
+				limited = append(limited, item)
+			}
+		}
+		return limited
+	case map[string]interface{}:
+		if depth <= 0 {
+			return nil
+		}
+		limited := map[string]interface{}{}
+		for key, value := range v {
+			if value = limitDepth(value, depth-1); value != nil {
+				limited[key] = value
+			}
+		}
+		return limited
+	}
+	return v
+}
+
+// writeSelected writes v narrowed to the fields and depth query parameters, fields is a comma separated list of dotted
+// paths and depth the number of object levels kept
+func writeSelected(w http.ResponseWriter, r *http.Request, v interface{}) {
+	fields := r.URL.Query().Get("fields")
+	depth := r.URL.Query().Get("depth")
+	if len(fields) == 0 && len(depth) == 0 {
+		writeJSON(w, http.StatusOK, v)
+		return
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(fields) > 0 {
+		paths := [][]string{}
+		for _, field := range strings.Split(fields, ",") {
+			if field = strings.TrimSpace(field); len(field) > 0 {
+				paths = append(paths, strings.Split(field, "."))
+			}
+		}
+		doc = selectFields(doc, paths)
+	}
+	if len(depth) > 0 {
+		levels, err := strconv.Atoi(depth)
+		if err != nil || levels < 1 {
+			http.Error(w, "invalid depth", http.StatusBadRequest)
+			return
+		}
+		doc = limitDepth(doc, levels)
+	}
+	writeJSON(w, http.StatusOK, doc)
+}
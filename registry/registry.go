@@ -0,0 +1,14 @@
+package registry
+
+import (
+	"time"
+
+	"github.com/oiime/inverseschema"
+)
+
+type Entry struct {
+	Name        string                `json:"name,omitempty"`
+	Fingerprint string                `json:"fingerprint,omitempty"`
+	PublishedAt time.Time             `json:"published_at,omitempty"`
+	Schema      *inverseschema.Schema `json:"schema,omitempty"`
+}
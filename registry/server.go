@@ -0,0 +1,130 @@
+package registry
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const pathPrefix = "/schemas/"
+
+func NewServer() *Server {
+	return &Server{entries: map[string]Entry{}}
+}
+
+// Server keeps the latest published schema of every service in memory
+//
+//	GET /schemas                lists every entry without its schema
+//	GET /schemas/{name}         returns the entry of a service
+//	GET /schemas/{name}/tables  returns the tables of a service
+//	GET /schemas/{name}/enums   returns the enums of a service
+//	PUT /schemas/{name}         publishes an entry
+//
+// GET requests accept fields (e.g. fields=name,columns.name,columns.datatype) and depth query parameters to narrow
+// the response
+type Server struct {
+	mu      sync.RWMutex
+	entries map[string]Entry
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/schemas" || r.URL.Path == pathPrefix {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		s.list(w, r)
+		return
+	}
+	if !strings.HasPrefix(r.URL.Path, pathPrefix) {
+		http.NotFound(w, r)
+		return
+	}
+	name := strings.TrimPrefix(r.URL.Path, pathPrefix)
+	resource := ""
+	if idx := strings.Index(name, "/"); idx >= 0 {
+		name, resource = name[:idx], name[idx+1:]
+	}
+	if len(name) == 0 || (len(resource) > 0 && resource != "tables" && resource != "enums") {
+		http.NotFound(w, r)
+		return
+	}
+	if len(resource) > 0 && r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		s.get(w, r, name, resource)
+	case http.MethodPut:
+		s.put(w, r, name)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) list(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	entries := make([]Entry, 0, len(s.entries))
+	for _, entry := range s.entries {
+		entry.Schema = nil
+		entries = append(entries, entry)
+	}
+	s.mu.RUnlock()
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+	writeSelected(w, r, entries)
+}
+
+func (s *Server) get(w http.ResponseWriter, r *http.Request, name string, resource string) {
+	s.mu.RLock()
+	entry, ok := s.entries[name]
+	s.mu.RUnlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	switch resource {
+	case "tables":
+		writeSelected(w, r, entry.Schema.Tables)
+	case "enums":
+		writeSelected(w, r, entry.Schema.Enums)
+	default:
+		writeSelected(w, r, entry)
+	}
+}
+
+func (s *Server) put(w http.ResponseWriter, r *http.Request, name string) {
+	entry := Entry{}
+	if err := json.NewDecoder(r.Body).Decode(&entry); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if entry.Schema == nil {
+		http.Error(w, "missing schema", http.StatusBadRequest)
+		return
+	}
+	fingerprint, err := entry.Schema.Fingerprint()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	entry.Name = name
+	entry.Fingerprint = fingerprint
+	entry.PublishedAt = time.Now().UTC()
+
+	s.mu.Lock()
+	s.entries[name] = entry
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, Entry{Name: entry.Name, Fingerprint: entry.Fingerprint, PublishedAt: entry.PublishedAt})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,222 @@
+package inverseschema
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// postgresMaxIdentifier is the length postgres truncates identifiers to, generated names of that length were
+// truncated and cannot be derived again
+const postgresMaxIdentifier = 63
+
+// Renames maps current names to new ones, Tables by table name and Columns by "table.column" using the current table
+// name
+type Renames struct {
+	Tables  map[string]string `json:"tables,omitempty"`
+	Columns map[string]string `json:"columns,omitempty"`
+}
+
+// RenamePlan is the outcome of PlanRename. SQL renames the columns, the constraints, indexes and sequences (identity
+// sequences included) named after them following the postgres naming conventions, the columns of parsed views
+// exposing a renamed column under its old name, then the tables. Dependents lists the objects depending on renamed
+// columns: views whose columns are not renamed keep working with their output unchanged and function bodies
+// referencing the column break, they have to be reviewed or recreated. Artifacts lists the generated files rendering
+// a renamed table
+type RenamePlan struct {
+	SQL        []string    `json:"sql,omitempty"`
+	Dependents []Dependent `json:"dependents,omitempty"`
+	Artifacts  []string    `json:"artifacts,omitempty"`
+	// Locks annotates every statement of SQL with its lock on the server version of the schema
+	Locks []StatementLock `json:"locks,omitempty"`
+}
+
+// renamedObjectName derives the new name of a constraint, index or sequence named <table>_<columns>_<suffix>
+func renamedObjectName(name string, tablename string, newTablename string, columns map[string]string) (string, bool) {
+	if len(name) >= postgresMaxIdentifier || !strings.HasPrefix(name, tablename+"_") {
+		return "", false
+	}
+	rest := "_" + strings.TrimPrefix(name, tablename+"_") + "_"
+	olds := make([]string, 0, len(columns))
+	for old := range columns {
+		olds = append(olds, old)
+	}
+	// longer names first so that a column named after the prefix of another does not claim its segment
+	sort.Slice(olds, func(i, j int) bool {
+		return len(olds[i]) > len(olds[j]) || (len(olds[i]) == len(olds[j]) && olds[i] < olds[j])
+	})
+	for _, old := range olds {
+		rest = strings.ReplaceAll(rest, "_"+old+"_", "_"+columns[old]+"_")
+	}
+	renamed := newTablename + "_" + strings.Trim(rest, "_")
+	if renamed == name || len(renamed) > postgresMaxIdentifier {
+		return "", false
+	}
+	return renamed, true
+}
+
+func (s *Schema) viewByName(name string) (*Table, bool) {
+	for i := range s.Views {
+		if s.Views[i].Name == name {
+			return &s.Views[i], true
+		}
+	}
+	return nil, false
+}
+
+func (s *Schema) PlanRename(renames Renames, artifacts map[string][]string) (*RenamePlan, error) {
+	return s.PlanRenameContext(context.Background(), renames, artifacts)
+}
+
+// PlanRenameContext validates a rename map against the schema and renders the statements applying it, artifacts maps
+// generated file paths to the tables they render (as recorded by the previous generator run)
+func (s *Schema) PlanRenameContext(ctx context.Context, renames Renames, artifacts map[string][]string) (*RenamePlan, error) {
+	plan := &RenamePlan{SQL: []string{}}
+	columnsByTable := map[string]map[string]string{}
+	for key, renamed := range renames.Columns {
+		idx := strings.Index(key, ".")
+		if idx < 0 {
+			return nil, fmt.Errorf("column rename %s is not of the form table.column", key)
+		}
+		tablename, columnname := key[:idx], key[idx+1:]
+		table, ok := s.TableByName(tablename)
+		if !ok {
+			return nil, fmt.Errorf("unknown table %s", tablename)
+		}
+		if _, ok := table.ColumnsByName[columnname]; !ok {
+			return nil, fmt.Errorf("unknown column %s.%s", tablename, columnname)
+		}
+		if columnsByTable[tablename] == nil {
+			columnsByTable[tablename] = map[string]string{}
+		}
+		columnsByTable[tablename][columnname] = renamed
+	}
+	for tablename := range renames.Tables {
+		if _, ok := s.TableByName(tablename); !ok {
+			return nil, fmt.Errorf("unknown table %s", tablename)
+		}
+	}
+
+	// renames are applied one by one, a new name cannot be one in use nor the target of another rename
+	targets := map[string]string{}
+	for tablename, renamed := range renames.Tables {
+		if _, ok := s.TableByName(renamed); ok {
+			return nil, fmt.Errorf("cannot rename %s to %s: the table exists", tablename, renamed)
+		}
+		if other, ok := targets[renamed]; ok {
+			return nil, fmt.Errorf("cannot rename both %s and %s to %s", other, tablename, renamed)
+		}
+		targets[renamed] = tablename
+	}
+	for tablename, columns := range columnsByTable {
+		table, _ := s.TableByName(tablename)
+		targets := map[string]string{}
+		for columnname, renamed := range columns {
+			if _, ok := table.ColumnsByName[renamed]; ok {
+				return nil, fmt.Errorf("cannot rename %s.%s to %s: the column exists", tablename, columnname, renamed)
+			}
+			if other, ok := targets[renamed]; ok {
+				return nil, fmt.Errorf("cannot rename both %s.%s and %s.%s to %s", tablename, other, tablename, columnname, renamed)
+			}
+			targets[renamed] = columnname
+		}
+	}
+
+	renamedTables := map[string]bool{}
+	tableRenames := []string{}
+	objectRenames := []string{}
+	viewRenames := []string{}
+	viewColumns := map[string]bool{}
+	dependents := map[Dependent]bool{}
+	for _, table := range s.Tables {
+		columns := columnsByTable[table.Name]
+		newTablename, tableRenamed := renames.Tables[table.Name]
+		if !tableRenamed {
+			newTablename = table.Name
+		}
+		if len(columns) == 0 && !tableRenamed {
+			continue
+		}
+		renamedTables[table.Name] = true
+
+		for _, col := range table.Columns {
+			renamed, ok := columns[col.Name]
+			if !ok {
+				continue
+			}
+			plan.SQL = append(plan.SQL, fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s;", quoteIdent(table.Name), quoteIdent(col.Name), quoteIdent(renamed)))
+			impact, err := s.ImpactContext(ctx, table.Name, col.Name)
+			if err != nil {
+				return nil, err
+			}
+			for _, dependent := range impact.Dependents {
+				if dependent.Kind == "view" {
+					// views expose the column under its old name until their own column is renamed
+					_, viewname := splitRegclass(dependent.Name)
+					if view, ok := s.viewByName(viewname); ok && !viewColumns[viewname+"."+col.Name] {
+						if _, exposed := view.ColumnsByName[col.Name]; exposed {
+							viewColumns[viewname+"."+col.Name] = true
+							viewRenames = append(viewRenames, fmt.Sprintf("ALTER VIEW %s RENAME COLUMN %s TO %s;", dependent.Name, quoteIdent(col.Name), quoteIdent(renamed)))
+							continue
+						}
+					}
+				}
+				if dependent.Kind != "constraint" && !dependents[dependent] {
+					dependents[dependent] = true
+					plan.Dependents = append(plan.Dependents, dependent)
+				}
+			}
+		}
+
+		constraints := map[string]bool{}
+		for _, c := range tableConstraints(table) {
+			constraints[c.name] = true
+			if renamed, ok := renamedObjectName(c.name, table.Name, newTablename, columns); ok {
+				objectRenames = append(objectRenames, fmt.Sprintf("ALTER TABLE %s RENAME CONSTRAINT %s TO %s;", quoteIdent(table.Name), quoteIdent(c.name), quoteIdent(renamed)))
+			}
+		}
+		for _, index := range table.Indexes {
+			// indexes backing a constraint follow its name
+			if constraints[index.Name] {
+				continue
+			}
+			if renamed, ok := renamedObjectName(index.Name, table.Name, newTablename, columns); ok {
+				objectRenames = append(objectRenames, fmt.Sprintf("ALTER INDEX %s RENAME TO %s;", quoteIdent(index.Name), quoteIdent(renamed)))
+			}
+		}
+		for _, col := range table.Columns {
+			// the regclass text of nextval is already quoted and possibly schema qualified
+			for _, match := range nextvalRe.FindAllStringSubmatch(col.Default, -1) {
+				_, sequence := splitRegclass(match[1])
+				if renamed, ok := renamedObjectName(sequence, table.Name, newTablename, columns); ok {
+					objectRenames = append(objectRenames, fmt.Sprintf("ALTER SEQUENCE %s RENAME TO %s;", match[1], quoteIdent(renamed)))
+				}
+			}
+			// identity sequences are named like serial ones but do not appear in the default, they may have been named
+			// otherwise on creation
+			if col.IsIdentity {
+				if renamed, ok := renamedObjectName(table.Name+"_"+col.Name+"_seq", table.Name, newTablename, columns); ok {
+					objectRenames = append(objectRenames, fmt.Sprintf("ALTER SEQUENCE IF EXISTS %s RENAME TO %s;", quoteIdent(table.Name+"_"+col.Name+"_seq"), quoteIdent(renamed)))
+				}
+			}
+		}
+		if tableRenamed {
+			tableRenames = append(tableRenames, fmt.Sprintf("ALTER TABLE %s RENAME TO %s;", quoteIdent(table.Name), quoteIdent(newTablename)))
+		}
+	}
+	// constraints are renamed while the tables still have their current name
+	plan.SQL = append(append(append(plan.SQL, objectRenames...), viewRenames...), tableRenames...)
+	plan.Locks = AnnotateLocks(strings.Join(plan.SQL, "\n"), s.ServerVersion)
+
+	for path, tables := range artifacts {
+		for _, tablename := range tables {
+			if renamedTables[tablename] {
+				plan.Artifacts = append(plan.Artifacts, path)
+				break
+			}
+		}
+	}
+	sort.Strings(plan.Artifacts)
+	return plan, nil
+}
@@ -0,0 +1,178 @@
+package inverseschema
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type Dialect int
+
+const (
+	DialectMySQL Dialect = iota + 1
+	DialectMSSQL
+	DialectGraphQL
+	DialectProtobuf
+)
+
+var dialectNames = map[Dialect]string{
+	DialectMySQL:    "mysql",
+	DialectMSSQL:    "mssql",
+	DialectGraphQL:  "graphql",
+	DialectProtobuf: "protobuf",
+}
+
+func (d Dialect) String() string {
+	return dialectNames[d]
+}
+
+type IdentifierIssue struct {
+	Dialect    Dialect `json:"dialect,omitempty"`
+	Tablename  string  `json:"tablename,omitempty"`
+	Columnname string  `json:"columnname,omitempty"`
+	Enumname   string  `json:"enumname,omitempty"`
+	Label      string  `json:"label,omitempty"`
+	Reason     string  `json:"reason,omitempty"`
+}
+
+type dialectRules struct {
+	pattern   *regexp.Regexp
+	maxLength int
+	// reserved words are compared lowercased unless caseSensitive is set
+	caseSensitive bool
+	reserved      map[string]bool
+	// reserved type names, only checked against table and enum names
+	reservedTypes map[string]bool
+}
+
+func wordSet(words string) map[string]bool {
+	set := map[string]bool{}
+	for _, word := range strings.Fields(words) {
+		set[word] = true
+	}
+	return set
+}
+
+var dialects = map[Dialect]dialectRules{
+	DialectMySQL: {
+		pattern:   regexp.MustCompile(`^[0-9A-Za-z_$\x{80}-\x{FFFF}]+$`),
+		maxLength: 64,
+		reserved: wordSet(`accessible add all alter analyze and as asc asensitive before between bigint binary blob both by call cascade
+			case change char character check collate column condition constraint continue convert create cross cube cume_dist
+			current_date current_time current_timestamp current_user cursor database databases day_hour day_microsecond
+			day_minute day_second dec decimal declare default delayed delete dense_rank desc describe deterministic distinct
+			distinctrow div double drop dual each else elseif empty enclosed escaped except exists exit explain false fetch
+			first_value float float4 float8 for force foreign from fulltext function generated get grant group grouping groups
+			having high_priority hour_microsecond hour_minute hour_second if ignore in index infile inner inout insensitive
+			insert int int1 int2 int3 int4 int8 integer interval into io_after_gtids io_before_gtids is iterate join json_table
+			key keys kill lag last_value lateral lead leading leave left like limit linear lines load localtime localtimestamp
+			lock long longblob longtext loop low_priority master_bind master_ssl_verify_server_cert match maxvalue mediumblob
+			mediumint mediumtext middleint minute_microsecond minute_second mod modifies natural not no_write_to_binlog nth_value
+			ntile null numeric of on optimize optimizer_costs option optionally or order out outer outfile over partition
+			percent_rank precision primary procedure purge range rank read reads read_write real recursive references regexp
+			release rename repeat replace require resignal restrict return revoke right rlike row row_number rows schema schemas
+			second_microsecond select sensitive separator set show signal smallint spatial specific sql sqlexception sqlstate
+			sqlwarning sql_big_result sql_calc_found_rows sql_small_result ssl starting stored straight_join system table
+			terminated then tinyblob tinyint tinytext to trailing trigger true undo union unique unlock unsigned update usage use
+			using utc_date utc_time utc_timestamp values varbinary varchar varcharacter varying virtual when where while window
+			with write xor year_month zerofill`),
+	},
+	DialectMSSQL: {
+		pattern:   regexp.MustCompile(`^[A-Za-z_@#\x{80}-\x{FFFF}][0-9A-Za-z_@#$\x{80}-\x{FFFF}]*$`),
+		maxLength: 128,
+		reserved: wordSet(`add all alter and any as asc authorization backup begin between break browse bulk by cascade case check
+			checkpoint close clustered coalesce collate column commit compute constraint contains containstable continue convert
+			create cross current current_date current_time current_timestamp current_user cursor database dbcc deallocate
+			declare default delete deny desc disk distinct distributed double drop dump else end errlvl escape except exec execute
+			exists exit external fetch file fillfactor for foreign freetext freetexttable from full function goto grant group
+			having holdlock identity identity_insert identitycol if in index inner insert intersect into is join key kill left
+			like lineno load merge national nocheck nonclustered not null nullif of off offsets on open opendatasource openquery
+			openrowset openxml option or order outer over percent pivot plan precision primary print proc procedure public
+			raiserror read readtext reconfigure references replication restore restrict return revert revoke right rollback
+			rowcount rowguidcol rule save schema securityaudit select semantickeyphrasetable semanticsimilaritydetailstable
+			semanticsimilaritytable session_user set setuser shutdown some statistics system_user table tablesample textsize then
+			to top tran transaction trigger truncate try_convert tsequal union unique unpivot update updatetext use user values
+			varying view waitfor when where while with within writetext`),
+	},
+	DialectGraphQL: {
+		pattern:       regexp.MustCompile(`^[_A-Za-z][_0-9A-Za-z]*$`),
+		caseSensitive: true,
+		reservedTypes: wordSet(`Query Mutation Subscription String Int Float Boolean ID`),
+	},
+	DialectProtobuf: {
+		pattern:       regexp.MustCompile(`^[A-Za-z][_0-9A-Za-z]*$`),
+		caseSensitive: true,
+		reserved: wordSet(`syntax edition import weak public package option message enum service rpc returns stream repeated
+			optional required reserved extensions extend oneof map to max true false group inf nan`),
+		reservedTypes: wordSet(`double float int32 int64 uint32 uint64 sint32 sint64 fixed32 fixed64 sfixed32 sfixed64 bool string bytes`),
+	},
+}
+
+// identifierIssues names the problems of an identifier in a dialect, isType marks names which become type names
+func identifierIssues(rules dialectRules, dialect Dialect, name string, isType bool) []string {
+	reasons := []string{}
+	if !rules.pattern.MatchString(name) {
+		reasons = append(reasons, fmt.Sprintf("%q is not a valid %s identifier", name, dialect))
+	}
+	if rules.maxLength > 0 && len(name) > rules.maxLength {
+		reasons = append(reasons, fmt.Sprintf("%q exceeds the %d characters %s allows", name, rules.maxLength, dialect))
+	}
+	if dialect == DialectGraphQL && strings.HasPrefix(name, "__") {
+		reasons = append(reasons, fmt.Sprintf("%q uses the __ prefix graphql reserves for introspection", name))
+	}
+	word := name
+	if !rules.caseSensitive {
+		word = strings.ToLower(name)
+	}
+	if rules.reserved[word] || (isType && rules.reservedTypes[word]) {
+		reasons = append(reasons, fmt.Sprintf("%q is a reserved word in %s", name, dialect))
+	}
+	return reasons
+}
+
+// CheckIdentifiers flags table, column, enum and enum label names which are reserved or invalid in the target dialects,
+// graphql and protobuf type names are checked after pascal casing, as the generators name them
+func (s *Schema) CheckIdentifiers(targets ...Dialect) ([]IdentifierIssue, error) {
+	issues := []IdentifierIssue{}
+	for _, dialect := range targets {
+		rules, ok := dialects[dialect]
+		if !ok {
+			return nil, fmt.Errorf("unsupported dialect %d", dialect)
+		}
+		typeName := func(name string) string {
+			if dialect == DialectGraphQL || dialect == DialectProtobuf {
+				return pascalCase(name)
+			}
+			return name
+		}
+		for _, table := range s.Tables {
+			for _, reason := range identifierIssues(rules, dialect, typeName(table.Name), true) {
+				issues = append(issues, IdentifierIssue{Dialect: dialect, Tablename: table.Name, Reason: reason})
+			}
+			for _, col := range table.Columns {
+				for _, reason := range identifierIssues(rules, dialect, col.Name, false) {
+					issues = append(issues, IdentifierIssue{Dialect: dialect, Tablename: table.Name, Columnname: col.Name, Reason: reason})
+				}
+			}
+		}
+		for _, enum := range s.Enums {
+			for _, reason := range identifierIssues(rules, dialect, typeName(enum.Name), true) {
+				issues = append(issues, IdentifierIssue{Dialect: dialect, Enumname: enum.Name, Reason: reason})
+			}
+			// enum labels are only identifiers once generated
+			if dialect != DialectGraphQL && dialect != DialectProtobuf {
+				continue
+			}
+			for _, value := range enum.Values {
+				label := strings.ToUpper(value.Label)
+				for _, reason := range identifierIssues(rules, dialect, label, false) {
+					issues = append(issues, IdentifierIssue{Dialect: dialect, Enumname: enum.Name, Label: value.Label, Reason: reason})
+				}
+				if dialect == DialectGraphQL && (label == "TRUE" || label == "FALSE" || label == "NULL") {
+					issues = append(issues, IdentifierIssue{Dialect: dialect, Enumname: enum.Name, Label: value.Label, Reason: fmt.Sprintf("%q cannot be a graphql enum value", value.Label)})
+				}
+			}
+		}
+	}
+	return issues, nil
+}
@@ -0,0 +1,154 @@
+package inverseschema
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Connection describes a database to introspect, the driver has to be registered by the caller
+type Connection struct {
+	Name       string `json:"name,omitempty"`
+	Driver     string `json:"driver,omitempty"`
+	DSN        string `json:"dsn,omitempty"`
+	Schemaname string `json:"schemaname,omitempty"`
+}
+
+// LoadConnections reads a JSON list of connections
+func LoadConnections(r io.Reader) ([]Connection, error) {
+	connections := []Connection{}
+	if err := json.NewDecoder(r).Decode(&connections); err != nil {
+		return nil, err
+	}
+	return connections, nil
+}
+
+type runnerTarget struct {
+	name    string
+	adapter Adapter
+	opts    []SchemaOption
+}
+
+// Runner introspects several named databases concurrently
+type Runner struct {
+	concurrency int
+	targets     []runnerTarget
+	dbs         []*sql.DB
+}
+
+// NewRunner returns a runner introspecting up to concurrency databases at once, unlimited when zero
+func NewRunner(concurrency int) *Runner {
+	return &Runner{concurrency: concurrency}
+}
+
+func (r *Runner) Add(name string, adapter Adapter, opts ...SchemaOption) {
+	r.targets = append(r.targets, runnerTarget{name: name, adapter: adapter, opts: opts})
+}
+
+// AddConnections opens every connection and adds a postgres adapter for it, the schema defaults to public,
+// Close releases the opened databases
+func (r *Runner) AddConnections(connections []Connection, opts ...PostgresOption) error {
+	for _, c := range connections {
+		db, err := sql.Open(c.Driver, c.DSN)
+		if err != nil {
+			return err
+		}
+		r.dbs = append(r.dbs, db)
+		schemaname := c.Schemaname
+		if len(schemaname) == 0 {
+			schemaname = "public"
+		}
+		r.Add(c.Name, NewPostgresAdapter(db, schemaname, opts...))
+	}
+	return nil
+}
+
+func (r *Runner) Close() error {
+	var err error
+	for _, db := range r.dbs {
+		if closeErr := db.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	r.dbs = nil
+	return err
+}
+
+type DatabaseResult struct {
+	Name     string        `json:"name,omitempty"`
+	Schema   *Schema       `json:"schema,omitempty"`
+	Tables   int           `json:"tables,omitempty"`
+	Columns  int           `json:"columns,omitempty"`
+	Enums    int           `json:"enums,omitempty"`
+	Duration time.Duration `json:"duration,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Err      error         `json:"-"`
+}
+
+// RunReport holds the result of every database ordered by name
+type RunReport struct {
+	Databases []DatabaseResult `json:"databases,omitempty"`
+}
+
+func (r *RunReport) Failed() []DatabaseResult {
+	failed := []DatabaseResult{}
+	for _, result := range r.Databases {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// Schemas maps the name of every successfully introspected database to its schema
+func (r *RunReport) Schemas() map[string]*Schema {
+	schemas := map[string]*Schema{}
+	for _, result := range r.Databases {
+		if result.Err == nil {
+			schemas[result.Name] = result.Schema
+		}
+	}
+	return schemas
+}
+
+// Run introspects every database, a failing database does not stop the others and is reported with its error
+func (r *Runner) Run(ctx context.Context) *RunReport {
+	report := &RunReport{Databases: make([]DatabaseResult, len(r.targets))}
+	concurrency := r.concurrency
+	if concurrency <= 0 {
+		concurrency = len(r.targets)
+	}
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, target := range r.targets {
+		wg.Add(1)
+		go func(i int, target runnerTarget) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			started := time.Now()
+			result := DatabaseResult{Name: target.name, Schema: NewSchema(target.adapter, target.opts...)}
+			if err := result.Schema.ParseContext(ctx); err != nil {
+				result.Err = err
+				result.Error = err.Error()
+			}
+			result.Duration = time.Since(started)
+			result.Tables = len(result.Schema.Tables)
+			result.Enums = len(result.Schema.Enums)
+			for _, table := range result.Schema.Tables {
+				result.Columns += len(table.Columns)
+			}
+			report.Databases[i] = result
+		}(i, target)
+	}
+	wg.Wait()
+	sort.SliceStable(report.Databases, func(i, j int) bool {
+		return report.Databases[i].Name < report.Databases[j].Name
+	})
+	return report
+}
@@ -0,0 +1,223 @@
+package sample
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"github.com/oiime/inverseschema"
+)
+
+// Options shape a generated schema, the same options always generate the same schema
+type Options struct {
+	Seed   int64
+	Tables int
+	Enums  int
+	// ForeignKeyDensity is the probability, between 0 and 1, of a table referencing each table generated before it
+	ForeignKeyDensity float64
+}
+
+type columnKind struct {
+	name        string
+	datatype    inverseschema.Datatype
+	datatypeRaw string
+	maxLength   int
+	nullable    bool
+	unique      bool
+	def         string
+}
+
+var tableNouns = []string{
+	"accounts", "users", "organizations", "projects", "orders", "invoices", "payments", "products", "categories",
+	"shipments", "addresses", "comments", "tags", "sessions", "subscriptions", "plans", "teams", "documents",
+	"events", "notifications", "reviews", "warehouses", "suppliers", "contracts", "tickets",
+}
+
+var enumNouns = []string{"status", "priority", "kind", "visibility", "currency", "tier", "channel", "region"}
+
+var enumLabels = []string{
+	"active", "inactive", "pending", "archived", "draft", "low", "medium", "high", "public", "private", "internal",
+	"usd", "eur", "gbp", "email", "sms", "push", "north", "south", "east", "west",
+}
+
+var columnKinds = []columnKind{
+	{name: "name", datatype: inverseschema.DatatypeVarchar, datatypeRaw: "character varying", maxLength: 200},
+	{name: "title", datatype: inverseschema.DatatypeText, datatypeRaw: "text"},
+	{name: "description", datatype: inverseschema.DatatypeText, datatypeRaw: "text", nullable: true},
+	{name: "email", datatype: inverseschema.DatatypeVarchar, datatypeRaw: "character varying", maxLength: 320, unique: true},
+	{name: "slug", datatype: inverseschema.DatatypeVarchar, datatypeRaw: "character varying", maxLength: 100, unique: true},
+	{name: "external_id", datatype: inverseschema.DatatypeUuid, datatypeRaw: "uuid", unique: true, def: "gen_random_uuid()"},
+	{name: "amount", datatype: inverseschema.DatatypeNumeric, datatypeRaw: "numeric"},
+	{name: "quantity", datatype: inverseschema.DatatypeInt, datatypeRaw: "integer", def: "0"},
+	{name: "is_enabled", datatype: inverseschema.DatatypeBoolean, datatypeRaw: "boolean", def: "true"},
+	{name: "metadata", datatype: inverseschema.DatatypeJsonb, datatypeRaw: "jsonb", nullable: true},
+	{name: "starts_on", datatype: inverseschema.DatatypeDate, datatypeRaw: "date", nullable: true},
+	{name: "updated_at", datatype: inverseschema.DatatypeTimestampz, datatypeRaw: "timestamp with time zone", nullable: true},
+	{name: "deleted_at", datatype: inverseschema.DatatypeTimestampz, datatypeRaw: "timestamp with time zone", nullable: true},
+	{name: "payload", datatype: inverseschema.DatatypeBytea, datatypeRaw: "bytea", nullable: true},
+}
+
+// numbered names the nth use of a word, repeating words once the list is exhausted
+func numbered(words []string, n int) string {
+	name := words[n%len(words)]
+	if round := n / len(words); round > 0 {
+		name = fmt.Sprintf("%s_%d", name, round+1)
+	}
+	return name
+}
+
+func singular(name string) string {
+	switch {
+	case strings.HasSuffix(name, "ies"):
+		return strings.TrimSuffix(name, "ies") + "y"
+	case strings.HasSuffix(name, "sses"), strings.HasSuffix(name, "xes"):
+		return strings.TrimSuffix(name, "es")
+	case strings.HasSuffix(name, "s"):
+		return strings.TrimSuffix(name, "s")
+	}
+	return name
+}
+
+type tableBuilder struct {
+	table inverseschema.Table
+}
+
+func (b *tableBuilder) add(col inverseschema.Column, constraints ...inverseschema.Constraint) {
+	col.OrdinalPosition = len(b.table.Columns) + 1
+	for _, c := range constraints {
+		c.Tablename = b.table.Name
+		c.Columnname = col.Name
+		col.Constraints = append(col.Constraints, c)
+		switch c.Type {
+		case inverseschema.ConstraintTypePrimaryKey:
+			col.IsPrimary = true
+		case inverseschema.ConstraintTypeForeignKey:
+			col.IsReference = true
+			col.ForeignTablename = c.ForeignTablename
+			col.ForeignColumnname = c.ForeignColumnname
+		case inverseschema.ConstraintTypeUnique:
+			col.IsUnique = true
+		}
+	}
+	b.table.Columns = append(b.table.Columns, col)
+	b.table.ColumnsByName[col.Name] = col
+}
+
+func (b *tableBuilder) index(name string, unique bool, primary bool, columns ...string) {
+	definition := "CREATE INDEX "
+	if unique {
+		definition = "CREATE UNIQUE INDEX "
+	}
+	definition += name + " ON public." + b.table.Name + " USING btree (" + strings.Join(columns, ", ") + ")"
+	b.table.Indexes = append(b.table.Indexes, inverseschema.Index{
+		Name:       name,
+		Columns:    columns,
+		IsUnique:   unique,
+		IsPrimary:  primary,
+		Method:     "btree",
+		Definition: definition,
+	})
+}
+
+// New generates a random but realistic schema: tables with a bigint primary key, timestamps, typed columns, enum columns
+// and foreign keys to earlier tables (keeping the reference graph acyclic), constraints and indexes are named the way
+// postgres names them
+func New(o Options) *inverseschema.Schema {
+	r := rand.New(rand.NewSource(o.Seed))
+	schema := &inverseschema.Schema{Tables: []inverseschema.Table{}, Enums: []inverseschema.Enum{}}
+
+	for i := 0; i < o.Enums; i++ {
+		enum := inverseschema.Enum{Name: numbered(enumNouns, i)}
+		start := r.Intn(len(enumLabels))
+		count := 2 + r.Intn(4)
+		for j := 0; j < count && j < len(enumLabels); j++ {
+			enum.Values = append(enum.Values, inverseschema.EnumValue{Label: enumLabels[(start+j)%len(enumLabels)], Order: j + 1})
+		}
+		schema.Enums = append(schema.Enums, enum)
+	}
+
+	for i := 0; i < o.Tables; i++ {
+		b := &tableBuilder{table: inverseschema.Table{
+			Name:          numbered(tableNouns, i),
+			ColumnsByName: map[string]inverseschema.Column{},
+		}}
+		name := b.table.Name
+
+		b.add(inverseschema.Column{
+			Name:        "id",
+			Datatype:    inverseschema.DatatypeBigint,
+			DatatypeRaw: "bigint",
+			HasDefault:  true,
+			Default:     "nextval('" + name + "_id_seq'::regclass)",
+		}, inverseschema.Constraint{Name: name + "_pkey", Type: inverseschema.ConstraintTypePrimaryKey})
+		b.index(name+"_pkey", true, true, "id")
+
+		for j, parent := range schema.Tables {
+			if r.Float64() >= o.ForeignKeyDensity {
+				continue
+			}
+			colname := singular(parent.Name) + "_id"
+			if _, ok := b.table.ColumnsByName[colname]; ok {
+				colname = fmt.Sprintf("%s_%d_id", singular(parent.Name), j)
+			}
+			b.add(inverseschema.Column{
+				Name:        colname,
+				Datatype:    inverseschema.DatatypeBigint,
+				DatatypeRaw: "bigint",
+				IsNullable:  r.Intn(3) == 0,
+			}, inverseschema.Constraint{
+				Name:              name + "_" + colname + "_fkey",
+				Type:              inverseschema.ConstraintTypeForeignKey,
+				ForeignTablename:  parent.Name,
+				ForeignColumnname: "id",
+			})
+			b.index(name+"_"+colname+"_idx", false, false, colname)
+		}
+
+		for _, k := range r.Perm(len(columnKinds))[:2+r.Intn(5)] {
+			kind := columnKinds[k]
+			col := inverseschema.Column{
+				Name:               kind.name,
+				Datatype:           kind.datatype,
+				DatatypeRaw:        kind.datatypeRaw,
+				CharacterMaxLength: kind.maxLength,
+				IsNullable:         kind.nullable,
+				HasDefault:         len(kind.def) > 0,
+				Default:            kind.def,
+			}
+			if !kind.unique {
+				b.add(col)
+				continue
+			}
+			b.add(col, inverseschema.Constraint{Name: name + "_" + kind.name + "_key", Type: inverseschema.ConstraintTypeUnique})
+			b.index(name+"_"+kind.name+"_key", true, false, kind.name)
+		}
+
+		if len(schema.Enums) > 0 && r.Intn(2) == 0 {
+			enum := schema.Enums[r.Intn(len(schema.Enums))]
+			b.add(inverseschema.Column{
+				Name:            enum.Name,
+				Datatype:        inverseschema.DatatypeUserdefined,
+				DatatypeRaw:     "USER-DEFINED",
+				IsUserDefined:   true,
+				UserDefinedType: &inverseschema.UserDefinedType{Name: enum.Name, Schema: "public"},
+				HasDefault:      true,
+				Default:         "'" + enum.Values[0].Label + "'::" + enum.Name,
+			})
+		}
+
+		b.add(inverseschema.Column{
+			Name:        "created_at",
+			Datatype:    inverseschema.DatatypeTimestampz,
+			DatatypeRaw: "timestamp with time zone",
+			HasDefault:  true,
+			Default:     "now()",
+		})
+		sort.Slice(b.table.Indexes, func(i, j int) bool {
+			return b.table.Indexes[i].Name < b.table.Indexes[j].Name
+		})
+		schema.Tables = append(schema.Tables, b.table)
+	}
+	return schema
+}
@@ -0,0 +1,109 @@
+package inverseschema
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// NewSchemaRegistryAdapter reads event schemas from a Confluent compatible schema registry, the latest version of
+// every subject (or of the given subjects) becomes a table named after the subject. Avro records and JSON Schema
+// objects are supported, protobuf subjects are skipped
+func NewSchemaRegistryAdapter(baseURL string, httpClient *http.Client, subjects ...string) *SchemaRegistryAdapter {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &SchemaRegistryAdapter{baseURL: strings.TrimRight(baseURL, "/"), httpClient: httpClient, subjects: subjects}
+}
+
+type SchemaRegistryAdapter struct {
+	baseURL    string
+	httpClient *http.Client
+	subjects   []string
+}
+
+type schemaRegistryVersion struct {
+	Subject    string `json:"subject"`
+	Version    int    `json:"version"`
+	ID         int    `json:"id"`
+	SchemaType string `json:"schemaType"`
+	Schema     string `json:"schema"`
+}
+
+func (a *SchemaRegistryAdapter) get(ctx context.Context, path string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.schemaregistry.v1+json, application/json")
+	res, err := a.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("schema registry responded with %d: %s", res.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+// Tables reads the latest version of the subjects
+func (a *SchemaRegistryAdapter) Tables(ctx context.Context) ([]Table, error) {
+	tables, _, err := a.read(ctx)
+	return tables, err
+}
+
+// Enums reads the latest version of the subjects and returns the enums of their Avro schemas
+func (a *SchemaRegistryAdapter) Enums(ctx context.Context) ([]Enum, error) {
+	_, enums, err := a.read(ctx)
+	return enums, err
+}
+
+// read maps the latest version of every subject to a table along with the enums of Avro schemas
+func (a *SchemaRegistryAdapter) read(ctx context.Context) ([]Table, []Enum, error) {
+	subjects := a.subjects
+	if len(subjects) == 0 {
+		if err := a.get(ctx, "/subjects", &subjects); err != nil {
+			return nil, nil, err
+		}
+		sort.Strings(subjects)
+	}
+
+	tables := []Table{}
+	enums := []Enum{}
+	seen := map[string]bool{}
+	for _, subject := range subjects {
+		version := schemaRegistryVersion{}
+		if err := a.get(ctx, "/subjects/"+url.PathEscape(subject)+"/versions/latest", &version); err != nil {
+			return nil, nil, fmt.Errorf("subject %s: %w", subject, err)
+		}
+		var table Table
+		var err error
+		switch version.SchemaType {
+		case "", "AVRO":
+			var subjectEnums []Enum
+			table, subjectEnums, err = avroTable(subject, version.Schema)
+			for _, enum := range subjectEnums {
+				if !seen[enum.Name] {
+					seen[enum.Name] = true
+					enums = append(enums, enum)
+				}
+			}
+		case "JSON":
+			table, err = jsonSchemaTable(subject, version.Schema)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		tables = append(tables, table)
+	}
+	return tables, enums, nil
+}
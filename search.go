@@ -0,0 +1,88 @@
+package inverseschema
+
+import (
+	"sort"
+	"strings"
+)
+
+type SearchKind int
+
+const (
+	SearchKindTable SearchKind = iota + 1
+	SearchKindColumn
+	SearchKindEnum
+	SearchKindEnumValue
+)
+
+type SearchResult struct {
+	Kind       SearchKind `json:"kind,omitempty"`
+	Tablename  string     `json:"tablename,omitempty"`
+	Columnname string     `json:"columnname,omitempty"`
+	Enumname   string     `json:"enumname,omitempty"`
+	Label      string     `json:"label,omitempty"`
+	Score      int        `json:"score,omitempty"`
+}
+
+// scores per term, names outrank comments and exact matches outrank prefixes and substrings
+const (
+	searchScoreExact     = 100
+	searchScorePrefix    = 50
+	searchScoreSubstring = 20
+	searchScoreComment   = 5
+)
+
+func searchScore(terms []string, name string, comments string) int {
+	name = strings.ToLower(name)
+	comments = strings.ToLower(comments)
+	total := 0
+	for _, term := range terms {
+		switch {
+		case name == term:
+			total += searchScoreExact
+		case strings.HasPrefix(name, term):
+			total += searchScorePrefix
+		case strings.Contains(name, term):
+			total += searchScoreSubstring
+		case strings.Contains(comments, term):
+			total += searchScoreComment
+		default:
+			// every term has to match
+			return 0
+		}
+	}
+	return total
+}
+
+// Search matches the whitespace separated terms of query against table names, column names, comments and enum labels,
+// results are ordered by descending score
+func (s *Schema) Search(query string) []SearchResult {
+	terms := strings.Fields(strings.ToLower(query))
+	results := []SearchResult{}
+	if len(terms) == 0 {
+		return results
+	}
+	for _, table := range s.Tables {
+		if score := searchScore(terms, table.Name, table.Comments); score > 0 {
+			results = append(results, SearchResult{Kind: SearchKindTable, Tablename: table.Name, Score: score})
+		}
+		for _, col := range table.Columns {
+			if score := searchScore(terms, col.Name, col.Comments); score > 0 {
+				results = append(results, SearchResult{Kind: SearchKindColumn, Tablename: table.Name, Columnname: col.Name, Score: score})
+			}
+		}
+	}
+	for _, enum := range s.Enums {
+		if score := searchScore(terms, enum.Name, enum.Comments); score > 0 {
+			results = append(results, SearchResult{Kind: SearchKindEnum, Enumname: enum.Name, Score: score})
+		}
+		for _, value := range enum.Values {
+			if score := searchScore(terms, value.Label, ""); score > 0 {
+				results = append(results, SearchResult{Kind: SearchKindEnumValue, Enumname: enum.Name, Label: value.Label, Score: score})
+			}
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	return results
+}
@@ -0,0 +1,76 @@
+package inverseschema
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type VersionBump string
+
+const (
+	BumpNone  VersionBump = "none"
+	BumpPatch VersionBump = "patch"
+	BumpMinor VersionBump = "minor"
+	BumpMajor VersionBump = "major"
+)
+
+// additions are the changes extending the API, they call for a minor version
+var additions = map[ChangeKind]bool{
+	ChangeTableAdded:     true,
+	ChangeColumnAdded:    true,
+	ChangeEnumAdded:      true,
+	ChangeEnumValueAdded: true,
+}
+
+// SuggestBump recommends the semantic version bump of a service whose API is generated from the schema: major for
+// breaking changes, minor for potentially breaking changes and additions, patch for the other safe changes
+func (c *ChangeSet) SuggestBump() VersionBump {
+	bump := BumpNone
+	for _, change := range c.Changes {
+		switch {
+		case change.Severity == SeverityBreaking:
+			return BumpMajor
+		case change.Severity == SeverityPotentiallyBreaking || additions[change.Kind]:
+			bump = BumpMinor
+		case bump == BumpNone:
+			bump = BumpPatch
+		}
+	}
+	return bump
+}
+
+// NextVersion applies a bump to a semantic version such as "1.4.2" or "v1.4.2", keeping the "v" prefix and dropping
+// pre-release and build metadata. Below 1.0.0 a major bump raises the minor version, breaking changes being allowed
+// in 0.y.z releases
+func NextVersion(version string, bump VersionBump) (string, error) {
+	prefix := ""
+	if strings.HasPrefix(version, "v") {
+		prefix, version = "v", version[1:]
+	}
+	if i := strings.IndexAny(version, "-+"); i >= 0 {
+		version = version[:i]
+	}
+	parts := strings.Split(version, ".")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("invalid semantic version %s", prefix+version)
+	}
+	numbers := make([]int, 3)
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return "", fmt.Errorf("invalid semantic version %s", prefix+version)
+		}
+		numbers[i] = n
+	}
+	major, minor, patch := numbers[0], numbers[1], numbers[2]
+	switch {
+	case bump == BumpMajor && major > 0:
+		major, minor, patch = major+1, 0, 0
+	case bump == BumpMajor || bump == BumpMinor:
+		minor, patch = minor+1, 0
+	case bump == BumpPatch:
+		patch++
+	}
+	return fmt.Sprintf("%s%d.%d.%d", prefix, major, minor, patch), nil
+}
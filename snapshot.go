@@ -0,0 +1,149 @@
+package inverseschema
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+)
+
+func (s *Schema) WriteSnapshot(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+func LoadSnapshot(r io.Reader) (*Schema, error) {
+	schema := &Schema{}
+	if err := json.NewDecoder(r).Decode(schema); err != nil {
+		return nil, err
+	}
+	schema.adapter = NewSnapshotAdapter(schema)
+	return schema, nil
+}
+
+func NewSnapshotAdapter(snapshot *Schema) *SnapshotAdapter {
+	return &SnapshotAdapter{snapshot: snapshot}
+}
+
+type SnapshotAdapter struct {
+	snapshot *Schema
+}
+
+// deepCopy copies src into dst through its JSON encoding, so that no slice, map or pointer is shared
+func deepCopy(src interface{}, dst interface{}) error {
+	encoded, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(encoded, dst)
+}
+
+// Tables returns a copy of the snapshot tables, parsing mutates the tables it gets (overlays, history pairing,
+// natural keys) and must not change the snapshot
+func (a *SnapshotAdapter) Tables(ctx context.Context) ([]Table, error) {
+	tables := []Table{}
+	if err := deepCopy(a.snapshot.Tables, &tables); err != nil {
+		return nil, err
+	}
+	return tables, nil
+}
+
+func (a *SnapshotAdapter) Enums(ctx context.Context) ([]Enum, error) {
+	enums := []Enum{}
+	if err := deepCopy(a.snapshot.Enums, &enums); err != nil {
+		return nil, err
+	}
+	return enums, nil
+}
+
+// ServerVersion returns the version the snapshot was parsed from, ErrNotSupported when it was not recorded
+func (a *SnapshotAdapter) ServerVersion(ctx context.Context) (int, error) {
+	if a.snapshot.ServerVersion == 0 {
+		return 0, ErrNotSupported
+	}
+	return a.snapshot.ServerVersion, nil
+}
+
+// NewOverlayAdapter serves everything from base except for the given tables which are always fetched from live
+func NewOverlayAdapter(base Adapter, live Adapter, tablenames ...string) *OverlayAdapter {
+	a := &OverlayAdapter{base: base, live: live, tablenames: make(map[string]bool, len(tablenames))}
+	for _, tablename := range tablenames {
+		a.tablenames[tablename] = true
+	}
+	return a
+}
+
+type OverlayAdapter struct {
+	base       Adapter
+	live       Adapter
+	tablenames map[string]bool
+}
+
+func (a *OverlayAdapter) Tables(ctx context.Context) ([]Table, error) {
+	tables, err := a.base.Tables(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(a.tablenames) == 0 {
+		return tables, nil
+	}
+	liveTables, err := a.liveTables(ctx)
+	if err != nil {
+		return nil, err
+	}
+	fresh := map[string]Table{}
+	for _, table := range liveTables {
+		if a.tablenames[table.Name] {
+			fresh[table.Name] = table
+		}
+	}
+
+	result := make([]Table, 0, len(tables))
+	for _, table := range tables {
+		if !a.tablenames[table.Name] {
+			result = append(result, table)
+			continue
+		}
+		// a hot table dropped from the live database is dropped from the result as well
+		if table, ok := fresh[table.Name]; ok {
+			result = append(result, table)
+			delete(fresh, table.Name)
+		}
+	}
+	for _, table := range liveTables {
+		if _, ok := fresh[table.Name]; ok {
+			result = append(result, table)
+		}
+	}
+	return result, nil
+}
+
+// liveTables fetches the hot tables one by one when live supports it, rather than listing every live table
+func (a *OverlayAdapter) liveTables(ctx context.Context) ([]Table, error) {
+	adapter, ok := a.live.(TableAdapter)
+	if !ok {
+		return a.live.Tables(ctx)
+	}
+	names := make([]string, 0, len(a.tablenames))
+	for name := range a.tablenames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	tables := []Table{}
+	for _, name := range names {
+		table, err := adapter.Table(ctx, name)
+		if errors.Is(err, ErrTableNotFound) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		tables = append(tables, *table)
+	}
+	return tables, nil
+}
+
+func (a *OverlayAdapter) Enums(ctx context.Context) ([]Enum, error) {
+	return a.base.Enums(ctx)
+}
@@ -0,0 +1,61 @@
+package inverseschema
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+var fuzzSnapshots = []string{
+	`{}`,
+	`{"tables":[{"name":"users","columns":[{"name":"id","datatype":1,"is_primary":true}]}]}`,
+	`{"tables":[{"name":"orders","columns":[{"name":"id","is_primary":true,"has_default":true,"default":"nextval('orders_id_seq'::regclass)"},` +
+		`{"name":"user_id","is_reference":true,"foreign_tablename":"users","foreign_columnname":"id","constraints":[{"name":"orders_user_id_fkey","type":3,"foreign_tablename":"users","foreign_columnname":"id"}]}],` +
+		`"indexes":[{"name":"orders_user_id_idx","definition":"CREATE INDEX orders_user_id_idx ON public.orders USING btree (user_id) INCLUDE (id)"}]},` +
+		`{"name":"users","columns":[{"name":"id","is_user_defined":true,"user_defined_type":{"name":"uid"}}],"partitioning":{"strategy":"list","definition":"LIST (id)"}}],` +
+		`"enums":[{"name":"status","values":[{"label":"a","order":1},{"label":"b","order":2}]}]}`,
+	`{"tables":[{"name":"t","columns":[{"name":"c","is_user_defined":true}]}],"views":[{"name":"v","view_definition":"SELECT c FROM t"}]}`,
+}
+
+// fuzzConsumers runs the consumers of a loaded snapshot, none of them may panic on a document LoadSnapshot accepts
+func fuzzConsumers(t *testing.T, schema *Schema) {
+	schema.BaselineSQL()
+	schema.CloneSQL()
+	schema.SeedOrder()
+	CompareSchemas(&Schema{}, schema, nil).WriteChangelog(io.Discard, "")
+	CompareSchemas(schema, schema, nil)
+	if err := schema.WriteSnapshot(io.Discard); err != nil {
+		t.Fatalf("writing a loaded snapshot: %v", err)
+	}
+}
+
+func FuzzLoadSnapshot(f *testing.F) {
+	for _, snapshot := range fuzzSnapshots {
+		f.Add([]byte(snapshot))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		schema, err := LoadSnapshot(bytes.NewReader(data))
+		if err != nil {
+			return
+		}
+		fuzzConsumers(t, schema)
+	})
+}
+
+func FuzzValidateIR(f *testing.F) {
+	for _, snapshot := range fuzzSnapshots {
+		f.Add([]byte(snapshot), false)
+		f.Add([]byte(snapshot), true)
+	}
+	f.Fuzz(func(t *testing.T, data []byte, strict bool) {
+		if err := ValidateIR(data, strict); err != nil {
+			return
+		}
+		// a valid document loads as a snapshot
+		schema, err := LoadSnapshot(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("valid IR document does not load: %v", err)
+		}
+		fuzzConsumers(t, schema)
+	})
+}
@@ -0,0 +1,199 @@
+package inverseschema
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+)
+
+type sqitchChange struct {
+	Name         string
+	Dependencies string
+	Timestamp    string
+	Note         string
+	Deploy       string
+	Revert       string
+	Verify       string
+	// tables and enums rendered by the change scripts, recorded in the manifest
+	tables []string
+	enums  []string
+}
+
+type sqitchPlan struct {
+	Project string
+	Changes []sqitchChange
+}
+
+var sqitchTemplate = template.Must(template.New("sqitch").Parse(`{{define "plan"}}%syntax-version=1.0.0
+%project={{.Project}}
+
+{{range .Changes}}{{.Name}} {{if .Dependencies}}[{{.Dependencies}}] {{end}}{{.Timestamp}} inverseschema <inverseschema@localhost>{{if .Note}} # {{.Note}}{{end}}
+{{end}}{{end}}{{define "deploy"}}-- Deploy {{.Name}}
+
+BEGIN;
+
+{{.Deploy}}
+
+COMMIT;
+{{end}}{{define "revert"}}-- Revert {{.Name}}
+
+BEGIN;
+
+{{.Revert}}
+
+COMMIT;
+{{end}}{{define "verify"}}-- Verify {{.Name}}
+
+BEGIN;
+
+{{.Verify}}
+
+ROLLBACK;
+{{end}}`))
+
+var sqitchInvalidNameRe = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+func sqitchChangeName(prefix string, name string) string {
+	return prefix + "_" + sqitchInvalidNameRe.ReplaceAllString(name, "_")
+}
+
+var sqitchPlannedRe = regexp.MustCompile(`^(\S+) (?:\[[^\]]*\] )?(\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}Z) `)
+
+// sqitchPlanned reads the planned timestamps of the changes of an existing plan, they are kept so that regenerating
+// an unchanged schema leaves the plan untouched
+func sqitchPlanned(dir string) (map[string]string, error) {
+	planned := map[string]string{}
+	data, err := os.ReadFile(filepath.Join(dir, "sqitch.plan"))
+	if errors.Is(err, os.ErrNotExist) {
+		return planned, nil
+	} else if err != nil {
+		return nil, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if match := sqitchPlannedRe.FindStringSubmatch(line); match != nil {
+			planned[match[1]] = match[2]
+		}
+	}
+	return planned, nil
+}
+
+// WriteSqitch writes a sqitch project and a manifest into dir: a plan with a change per enum and table, tables depending on the
+// enums they use and on the tables they reference, alongside their deploy, revert and verify scripts. The package
+// option sets the project name, foreign key cycles between tables cannot be planned and fail. Changes already in the
+// plan of dir keep their planned date, new ones are planned now
+func (s *Schema) WriteSqitch(dir string, opts ...GeneratorOption) error {
+	o := newGeneratorOptions("schema", opts)
+	plan := sqitchPlan{Project: o.packageName}
+	planned, err := sqitchPlanned(dir)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC().Format("2006-01-02T15:04:05Z")
+	timestamp := func(name string) string {
+		if at, ok := planned[name]; ok {
+			return at
+		}
+		return now
+	}
+	collisions := newNameCollisions("sqitch")
+
+	enumChanges := map[string]string{}
+	for _, enum := range s.Enums {
+		name := sqitchChangeName("enum", enum.Name)
+		change := sqitchChange{
+			Name:      name,
+			Timestamp: timestamp(name),
+			Note:      singleLine(enum.Comments),
+			Deploy:    CreateEnumSQL(enum),
+			Revert:    "DROP TYPE " + quoteIdent(enum.Name) + ";",
+			Verify:    "SELECT 1/count(*) FROM pg_catalog.pg_type WHERE typname = " + quoteLiteral(enum.Name) + ";",
+			enums:     []string{enum.Name},
+		}
+		collisions.addEnum(change.Name, enum)
+		enumChanges[enum.Name] = change.Name
+		plan.Changes = append(plan.Changes, change)
+	}
+
+	tables, cyclic := dependencyOrder(s.Tables)
+	if len(cyclic) > 0 {
+		return fmt.Errorf("foreign key cycle between tables %s", strings.Join(cyclic, ", "))
+	}
+	tableChanges := map[string]string{}
+	for _, table := range tables {
+		tableChanges[table.Name] = sqitchChangeName("table", table.Name)
+	}
+	for _, table := range tables {
+		dependencies := []string{}
+		seen := map[string]bool{}
+		for _, col := range table.Columns {
+			if col.IsUserDefined && col.UserDefinedType != nil {
+				if name, ok := enumChanges[col.UserDefinedType.Name]; ok && !seen[name] {
+					seen[name] = true
+					dependencies = append(dependencies, name)
+				}
+			}
+		}
+		for _, tablename := range referencedTables(table) {
+			if name, ok := tableChanges[tablename]; ok {
+				dependencies = append(dependencies, name)
+			}
+		}
+		columns := []string{}
+		for _, col := range table.Columns {
+			if !col.IsVirtual {
+				columns = append(columns, quoteIdent(col.Name))
+			}
+		}
+		change := sqitchChange{
+			Name:         tableChanges[table.Name],
+			Dependencies: strings.Join(dependencies, " "),
+			Timestamp:    timestamp(tableChanges[table.Name]),
+			Note:         singleLine(table.Comments),
+			Deploy:       CreateTableSQL(table),
+			Revert:       "DROP TABLE " + quoteIdent(table.Name) + ";",
+			Verify:       "SELECT " + strings.Join(columns, ", ") + " FROM " + quoteIdent(table.Name) + " WHERE FALSE;",
+			tables:       []string{table.Name},
+		}
+		collisions.addTable(change.Name, table)
+		plan.Changes = append(plan.Changes, change)
+	}
+	if err := collisions.err(); err != nil {
+		return err
+	}
+
+	tmpl, err := o.template("sqitch", sqitchTemplate)
+	if err != nil {
+		return err
+	}
+	out, err := s.newGeneratorOutput(dir, "sqitch", o)
+	if err != nil {
+		return err
+	}
+	for _, sub := range []string{"deploy", "revert", "verify"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			return err
+		}
+		for _, change := range plan.Changes {
+			if err := out.write(filepath.Join(sub, change.Name+".sql"), tmpl, sub, change, change.tables, change.enums); err != nil {
+				return err
+			}
+		}
+	}
+	tablenames := make([]string, len(s.Tables))
+	for i, table := range s.Tables {
+		tablenames[i] = table.Name
+	}
+	enumnames := make([]string, len(s.Enums))
+	for i, enum := range s.Enums {
+		enumnames[i] = enum.Name
+	}
+	if err := out.write("sqitch.plan", tmpl, "plan", plan, tablenames, enumnames); err != nil {
+		return err
+	}
+	return out.close()
+}
@@ -0,0 +1,368 @@
+package inverseschema
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+type sqlalchemyType struct {
+	column  string
+	python  string
+	imports []string
+}
+
+var sqlalchemyDatatypes = map[Datatype]sqlalchemyType{
+	DatatypeBigint:          {"BigInteger", "int", []string{"sqlalchemy:BigInteger"}},
+	DatatypeInt:             {"Integer", "int", []string{"sqlalchemy:Integer"}},
+	DatatypeSmallint:        {"SmallInteger", "int", []string{"sqlalchemy:SmallInteger"}},
+	DatatypeDecimal:         {"Numeric", "decimal.Decimal", []string{"sqlalchemy:Numeric", "decimal"}},
+	DatatypeNumeric:         {"Numeric", "decimal.Decimal", []string{"sqlalchemy:Numeric", "decimal"}},
+	DatatypeVariableNumeric: {"Numeric", "decimal.Decimal", []string{"sqlalchemy:Numeric", "decimal"}},
+	DatatypeJsonb:           {"JSONB", "Any", []string{"sqlalchemy.dialects.postgresql:JSONB", "typing:Any"}},
+	DatatypeJson:            {"JSON", "Any", []string{"sqlalchemy:JSON", "typing:Any"}},
+	DatatypeText:            {"Text", "str", []string{"sqlalchemy:Text"}},
+	DatatypeVarchar:         {"String", "str", []string{"sqlalchemy:String"}},
+	DatatypeBoolean:         {"Boolean", "bool", []string{"sqlalchemy:Boolean"}},
+	DatatypeDate:            {"Date", "datetime.date", []string{"sqlalchemy:Date", "datetime"}},
+	DatatypeTimestamp:       {"DateTime", "datetime.datetime", []string{"sqlalchemy:DateTime", "datetime"}},
+	DatatypeTimestampz:      {"DateTime(timezone=True)", "datetime.datetime", []string{"sqlalchemy:DateTime", "datetime"}},
+	DatatypeUuid:            {"Uuid", "uuid.UUID", []string{"sqlalchemy:Uuid", "uuid"}},
+	DatatypeBytea:           {"LargeBinary", "bytes", []string{"sqlalchemy:LargeBinary"}},
+}
+
+var pythonKeywords = map[string]bool{
+	"False": true, "None": true, "True": true, "and": true, "as": true, "assert": true, "async": true, "await": true,
+	"break": true, "class": true, "continue": true, "def": true, "del": true, "elif": true, "else": true, "except": true,
+	"finally": true, "for": true, "from": true, "global": true, "if": true, "import": true, "in": true, "is": true,
+	"lambda": true, "nonlocal": true, "not": true, "or": true, "pass": true, "raise": true, "return": true, "try": true,
+	"while": true, "with": true, "yield": true, "metadata": true, "registry": true,
+}
+
+func pythonBool(b bool) string {
+	if b {
+		return "True"
+	}
+	return "False"
+}
+
+var pythonInvalidIdentRe = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+func pythonIdent(name string) string {
+	name = pythonInvalidIdentRe.ReplaceAllString(name, "_")
+	if len(name) == 0 || (name[0] >= '0' && name[0] <= '9') {
+		name = "_" + name
+	}
+	if pythonKeywords[name] {
+		name += "_"
+	}
+	return name
+}
+
+var sqlalchemyTemplate = template.Must(template.New("sqlalchemy").Parse(`{{define "file"}}from __future__ import annotations
+{{range .Modules}}
+import {{.}}{{end}}
+{{range .FromImports}}
+from {{.}}{{end}}
+
+
+class Base(DeclarativeBase):
+    pass
+{{range .Enums}}
+
+{{template "enum" .}}{{end}}{{range .Models}}
+
+{{if .Keyless}}{{template "table" .}}{{else}}{{template "model" .}}{{end}}{{end}}{{end}}
+
+{{define "enum"}}class {{.Name}}(enum.Enum):
+{{range .Values}}    {{.Name}} = {{.Label}}
+{{end}}{{end}}
+
+{{define "model"}}class {{.Name}}(Base):
+{{if .Comment}}    """{{.Comment}}"""
+
+{{end}}    __tablename__ = {{.Tablename}}{{if .IsView}}
+    __table_args__ = {"info": {"is_view": True}}{{end}}
+
+{{range .Columns}}{{template "column" .}}{{end}}{{if .Relationships}}
+{{range .Relationships}}{{template "relationship" .}}{{end}}{{end}}{{end}}
+
+{{define "table"}}{{.Variable}} = Table(
+    {{.Tablename}},
+    Base.metadata,
+{{range .Columns}}    Column({{.Core}}),
+{{end}}    info={"is_view": True},{{if .TableComment}}
+    comment={{.TableComment}},{{end}}
+)
+{{end}}
+
+{{define "column"}}    {{.Name}}: Mapped[{{.Type}}] = mapped_column({{.Args}})
+{{end}}
+
+{{define "relationship"}}    {{.Name}}: Mapped[{{.Type}}] = relationship({{.Args}})
+{{end}}`))
+
+type sqlalchemyFile struct {
+	Modules     []string
+	FromImports []string
+	Enums       []sqlalchemyEnum
+	Models      []sqlalchemyModel
+}
+
+type sqlalchemyEnum struct {
+	Name   string
+	Values []sqlalchemyEnumValue
+}
+
+type sqlalchemyEnumValue struct {
+	Name  string
+	Label string
+}
+
+type sqlalchemyModel struct {
+	Name      string
+	Tablename string
+	Comment   string
+	IsView    bool
+	// Keyless views are rendered as a Table of the metadata named Variable, ORM models need a key
+	Keyless       bool
+	Variable      string
+	TableComment  string
+	Columns       []sqlalchemyAttribute
+	Relationships []sqlalchemyAttribute
+}
+
+type sqlalchemyAttribute struct {
+	Name string
+	Type string
+	Args string
+	// Core holds the arguments of the Column of a keyless view
+	Core string
+}
+
+type sqlalchemyImports map[string]map[string]bool
+
+func (i sqlalchemyImports) add(imports ...string) {
+	for _, imp := range imports {
+		module, name := imp, ""
+		if idx := strings.Index(imp, ":"); idx >= 0 {
+			module, name = imp[:idx], imp[idx+1:]
+		}
+		if i[module] == nil {
+			i[module] = map[string]bool{}
+		}
+		if len(name) > 0 {
+			i[module][name] = true
+		}
+	}
+}
+
+func (i sqlalchemyImports) render(file *sqlalchemyFile) {
+	for module, names := range i {
+		if len(names) == 0 {
+			file.Modules = append(file.Modules, module)
+			continue
+		}
+		list := make([]string, 0, len(names))
+		for name := range names {
+			list = append(list, name)
+		}
+		sort.Strings(list)
+		file.FromImports = append(file.FromImports, module+" import "+strings.Join(list, ", "))
+	}
+	sort.Strings(file.Modules)
+	sort.Strings(file.FromImports)
+}
+
+// WriteSQLAlchemy emits SQLAlchemy declarative models, every foreign key to a table within the schema becomes a
+// many-to-one relationship() with a one-to-many back reference on the referenced model, or a scalar back reference
+// (uselist=False) when the foreign key column is unique. Views rendered with WithViews are flagged in the table info,
+// views without a key are rendered as a Table of the metadata rather than a model
+func (s *Schema) WriteSQLAlchemy(w io.Writer, opts ...GeneratorOption) error {
+	o := newGeneratorOptions("", opts)
+	tmpl, err := o.template("sqlalchemy", sqlalchemyTemplate)
+	if err != nil {
+		return err
+	}
+	file := sqlalchemyFile{}
+	imports := sqlalchemyImports{}
+	imports.add("sqlalchemy.orm:DeclarativeBase", "sqlalchemy.orm:Mapped", "sqlalchemy.orm:mapped_column")
+
+	collisions := newNameCollisions("sqlalchemy")
+	enumTypes := map[string]string{}
+	for _, enum := range s.Enums {
+		imports.add("enum")
+		e := sqlalchemyEnum{Name: pascalCase(enum.Name)}
+		collisions.addEnum(e.Name, enum)
+		for _, value := range enum.Values {
+			name := pythonIdent(value.Label)
+			collisions.addEnumValue(name, enum, value)
+			e.Values = append(e.Values, sqlalchemyEnumValue{Name: name, Label: strconv.Quote(value.Label)})
+		}
+		enumTypes[enum.Name] = e.Name
+		file.Enums = append(file.Enums, e)
+	}
+
+	tables := o.tables(s)
+	models := map[string]*sqlalchemyModel{}
+	attributes := map[string]map[string]bool{}
+	columnAttributes := map[string]string{}
+	for _, table := range tables {
+		models[table.Name] = &sqlalchemyModel{
+			Name:      tableTypeName(table),
+			Tablename: strconv.Quote(table.Name),
+			Comment:   strings.ReplaceAll(singleLine(table.Comments), `"""`, `\"\"\"`),
+			IsView:    table.IsView,
+			Keyless:   keylessView(table),
+			Variable:  pythonIdent(table.Name + "_table"),
+		}
+		if models[table.Name].Keyless {
+			imports.add("sqlalchemy:Table", "sqlalchemy:Column")
+			if len(table.Comments) > 0 {
+				models[table.Name].TableComment = strconv.Quote(singleLine(table.Comments))
+			}
+		}
+		collisions.addTable(models[table.Name].Name, table)
+		attributes[table.Name] = map[string]bool{}
+	}
+	if err := collisions.err(); err != nil {
+		return err
+	}
+	uniqueAttribute := func(tablename string, name string) string {
+		name = pythonIdent(name)
+		for candidate, i := name, 2; ; i++ {
+			if !attributes[tablename][candidate] {
+				attributes[tablename][candidate] = true
+				return candidate
+			}
+			candidate = fmt.Sprintf("%s_%d", name, i)
+		}
+	}
+
+	for _, table := range tables {
+		model := models[table.Name]
+		for _, col := range table.Columns {
+			col = opaqueColumn(col)
+			attr := sqlalchemyAttribute{Name: uniqueAttribute(table.Name, columnAttributeName(col))}
+			columnAttributes[table.Name+"."+col.Name] = attr.Name
+			args := []string{}
+
+			typ := sqlalchemyType{column: "NullType()", python: "Any", imports: []string{"sqlalchemy.types:NullType", "typing:Any"}}
+			if col.IsUserDefined && col.UserDefinedType != nil {
+				if enumType, ok := enumTypes[col.UserDefinedType.Name]; ok {
+					// SQLAlchemy persists member names by default, the postgres labels are the member values
+					typ = sqlalchemyType{
+						column:  fmt.Sprintf("Enum(%s, name=%s, values_callable=lambda e: [m.value for m in e])", enumType, strconv.Quote(col.UserDefinedType.Name)),
+						python:  enumType,
+						imports: []string{"sqlalchemy:Enum"},
+					}
+				}
+			} else if t, ok := sqlalchemyDatatypes[col.Datatype]; ok {
+				typ = t
+			}
+			if col.Datatype == DatatypeVarchar && col.CharacterMaxLength > 0 {
+				typ.column = fmt.Sprintf("String(%d)", col.CharacterMaxLength)
+			}
+			imports.add(typ.imports...)
+			if col.IsArray {
+				imports.add("sqlalchemy.dialects.postgresql:ARRAY", "typing:List")
+				typ.column = "ARRAY(" + typ.column + ")"
+				typ.python = "List[" + typ.python + "]"
+			}
+			args = append(args, typ.column)
+			attr.Type = typ.python
+
+			if _, ok := models[col.ForeignTablename]; ok && col.IsReference {
+				imports.add("sqlalchemy:ForeignKey")
+				args = append(args, fmt.Sprintf("ForeignKey(%s)", strconv.Quote(col.ForeignTablename+"."+col.ForeignColumnname)))
+			}
+			if col.IsIdentity {
+				imports.add("sqlalchemy:Identity")
+				args = append(args, fmt.Sprintf("Identity(always=%s)", pythonBool(col.IdentityGeneration == "ALWAYS")))
+			}
+			if col.IsGenerated {
+				imports.add("sqlalchemy:Computed")
+				args = append(args, fmt.Sprintf("Computed(%s, persisted=True)", strconv.Quote(col.GenerationExpression)))
+			}
+			if col.IsPrimary {
+				args = append(args, "primary_key=True")
+			}
+			if col.IsUnique && !col.IsPrimary {
+				args = append(args, "unique=True")
+			}
+			if col.IsNullable {
+				if !model.Keyless {
+					imports.add("typing:Optional")
+				}
+				attr.Type = "Optional[" + attr.Type + "]"
+				args = append(args, "nullable=True")
+			}
+			if len(col.Comments) > 0 {
+				args = append(args, "comment="+strconv.Quote(col.Comments))
+			}
+			attr.Core = strings.Join(append([]string{strconv.Quote(col.Name)}, args...), ", ")
+			if attr.Name != col.Name {
+				args = append([]string{strconv.Quote(col.Name)}, args...)
+			}
+			attr.Args = strings.Join(args, ", ")
+			model.Columns = append(model.Columns, attr)
+		}
+	}
+
+	for _, table := range tables {
+		for _, col := range table.Columns {
+			foreign, ok := models[col.ForeignTablename]
+			if !ok || !col.IsReference || models[table.Name].Keyless || foreign.Keyless {
+				continue
+			}
+			imports.add("sqlalchemy.orm:relationship")
+			model := models[table.Name]
+			name := strings.TrimSuffix(col.Name, "_id")
+			if name == col.Name {
+				name += "_ref"
+			}
+			forward := uniqueAttribute(table.Name, name)
+			backward := uniqueAttribute(col.ForeignTablename, table.Name)
+			fk := model.Name + "." + columnAttributes[table.Name+"."+col.Name]
+
+			forwardType := strconv.Quote(foreign.Name)
+			if col.IsNullable {
+				forwardType = "Optional[" + forwardType + "]"
+			}
+			forwardArgs := []string{"back_populates=" + strconv.Quote(backward), "foreign_keys=" + strconv.Quote("["+fk+"]")}
+			if table.Name == col.ForeignTablename {
+				forwardArgs = append(forwardArgs, "remote_side="+strconv.Quote("["+foreign.Name+"."+columnAttributes[col.ForeignTablename+"."+col.ForeignColumnname]+"]"))
+			}
+			model.Relationships = append(model.Relationships, sqlalchemyAttribute{
+				Name: forward,
+				Type: forwardType,
+				Args: strings.Join(forwardArgs, ", "),
+			})
+
+			backwardType := "Optional[" + strconv.Quote(model.Name) + "]"
+			backwardArgs := "back_populates=" + strconv.Quote(forward) + ", foreign_keys=" + strconv.Quote("["+fk+"]")
+			if cardinality, _ := referenceCardinality(table, col); cardinality == CardinalityOneToOne {
+				imports.add("typing:Optional")
+				backwardArgs += ", uselist=False"
+			} else {
+				imports.add("typing:List")
+				backwardType = "List[" + strconv.Quote(model.Name) + "]"
+			}
+			foreign.Relationships = append(foreign.Relationships, sqlalchemyAttribute{
+				Name: backward,
+				Type: backwardType,
+				Args: backwardArgs,
+			})
+		}
+	}
+
+	for _, table := range tables {
+		file.Models = append(file.Models, *models[table.Name])
+	}
+	imports.render(&file)
+	return tmpl.ExecuteTemplate(w, "file", file)
+}
@@ -0,0 +1,93 @@
+package inverseschema
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var ErrTableNotFound = errors.New("table not found")
+
+// TableAdapter is implemented by adapters able to introspect a single table without listing the others
+type TableAdapter interface {
+	Table(ctx context.Context, name string) (*Table, error)
+}
+
+// fetchTable introspects a single table through Table when the adapter supports it, otherwise it is picked out of
+// Tables
+func fetchTable(ctx context.Context, adapter Adapter, name string) (*Table, error) {
+	if adapter, ok := adapter.(TableAdapter); ok {
+		return adapter.Table(ctx, name)
+	}
+	tables, err := adapter.Tables(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range tables {
+		if tables[i].Name == name {
+			return &tables[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrTableNotFound, name)
+}
+
+// ParseTable introspects a single table along with the enums its columns use, both replace their previous version
+// in the schema. Natural keys are detected, history pairing needs the whole schema and is left to ParseContext
+func (s *Schema) ParseTable(ctx context.Context, name string) (*Table, error) {
+	if s.objects != 0 {
+		ctx = ContextWithObjects(ctx, s.objects)
+	}
+	table, err := fetchTable(ctx, s.adapter, name)
+	if err != nil {
+		return nil, err
+	}
+	if keys := naturalKeys(*table); len(keys) > 0 {
+		table.NaturalKeys = keys
+	}
+
+	used := map[string]bool{}
+	for _, col := range table.Columns {
+		if col.IsUserDefined && col.UserDefinedType != nil {
+			used[col.UserDefinedType.Name] = true
+		}
+	}
+	if len(used) > 0 {
+		enums, err := s.adapter.Enums(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, enum := range enums {
+			if !used[enum.Name] {
+				continue
+			}
+			if existing, ok := s.EnumByName(enum.Name); ok {
+				*existing = enum
+			} else {
+				s.Enums = append(s.Enums, enum)
+			}
+		}
+	}
+
+	if existing, ok := s.TableByName(name); ok {
+		*existing = *table
+		return existing, nil
+	}
+	s.Tables = append(s.Tables, *table)
+	return &s.Tables[len(s.Tables)-1], nil
+}
+
+func (a *SnapshotAdapter) Table(ctx context.Context, name string) (*Table, error) {
+	if table, ok := a.snapshot.TableByName(name); ok {
+		copied := *table
+		return &copied, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrTableNotFound, name)
+}
+
+// Table fetches hot tables from live and the others from base
+func (a *OverlayAdapter) Table(ctx context.Context, name string) (*Table, error) {
+	if a.tablenames[name] {
+		return fetchTable(ctx, a.live, name)
+	}
+	return fetchTable(ctx, a.base, name)
+}
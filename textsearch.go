@@ -0,0 +1,100 @@
+package inverseschema
+
+import (
+	"regexp"
+	"strings"
+)
+
+var tsvectorConfigRe = regexp.MustCompile(`(?i)to_tsvector\(\s*(?:'([^']*)'(?:::regconfig)?\s*,)?`)
+
+// TextSearch describes how a tsvector column is maintained: Source is "generated" for generated columns and
+// "trigger" for the built in tsvector_update_trigger functions, Configuration is the text search configuration
+// ("default" when default_text_search_config applies, a column name prefixed with @ when read from a column), Columns
+// the columns the document is built from and Indexes the GIN or GiST indexes covering the column
+type TextSearch struct {
+	Source        string   `json:"source,omitempty"`
+	Configuration string   `json:"configuration,omitempty"`
+	Columns       []string `json:"columns,omitempty"`
+	Indexes       []string `json:"indexes,omitempty"`
+}
+
+// textSearchConfiguration returns the configuration of the first to_tsvector call of an expression
+func textSearchConfiguration(expression string) (string, bool) {
+	match := tsvectorConfigRe.FindStringSubmatch(expression)
+	if match == nil {
+		return "", false
+	}
+	if len(match[1]) == 0 {
+		return "default", true
+	}
+	return match[1], true
+}
+
+// expressionColumns lists the columns of a table referenced by an expression, in order of appearance
+func expressionColumns(expression string, table *Table) []string {
+	cols := []string{}
+	seen := map[string]bool{}
+	for _, token := range tokenizeSQL(expression, 1) {
+		if token.kind != sqlTokenIdent && token.kind != sqlTokenQuotedIdent {
+			continue
+		}
+		if _, ok := table.ColumnsByName[token.text]; ok && !seen[token.text] {
+			seen[token.text] = true
+			cols = append(cols, token.text)
+		}
+	}
+	return cols
+}
+
+// textSearchIndexes lists the GIN and GiST indexes whose key holds the column
+func textSearchIndexes(table *Table, columnname string) []string {
+	names := []string{}
+	for _, index := range table.Indexes {
+		if index.Method != "gin" && index.Method != "gist" {
+			continue
+		}
+		for _, key := range index.Columns {
+			if key == columnname || key == quoteIdent(columnname) {
+				names = append(names, index.Name)
+				break
+			}
+		}
+	}
+	return names
+}
+
+// applyTextSearch describes the tsvector columns of a table, triggers maps a tsvector column to the arguments of
+// the tsvector_update_trigger maintaining it
+func applyTextSearch(table *Table, triggers map[string][]string) {
+	for name, col := range table.ColumnsByName {
+		if strings.ToLower(col.DatatypeRaw) != "tsvector" || col.IsArray {
+			continue
+		}
+		ts := &TextSearch{}
+		if col.IsGenerated {
+			ts.Source = "generated"
+			ts.Configuration, _ = textSearchConfiguration(col.GenerationExpression)
+			ts.Columns = expressionColumns(col.GenerationExpression, table)
+		} else if args, ok := triggers[name]; ok && len(args) >= 2 {
+			ts.Source = "trigger"
+			ts.Configuration = args[0]
+			ts.Columns = args[1:]
+		}
+		if indexes := textSearchIndexes(table, name); len(indexes) > 0 {
+			ts.Indexes = indexes
+		}
+		col.TextSearch = ts
+		table.ColumnsByName[name] = col
+	}
+	for i, index := range table.Indexes {
+		if index.Method != "gin" && index.Method != "gist" {
+			continue
+		}
+		for _, key := range index.Columns {
+			if config, ok := textSearchConfiguration(key); ok {
+				table.Indexes[i].TextSearchConfiguration = config
+				break
+			}
+		}
+	}
+}
@@ -19,6 +19,61 @@ type Table struct {
 	Name          string            `json:"name,omitempty"`
 	Columns       []Column          `json:"columns,omitempty"`
 	ColumnsByName map[string]Column `json:"columns_by_name,omitempty"`
+	Comments      string            `json:"comments,omitempty"`
+	Owner         string            `json:"owner,omitempty"`
+	AppName       string            `json:"app_name,omitempty"`
+	Stats         *TableStats       `json:"stats,omitempty"`
+	Indexes       []Index           `json:"indexes,omitempty"`
+	// Checks are the CHECK constraints referencing no column, column checks are kept on their columns
+	Checks         []Constraint   `json:"checks,omitempty"`
+	Partitioning   *Partitioning  `json:"partitioning,omitempty"`
+	PartitionOf    string         `json:"partition_of,omitempty"`
+	PartitionBound string         `json:"partition_bound,omitempty"`
+	HistoryOf      string         `json:"history_of,omitempty"`
+	HistoryTable   string         `json:"history_table,omitempty"`
+	HistoryPeriod  *HistoryPeriod `json:"history_period,omitempty"`
+	// NaturalKeys are the candidate natural keys of the table, unique and not nullable column sets without surrogate columns
+	NaturalKeys [][]string `json:"natural_keys,omitempty"`
+	// IsView marks a view parsed as a read-only table, ViewDefinition holds its query
+	IsView         bool   `json:"is_view,omitempty"`
+	ViewDefinition string `json:"view_definition,omitempty"`
+	// DroppedColumns are the attribute numbers of dropped columns, rows written before the drop still hold them
+	DroppedColumns []int `json:"dropped_columns,omitempty"`
+	// SystemColumns are the hidden system columns, only listed on request
+	SystemColumns []Column `json:"system_columns,omitempty"`
+	// Extension is the extension which created the table
+	Extension string `json:"extension,omitempty"`
+}
+
+type Partitioning struct {
+	Strategy   string   `json:"strategy,omitempty"`
+	Columns    []string `json:"columns,omitempty"`
+	Definition string   `json:"definition,omitempty"`
+}
+
+type Index struct {
+	Name       string   `json:"name,omitempty"`
+	Columns    []string `json:"columns,omitempty"`
+	IsUnique   bool     `json:"is_unique,omitempty"`
+	IsPrimary  bool     `json:"is_primary,omitempty"`
+	Method     string   `json:"method,omitempty"`
+	Predicate  string   `json:"predicate,omitempty"`
+	Definition string   `json:"definition,omitempty"`
+	SizeBytes  int64    `json:"size_bytes,omitempty"`
+	// TextSearchConfiguration is the configuration of GIN and GiST indexes over a to_tsvector expression
+	TextSearchConfiguration string `json:"text_search_configuration,omitempty"`
+}
+
+type TableStats struct {
+	SeqScans           int64 `json:"seq_scans,omitempty"`
+	SeqTuplesRead      int64 `json:"seq_tuples_read,omitempty"`
+	IndexScans         int64 `json:"index_scans,omitempty"`
+	IndexTuplesFetched int64 `json:"index_tuples_fetched,omitempty"`
+	TuplesInserted     int64 `json:"tuples_inserted,omitempty"`
+	TuplesUpdated      int64 `json:"tuples_updated,omitempty"`
+	TuplesDeleted      int64 `json:"tuples_deleted,omitempty"`
+	LiveTuples         int64 `json:"live_tuples,omitempty"`
+	DeadTuples         int64 `json:"dead_tuples,omitempty"`
 }
 type Constraint struct {
 	Name              string         `json:"name,omitempty"`
@@ -27,6 +82,13 @@ type Constraint struct {
 	Columnname        string         `json:"columnname,omitempty"`
 	ForeignTablename  string         `json:"foreign_tablename,omitempty"`
 	ForeignColumnname string         `json:"foreign_columnname,omitempty"`
+	Definition        string         `json:"definition,omitempty"`
+	// Position is the place of the column in the key of the constraint, starting at 1
+	Position int `json:"position,omitempty"`
+	// IsDeferrable marks constraints declared DEFERRABLE, they cannot serve as ON CONFLICT arbiters
+	IsDeferrable bool `json:"is_deferrable,omitempty"`
+	// IsInherited marks constraints cloned from a partitioned parent or inherited from a parent table
+	IsInherited bool `json:"is_inherited,omitempty"`
 }
 
 type UserDefinedType struct {
@@ -35,16 +97,22 @@ type UserDefinedType struct {
 }
 
 type Column struct {
-	OrdinalPosition    int              `json:"ordinal_position,omitempty"`
-	Name               string           `json:"name,omitempty"`
-	Constraints        []Constraint     `json:"constraints,omitempty"`
-	IsReference        bool             `json:"is_reference,omitempty"`
-	ForeignTablename   string           `json:"foreign_tablename,omitempty"`
-	ForeignColumnname  string           `json:"foreign_columnname,omitempty"`
-	IsPrimary          bool             `json:"is_primary,omitempty"`
-	IsUnique           bool             `json:"is_unique,omitempty"`
-	HasDefault         bool             `json:"has_default,omitempty"`
-	Default            string           `json:"default,omitempty"`
+	OrdinalPosition int `json:"ordinal_position,omitempty"`
+	// AttNum is the physical attribute number, dropped columns leave gaps in it which OrdinalPosition closes
+	AttNum            int          `json:"attnum,omitempty"`
+	Name              string       `json:"name,omitempty"`
+	Constraints       []Constraint `json:"constraints,omitempty"`
+	IsReference       bool         `json:"is_reference,omitempty"`
+	ForeignTablename  string       `json:"foreign_tablename,omitempty"`
+	ForeignColumnname string       `json:"foreign_columnname,omitempty"`
+	IsPrimary         bool         `json:"is_primary,omitempty"`
+	IsUnique          bool         `json:"is_unique,omitempty"`
+	HasDefault        bool         `json:"has_default,omitempty"`
+	Default           string       `json:"default,omitempty"`
+	// DefaultInherited is set when the default matches the one of the parent table
+	DefaultInherited bool `json:"default_inherited,omitempty"`
+	// IsInherited marks columns defined by a parent table only
+	IsInherited        bool             `json:"is_inherited,omitempty"`
 	IsNullable         bool             `json:"is_nullable,omitempty"`
 	DatatypeRaw        string           `json:"datatype_raw,omitempty"`
 	Datatype           Datatype         `json:"datatype,omitempty"`
@@ -52,17 +120,55 @@ type Column struct {
 	IsArray            bool             `json:"is_array,omitempty"`
 	CharacterMaxLength int              `json:"character_max_length,omitempty"`
 	UserDefinedType    *UserDefinedType `json:"user_defined_type,omitempty"`
-	Comments           string           `json:"comments,omitempty"`
+	// TypeKind, TypeCategory and BaseType classify unknown and user defined types from the database catalog
+	TypeKind     string `json:"type_kind,omitempty"`
+	TypeCategory string `json:"type_category,omitempty"`
+	BaseType     string `json:"base_type,omitempty"`
+	// FormattedType is the type as declared, with its length, precision and array dimensions, TypeDefinition the
+	// statement creating the domain or composite type of the column (of its elements for arrays) when it belongs to the
+	// parsed schema
+	FormattedType        string       `json:"formatted_type,omitempty"`
+	TypeDefinition       string       `json:"type_definition,omitempty"`
+	Comments             string       `json:"comments,omitempty"`
+	SecurityLabel        string       `json:"security_label,omitempty"`
+	IsEncrypted          bool         `json:"is_encrypted,omitempty"`
+	EncryptionKey        string       `json:"encryption_key,omitempty"`
+	IsVirtual            bool         `json:"is_virtual,omitempty"`
+	AppName              string       `json:"app_name,omitempty"`
+	Restriction          *Restriction `json:"restriction,omitempty"`
+	IsIdentity           bool         `json:"is_identity,omitempty"`
+	IdentityGeneration   string       `json:"identity_generation,omitempty"`
+	IsGenerated          bool         `json:"is_generated,omitempty"`
+	GenerationExpression string       `json:"generation_expression,omitempty"`
+	// Fields holds the nested fields of a struct column, for sources with nested types such as Delta Lake and Iceberg
+	// and for jsonb payloads described by JSONSchemaHook
+	Fields []Column `json:"fields,omitempty"`
+	// InferredShape is the structure of sampled json values, only present when profiling was requested
+	InferredShape *JSONShape  `json:"inferred_shape,omitempty"`
+	TextSearch    *TextSearch `json:"text_search,omitempty"`
+	// DerivedFrom lists the source columns of a derived column declared with ApplyLineage
+	DerivedFrom []string `json:"derived_from,omitempty"`
+}
+
+// Required tells whether a value must be provided when inserting a row: the column does not accept NULL and has no
+// default, identity nor generation expression (serial columns have a nextval default)
+func (c Column) Required() bool {
+	return !c.IsNullable && !c.HasDefault && !c.IsIdentity && !c.IsGenerated
 }
 
 type Enum struct {
-	Name   string      `json:"name,omitempty"`
-	Values []EnumValue `json:"values,omitempty"`
+	Name     string      `json:"name,omitempty"`
+	Values   []EnumValue `json:"values,omitempty"`
+	Comments string      `json:"comments,omitempty"`
+	// Extension is the extension which created the enum
+	Extension string `json:"extension,omitempty"`
 }
 
 type EnumValue struct {
-	Label string `json:"label,omitempty"`
-	Order int    `json:"order,omitempty"`
+	Label       string `json:"label,omitempty"`
+	Order       int    `json:"order,omitempty"`
+	Deprecated  bool   `json:"deprecated,omitempty"`
+	RenamedFrom string `json:"renamed_from,omitempty"`
 }
 
 type Adapter interface {
@@ -91,4 +197,34 @@ const (
 	DatatypeTimestamp
 	DatatypeTimestampz
 	DatatypeUuid
+	DatatypeBytea
 )
+
+var datatypeNames = map[Datatype]string{
+	DatatypeUnknown:         "unknown",
+	DatatypeUserdefined:     "userdefined",
+	DatatypeArray:           "array",
+	DatatypeBigint:          "bigint",
+	DatatypeInt:             "int",
+	DatatypeSmallint:        "smallint",
+	DatatypeDecimal:         "decimal",
+	DatatypeNumeric:         "numeric",
+	DatatypeVariableNumeric: "variable_numeric",
+	DatatypeJsonb:           "jsonb",
+	DatatypeJson:            "json",
+	DatatypeText:            "text",
+	DatatypeVarchar:         "varchar",
+	DatatypeBoolean:         "boolean",
+	DatatypeDate:            "date",
+	DatatypeTimestamp:       "timestamp",
+	DatatypeTimestampz:      "timestampz",
+	DatatypeUuid:            "uuid",
+	DatatypeBytea:           "bytea",
+}
+
+func (d Datatype) String() string {
+	if name, ok := datatypeNames[d]; ok {
+		return name
+	}
+	return datatypeNames[DatatypeUnknown]
+}
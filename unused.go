@@ -0,0 +1,142 @@
+package inverseschema
+
+import (
+	"context"
+	"errors"
+	"regexp"
+)
+
+type UnusedTable struct {
+	Tablename string `json:"tablename,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+type UnusedColumn struct {
+	Tablename  string `json:"tablename,omitempty"`
+	Columnname string `json:"columnname,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+}
+
+type UnusedSequence struct {
+	Name   string `json:"name,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+type UnusedReport struct {
+	Tables    []UnusedTable    `json:"tables,omitempty"`
+	Columns   []UnusedColumn   `json:"columns,omitempty"`
+	Sequences []UnusedSequence `json:"sequences,omitempty"`
+}
+
+// SequenceUsage tells whether a sequence is owned by a column (serial and identity columns) and whether a default,
+// view or function is recorded as calling it
+type SequenceUsage struct {
+	Name  string `json:"name,omitempty"`
+	Owned bool   `json:"owned,omitempty"`
+	Used  bool   `json:"used,omitempty"`
+}
+
+// ObjectUsage is what the database catalog knows of the use of columns and sequences: the columns views, policies,
+// triggers, indexes and other columns depend on, the columns statistics found always null and the sequences
+type ObjectUsage struct {
+	DependedColumns []ColumnRef     `json:"depended_columns,omitempty"`
+	NullColumns     []ColumnRef     `json:"null_columns,omitempty"`
+	Sequences       []SequenceUsage `json:"sequences,omitempty"`
+}
+
+// UsageAdapter is implemented by adapters able to tell which columns and sequences the database itself uses
+type UsageAdapter interface {
+	Usage(ctx context.Context) (*ObjectUsage, error)
+}
+
+func (m middlewareAdapter) Usage(ctx context.Context) (*ObjectUsage, error) {
+	adapter, ok := m.next.(UsageAdapter)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	return adapter.Usage(ctx)
+}
+
+// Unused is UnusedContext without a context
+func (s *Schema) Unused() (*UnusedReport, error) {
+	return s.UnusedContext(context.Background())
+}
+
+// UnusedContext reports tables which were never read nor written according to their activity statistics (tables
+// parsed without statistics are skipped) and are not referenced by foreign keys. When the adapter implements
+// UsageAdapter it also reports the columns statistics found always null which no constraint, reference, index, view
+// or other database object uses, and the sequences neither owned by a column nor called by a default
+func (s *Schema) UnusedContext(ctx context.Context) (*UnusedReport, error) {
+	report := &UnusedReport{Tables: []UnusedTable{}, Columns: []UnusedColumn{}, Sequences: []UnusedSequence{}}
+
+	referencedTables := map[string]bool{}
+	used := map[ColumnRef]bool{}
+	for _, table := range s.Tables {
+		for _, col := range table.Columns {
+			if col.IsReference && col.ForeignTablename != table.Name {
+				referencedTables[col.ForeignTablename] = true
+				used[ColumnRef{Tablename: col.ForeignTablename, Columnname: col.ForeignColumnname}] = true
+			}
+		}
+	}
+
+	for _, table := range s.Tables {
+		if stats := table.Stats; stats != nil && !referencedTables[table.Name] {
+			reads := stats.SeqScans + stats.IndexScans
+			writes := stats.TuplesInserted + stats.TuplesUpdated + stats.TuplesDeleted
+			switch {
+			case reads == 0 && writes == 0:
+				report.Tables = append(report.Tables, UnusedTable{Tablename: table.Name, Reason: "never read nor written"})
+			case reads == 0:
+				report.Tables = append(report.Tables, UnusedTable{Tablename: table.Name, Reason: "written but never read"})
+			}
+		}
+	}
+
+	adapter, ok := s.adapter.(UsageAdapter)
+	if !ok {
+		return report, nil
+	}
+	usage, err := adapter.Usage(ctx)
+	if errors.Is(err, ErrNotSupported) {
+		return report, nil
+	} else if err != nil {
+		return nil, err
+	}
+	for _, ref := range usage.DependedColumns {
+		used[ref] = true
+	}
+	for _, ref := range usage.NullColumns {
+		table, ok := s.TableByName(ref.Tablename)
+		if !ok || used[ref] {
+			continue
+		}
+		col, ok := table.ColumnsByName[ref.Columnname]
+		if !ok || col.IsVirtual || len(col.Constraints) > 0 || col.IsIdentity || col.IsGenerated || indexedColumn(*table, col.Name) {
+			continue
+		}
+		report.Columns = append(report.Columns, UnusedColumn{Tablename: ref.Tablename, Columnname: ref.Columnname, Reason: "always null and used by no other object"})
+	}
+	for _, sequence := range usage.Sequences {
+		if !sequence.Owned && !sequence.Used {
+			report.Sequences = append(report.Sequences, UnusedSequence{Name: sequence.Name, Reason: "neither owned by a column nor called by a default"})
+		}
+	}
+	return report, nil
+}
+
+// indexedColumn tells whether an index of the table covers the column, in its keys, an expression or its predicate
+func indexedColumn(table Table, columnname string) bool {
+	word := regexp.MustCompile(`(^|[^A-Za-z0-9_$])"?` + regexp.QuoteMeta(columnname) + `"?($|[^A-Za-z0-9_$])`)
+	for _, index := range table.Indexes {
+		for _, key := range index.Columns {
+			if key == columnname || word.MatchString(key) {
+				return true
+			}
+		}
+		if word.MatchString(index.Predicate) {
+			return true
+		}
+	}
+	return false
+}
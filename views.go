@@ -0,0 +1,50 @@
+package inverseschema
+
+import "context"
+
+// ViewAdapter is implemented by adapters able to describe views as read-only tables, with the nullability and keys
+// of the underlying columns where they can be resolved
+type ViewAdapter interface {
+	Views(ctx context.Context) ([]Table, error)
+}
+
+func (s *Schema) ParseViews(ctx context.Context) error {
+	adapter, ok := s.adapter.(ViewAdapter)
+	if !ok {
+		return ErrNotSupported
+	}
+	views, err := adapter.Views(ctx)
+	if err != nil {
+		return err
+	}
+	s.Views = views
+	return nil
+}
+
+// WithViews makes generators render the parsed views after the tables as read-only models, without setters or
+// insert and update mappings. Views without a resolved key stay keyless and are rendered as immutable rows instead
+// of entities
+func WithViews() GeneratorOption {
+	return func(o *generatorOptions) {
+		o.views = true
+	}
+}
+
+// tables lists the tables a generator renders, views are flagged and left keyed as resolved
+func (o *generatorOptions) tables(s *Schema) []Table {
+	if !o.views || len(s.Views) == 0 {
+		return s.Tables
+	}
+	tables := make([]Table, 0, len(s.Tables)+len(s.Views))
+	tables = append(tables, s.Tables...)
+	for _, view := range s.Views {
+		view.IsView = true
+		tables = append(tables, view)
+	}
+	return tables
+}
+
+// keylessView tells whether a view has no key an ORM could use as identity
+func keylessView(table Table) bool {
+	return table.IsView && len(primaryKeyColumns(table)) == 0
+}
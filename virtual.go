@@ -0,0 +1,30 @@
+package inverseschema
+
+// mergeVirtualColumns appends virtual columns to a table, columns without an ordinal position are placed after the real ones,
+// a virtual column never replaces an introspected column of the same name
+func mergeVirtualColumns(table *Table, cols []Column) {
+	if len(cols) == 0 {
+		return
+	}
+	position := 0
+	for _, col := range table.Columns {
+		if col.OrdinalPosition > position {
+			position = col.OrdinalPosition
+		}
+	}
+	if table.ColumnsByName == nil {
+		table.ColumnsByName = make(map[string]Column, len(cols))
+	}
+	for _, col := range cols {
+		if _, ok := table.ColumnsByName[col.Name]; ok {
+			continue
+		}
+		col.IsVirtual = true
+		if col.OrdinalPosition == 0 {
+			position++
+			col.OrdinalPosition = position
+		}
+		table.Columns = append(table.Columns, col)
+		table.ColumnsByName[col.Name] = col
+	}
+}
@@ -0,0 +1,221 @@
+package inverseschema
+
+import (
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const xsdNamespace = "http://www.w3.org/2001/XMLSchema"
+
+var xsdDatatypes = map[Datatype]string{
+	DatatypeBigint:          "xs:long",
+	DatatypeInt:             "xs:int",
+	DatatypeSmallint:        "xs:short",
+	DatatypeDecimal:         "xs:decimal",
+	DatatypeNumeric:         "xs:decimal",
+	DatatypeVariableNumeric: "xs:decimal",
+	DatatypeJsonb:           "xs:string",
+	DatatypeJson:            "xs:string",
+	DatatypeText:            "xs:string",
+	DatatypeVarchar:         "xs:string",
+	DatatypeBoolean:         "xs:boolean",
+	DatatypeDate:            "xs:date",
+	DatatypeTimestamp:       "xs:dateTime",
+	DatatypeTimestampz:      "xs:dateTime",
+	DatatypeUuid:            "xs:string",
+	DatatypeBytea:           "xs:base64Binary",
+}
+
+type xsdSchema struct {
+	XMLName            xml.Name         `xml:"xs:schema"`
+	XS                 string           `xml:"xmlns:xs,attr"`
+	TNS                string           `xml:"xmlns:tns,attr"`
+	TargetNamespace    string           `xml:"targetNamespace,attr"`
+	ElementFormDefault string           `xml:"elementFormDefault,attr"`
+	SimpleTypes        []xsdSimpleType  `xml:"xs:simpleType"`
+	ComplexTypes       []xsdComplexType `xml:"xs:complexType"`
+}
+
+type xsdAnnotation struct {
+	Documentation string `xml:"xs:documentation"`
+}
+
+type xsdSimpleType struct {
+	Name        string         `xml:"name,attr,omitempty"`
+	Annotation  *xsdAnnotation `xml:"xs:annotation,omitempty"`
+	Restriction xsdRestriction `xml:"xs:restriction"`
+}
+
+type xsdRestriction struct {
+	Base         string     `xml:"base,attr"`
+	Enumerations []xsdValue `xml:"xs:enumeration,omitempty"`
+	MinLength    *xsdValue  `xml:"xs:minLength,omitempty"`
+	MaxLength    *xsdValue  `xml:"xs:maxLength,omitempty"`
+	MinInclusive *xsdValue  `xml:"xs:minInclusive,omitempty"`
+	MinExclusive *xsdValue  `xml:"xs:minExclusive,omitempty"`
+	MaxInclusive *xsdValue  `xml:"xs:maxInclusive,omitempty"`
+	MaxExclusive *xsdValue  `xml:"xs:maxExclusive,omitempty"`
+	Pattern      *xsdValue  `xml:"xs:pattern,omitempty"`
+}
+
+// xsdPattern converts a postgres regular expression, unanchored unless it uses ^ and $, to an XSD pattern which
+// always matches the whole value
+func xsdPattern(pattern string) string {
+	if strings.HasPrefix(pattern, "^") {
+		pattern = strings.TrimPrefix(pattern, "^")
+	} else {
+		pattern = ".*" + pattern
+	}
+	if strings.HasSuffix(pattern, "$") && !strings.HasSuffix(pattern, `\$`) {
+		pattern = strings.TrimSuffix(pattern, "$")
+	} else {
+		pattern += ".*"
+	}
+	return pattern
+}
+
+// xsdColumnRestriction restricts the base type of a column by its varchar length and check constraint restriction,
+// nil when the column is unrestricted
+func xsdColumnRestriction(base string, col Column) *xsdRestriction {
+	restriction := &xsdRestriction{Base: base}
+	restricted := false
+	if col.Datatype == DatatypeVarchar && col.CharacterMaxLength > 0 {
+		restriction.MaxLength = &xsdValue{Value: strconv.Itoa(col.CharacterMaxLength)}
+		restricted = true
+	}
+	r := col.Restriction
+	if r == nil {
+		if restricted {
+			return restriction
+		}
+		return nil
+	}
+	for _, v := range r.Values {
+		restriction.Enumerations = append(restriction.Enumerations, xsdValue{Value: v})
+	}
+	if r.Minimum != nil {
+		value := &xsdValue{Value: strconv.FormatFloat(*r.Minimum, 'f', -1, 64)}
+		if r.ExclusiveMinimum {
+			restriction.MinExclusive = value
+		} else {
+			restriction.MinInclusive = value
+		}
+	}
+	if r.Maximum != nil {
+		value := &xsdValue{Value: strconv.FormatFloat(*r.Maximum, 'f', -1, 64)}
+		if r.ExclusiveMaximum {
+			restriction.MaxExclusive = value
+		} else {
+			restriction.MaxInclusive = value
+		}
+	}
+	if r.MinLength != nil {
+		restriction.MinLength = &xsdValue{Value: strconv.Itoa(*r.MinLength)}
+	}
+	if r.MaxLength != nil && (restriction.MaxLength == nil || *r.MaxLength < col.CharacterMaxLength) {
+		restriction.MaxLength = &xsdValue{Value: strconv.Itoa(*r.MaxLength)}
+	}
+	if len(r.Pattern) > 0 {
+		restriction.Pattern = &xsdValue{Value: xsdPattern(r.Pattern)}
+	}
+	return restriction
+}
+
+type xsdValue struct {
+	Value string `xml:"value,attr"`
+}
+
+type xsdComplexType struct {
+	Name       string         `xml:"name,attr"`
+	Annotation *xsdAnnotation `xml:"xs:annotation,omitempty"`
+	Elements   []xsdElement   `xml:"xs:sequence>xs:element"`
+}
+
+type xsdElement struct {
+	Name       string         `xml:"name,attr"`
+	Type       string         `xml:"type,attr,omitempty"`
+	MinOccurs  string         `xml:"minOccurs,attr,omitempty"`
+	MaxOccurs  string         `xml:"maxOccurs,attr,omitempty"`
+	Nillable   bool           `xml:"nillable,attr,omitempty"`
+	Annotation *xsdAnnotation `xml:"xs:annotation,omitempty"`
+	SimpleType *xsdSimpleType `xml:"xs:simpleType,omitempty"`
+}
+
+func xsdDocumentation(comment string) *xsdAnnotation {
+	if len(comment) == 0 {
+		return nil
+	}
+	return &xsdAnnotation{Documentation: comment}
+}
+
+// WriteXSD emits an XML Schema mapping tables to complexTypes and enums to simpleType restrictions,
+// the package option sets the target namespace
+func (s *Schema) WriteXSD(w io.Writer, opts ...GeneratorOption) error {
+	o := newGeneratorOptions("urn:inverseschema", opts)
+	doc := xsdSchema{
+		XS:                 xsdNamespace,
+		TNS:                o.packageName,
+		TargetNamespace:    o.packageName,
+		ElementFormDefault: "qualified",
+	}
+
+	collisions := newNameCollisions("xsd")
+	enumTypes := map[string]string{}
+	for _, enum := range s.Enums {
+		st := xsdSimpleType{
+			Name:        pascalCase(enum.Name),
+			Annotation:  xsdDocumentation(enum.Comments),
+			Restriction: xsdRestriction{Base: "xs:string"},
+		}
+		for _, value := range enum.Values {
+			st.Restriction.Enumerations = append(st.Restriction.Enumerations, xsdValue{Value: value.Label})
+		}
+		collisions.addEnum(st.Name, enum)
+		enumTypes[enum.Name] = "tns:" + st.Name
+		doc.SimpleTypes = append(doc.SimpleTypes, st)
+	}
+
+	for _, table := range s.Tables {
+		ct := xsdComplexType{Name: tableTypeName(table), Annotation: xsdDocumentation(table.Comments)}
+		collisions.addTable(ct.Name, table)
+		for _, col := range table.Columns {
+			col = opaqueColumn(col)
+			el := xsdElement{Name: columnAttributeName(col), Type: "xs:string", Nillable: col.IsNullable, Annotation: xsdDocumentation(col.Comments)}
+			if col.IsUserDefined && col.UserDefinedType != nil {
+				if enumType, ok := enumTypes[col.UserDefinedType.Name]; ok {
+					el.Type = enumType
+				}
+			} else if t, ok := xsdDatatypes[col.Datatype]; ok {
+				el.Type = t
+			}
+			if restriction := xsdColumnRestriction(el.Type, col); restriction != nil {
+				el.SimpleType = &xsdSimpleType{Restriction: *restriction}
+				el.Type = ""
+			}
+			if !col.Required() || col.IsArray {
+				el.MinOccurs = "0"
+			}
+			if col.IsArray {
+				el.MaxOccurs = "unbounded"
+			}
+			ct.Elements = append(ct.Elements, el)
+		}
+		doc.ComplexTypes = append(doc.ComplexTypes, ct)
+	}
+	if err := collisions.err(); err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}